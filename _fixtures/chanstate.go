@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func main() {
+	// the buffer of ch1 wraps around: it contains 2, 3, 4 starting at index 2
+	ch1 := make(chan int, 4)
+	for i := 0; i < 4; i++ {
+		ch1 <- i
+	}
+	<-ch1
+	<-ch1
+	ch1 <- 4
+
+	ch2 := make(chan string)
+	for i := 0; i < 2; i++ {
+		go func() {
+			fmt.Println(<-ch2)
+		}()
+	}
+
+	ch3 := make(chan int)
+	go func() {
+		ch3 <- 1
+	}()
+
+	ch4 := make(chan struct{}, 1)
+	close(ch4)
+
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	fmt.Println(len(ch1), ch2, ch3, ch4)
+}
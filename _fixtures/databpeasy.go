@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var globalvar1 = 0
+var globalvar2 = 0
+
+func main() { // Position 0
+	runtime.LockOSThread()
+	globalvar1 = 2
+	globalvar2 = globalvar1 + 1
+	globalvar1 = globalvar2 + 1
+	fmt.Printf("%d %d\n", globalvar1, globalvar2) // Position 1
+	runtime.Breakpoint()
+	f()
+	fmt.Printf("done\n") // Position 3
+}
+
+func f() {
+	localvar := 1
+	localvar++ // Position 2
+	localvar = localvar * 3
+	fmt.Println(localvar)
+}
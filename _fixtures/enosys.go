@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func main() {
+	// there is no system call 1000, the kernel fails it with ENOSYS
+	_, _, errno := syscall.Syscall(1000, 0, 0, 0)
+	fmt.Println(errno)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+type astruct struct {
+	X int
+}
+
+//go:noinline
+func (a astruct) add(n int) int {
+	return a.X + n
+}
+
+//go:noinline
+func callstacktrace() string {
+	return string(debugStack())
+}
+
+//go:noinline
+func debugStack() []byte {
+	buf := make([]byte, 1024)
+	return buf[:runtime.Stack(buf, false)]
+}
+
+//go:noinline
+func sum(a, b int) int {
+	return a + b
+}
+
+//go:noinline
+func repeat(s string, n int) (string, int) {
+	return strings.Repeat(s, n), len(s) * n
+}
+
+//go:noinline
+func callpanic() {
+	panic("callpanic panicked")
+}
+
+func main() {
+	one, two := 1, 2
+	a := astruct{X: 3}
+	runtime.Breakpoint()
+	fmt.Println(one, two, a.add(two), sum(one, two), callstacktrace())
+	fmt.Println(repeat("ab", two))
+	defer func() { recover() }()
+	callpanic()
+}
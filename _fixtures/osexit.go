@@ -0,0 +1,8 @@
+package main
+
+import "os"
+
+func main() {
+	code := 3
+	os.Exit(code)
+}
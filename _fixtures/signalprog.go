@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	fmt.Println("got", <-ch)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	fmt.Println("got", <-ch)
+}
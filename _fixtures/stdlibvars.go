@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"runtime"
+	"sync"
+	"time"
+)
+
+func main() {
+	t1 := time.Date(2017, 10, 16, 12, 0, 0, 0, time.UTC)
+	t2 := time.Date(2017, 10, 16, 12, 0, 0, 500, time.FixedZone("CEST", 2*60*60))
+	d1 := 1500 * time.Millisecond
+	b1, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	ip1 := net.ParseIP("192.168.0.1")
+	_, ipnet1, _ := net.ParseCIDR("10.0.0.0/8")
+	var mu1, mu2 sync.Mutex
+	mu1.Lock()
+	var buf1 bytes.Buffer
+	buf1.WriteString("hello world")
+	buf1.ReadByte()
+	runtime.Breakpoint()
+	fmt.Println(t1, t2, d1, b1, ip1, ipnet1, &mu1, &mu2, buf1.Len())
+}
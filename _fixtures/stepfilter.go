@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func less(v []int, i, j int) bool {
+	return v[i] < v[j]
+}
+
+func main() {
+	v := []int{3, 1, 2}
+	sort.Slice(v, func(i, j int) bool { return less(v, i, j) })
+	fmt.Println(v)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+//go:noinline
+func read(n int) int {
+	return n + 1
+}
+
+//go:noinline
+func parse(n int) int {
+	return n * 2
+}
+
+//go:noinline
+func handle(n int) int {
+	return n - 3
+}
+
+func main() {
+	r := 1
+	x := handle(parse(read(r)))
+	fmt.Println(x)
+	y := read(read(x))
+	fmt.Println(y)
+}
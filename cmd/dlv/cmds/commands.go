@@ -13,11 +13,13 @@ import (
 	"syscall"
 
 	"github.com/derekparker/delve/pkg/config"
+	"github.com/derekparker/delve/pkg/gobuild"
 	"github.com/derekparker/delve/pkg/goversion"
 	"github.com/derekparker/delve/pkg/terminal"
 	"github.com/derekparker/delve/pkg/version"
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
+	"github.com/derekparker/delve/service/debugger"
 	"github.com/derekparker/delve/service/rpc2"
 	"github.com/derekparker/delve/service/rpccommon"
 	"github.com/spf13/cobra"
@@ -170,7 +172,7 @@ consider compiling debugging binaries with -gcflags="-N -l".`,
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			os.Exit(execute(0, args, conf, "", executingExistingFile))
+			os.Exit(execute(0, args, conf, "", debugger.ExecutingExistingFile, ""))
 		},
 	}
 	RootCommand.AddCommand(execCommand)
@@ -209,7 +211,10 @@ that package instead.`,
 The trace sub command will set a tracepoint on every function matching the
 provided regular expression and output information when tracepoint is hit.  This
 is useful if you do not want to begin an entire debug session, but merely want
-to know what functions your process is executing.`,
+to know what functions your process is executing.
+
+When a traced function returns the values it returned are also printed, on a
+line containing '=>'.`,
 		Run: traceCmd,
 	}
 	traceCommand.Flags().IntVarP(&traceAttachPid, "pid", "p", 0, "Pid to attach to.")
@@ -261,7 +266,7 @@ https://github.com/mozilla/rr
 			},
 			Run: func(cmd *cobra.Command, args []string) {
 				Backend = "rr"
-				os.Exit(execute(0, []string{}, conf, args[0], executingOther))
+				os.Exit(execute(0, []string{}, conf, args[0], debugger.ExecutingOther, ""))
 			},
 		}
 		RootCommand.AddCommand(replayCommand)
@@ -278,7 +283,7 @@ func debugCmd(cmd *cobra.Command, args []string) {
 		if len(dlvArgs) > 0 {
 			pkg = args[0]
 		}
-		err := gobuild(debugname, pkg)
+		err := gobuild.GoBuild(debugname, pkg, BuildFlags)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			return 1
@@ -295,7 +300,7 @@ func debugCmd(cmd *cobra.Command, args []string) {
 			return 1
 		}
 		processArgs := append([]string{abs}, targetArgs...)
-		return execute(0, processArgs, conf, "", executingGeneratedFile)
+		return execute(0, processArgs, conf, "", debugger.ExecutingGeneratedFile, pkg)
 	}()
 	os.Exit(status)
 }
@@ -316,7 +321,7 @@ func traceCmd(cmd *cobra.Command, args []string) {
 				pkg = args[0]
 				regexp = args[1]
 			}
-			if err := gobuild(debugname, pkg); err != nil {
+			if err := gobuild.GoBuild(debugname, pkg, BuildFlags); err != nil {
 				return 1
 			}
 			defer os.Remove("./" + debugname)
@@ -356,6 +361,19 @@ func traceCmd(cmd *cobra.Command, args []string) {
 				fmt.Fprintln(os.Stderr, err)
 				return 1
 			}
+			addrs, err := client.FunctionReturnLocations(funcs[i])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			if len(addrs) == 0 {
+				continue
+			}
+			_, err = client.CreateBreakpoint(&api.Breakpoint{Addr: addrs[0], Addrs: addrs, Tracepoint: true, TraceReturn: true, Stacktrace: traceStackDepth})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
 		}
 		cmds := terminal.DebugCommands(client)
 		t := terminal.New(client, nil)
@@ -378,14 +396,14 @@ func testCmd(cmd *cobra.Command, args []string) {
 		if len(dlvArgs) > 0 {
 			pkg = args[0]
 		}
-		err := gotestbuild(pkg)
+		err := gobuild.GoTestBuild(testdebugname, pkg, BuildFlags)
 		if err != nil {
 			return 1
 		}
 		defer os.Remove("./" + testdebugname)
 		processArgs := append([]string{"./" + testdebugname}, targetArgs...)
 
-		return execute(0, processArgs, conf, "", executingGeneratedTest)
+		return execute(0, processArgs, conf, "", debugger.ExecutingGeneratedTest, pkg)
 	}()
 	os.Exit(status)
 }
@@ -396,11 +414,11 @@ func attachCmd(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "Invalid pid: %s\n", args[0])
 		os.Exit(1)
 	}
-	os.Exit(execute(pid, args[1:], conf, "", executingOther))
+	os.Exit(execute(pid, args[1:], conf, "", debugger.ExecutingOther, ""))
 }
 
 func coreCmd(cmd *cobra.Command, args []string) {
-	os.Exit(execute(0, []string{args[0]}, conf, args[1], executingOther))
+	os.Exit(execute(0, []string{args[0]}, conf, args[1], debugger.ExecutingOther, ""))
 }
 
 func connectCmd(cmd *cobra.Command, args []string) {
@@ -430,16 +448,7 @@ func connect(addr string, conf *config.Config) int {
 	return status
 }
 
-type executeKind int
-
-const (
-	executingExistingFile = executeKind(iota)
-	executingGeneratedFile
-	executingGeneratedTest
-	executingOther
-)
-
-func execute(attachPid int, processArgs []string, conf *config.Config, coreFile string, kind executeKind) int {
+func execute(attachPid int, processArgs []string, conf *config.Config, coreFile string, kind debugger.ExecuteKind, pkg string) int {
 	// Make a TCP listener
 	listener, err := net.Listen("tcp", Addr)
 	if err != nil {
@@ -459,6 +468,11 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 
 	disconnectChan := make(chan struct{})
 
+	var prettyPrinters []config.PrettyPrinter
+	if conf != nil {
+		prettyPrinters = conf.PrettyPrinters
+	}
+
 	// Create and start a debugger server
 	switch APIVersion {
 	case 1, 2:
@@ -471,7 +485,11 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 			WorkingDir:  WorkingDir,
 			Backend:     Backend,
 			CoreFile:    coreFile,
+			ExecuteKind: kind,
+			Package:     pkg,
+			BuildFlags:  BuildFlags,
 
+			PrettyPrinters: prettyPrinters,
 			DisconnectChan: disconnectChan,
 		}, Log)
 	default:
@@ -482,10 +500,10 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	if err := server.Run(); err != nil {
 		if err == api.NotExecutableErr {
 			switch kind {
-			case executingGeneratedFile:
+			case debugger.ExecutingGeneratedFile:
 				fmt.Fprintln(os.Stderr, "Can not debug non-main package")
 				return 1
-			case executingExistingFile:
+			case debugger.ExecutingExistingFile:
 				fmt.Fprintf(os.Stderr, "%s is not executable\n", processArgs[0])
 				return 1
 			default:
@@ -510,7 +528,7 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	} else {
 		// Create and start a terminal
 		client := rpc2.NewClient(listener.Addr().String())
-		if client.Recorded() && (kind == executingGeneratedFile || kind == executingGeneratedTest) {
+		if client.Recorded() && (kind == debugger.ExecutingGeneratedFile || kind == debugger.ExecutingGeneratedTest) {
 			// When using the rr backend remove the trace directory if we built the
 			// executable
 			if tracedir, err := client.TraceDirectory(); err == nil {
@@ -529,40 +547,6 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	return status
 }
 
-func gobuild(debugname, pkg string) error {
-	args := []string{"-gcflags", "-N -l", "-o", debugname}
-	if BuildFlags != "" {
-		args = append(args, config.SplitQuotedFields(BuildFlags, '\'')...)
-	}
-	if ver, _ := goversion.Installed(); ver.Major < 0 || ver.AfterOrEqual(goversion.GoVersion{1, 9, -1, 0, 0, ""}) {
-		// after go1.9 building with -gcflags='-N -l' and -a simultaneously works
-		args = append(args, "-a")
-	}
-	args = append(args, pkg)
-	return gocommand("build", args...)
-}
-
-func gotestbuild(pkg string) error {
-	args := []string{"-gcflags", "-N -l", "-c", "-o", testdebugname}
-	if BuildFlags != "" {
-		args = append(args, config.SplitQuotedFields(BuildFlags, '\'')...)
-	}
-	if ver, _ := goversion.Installed(); ver.Major < 0 || ver.AfterOrEqual(goversion.GoVersion{1, 9, -1, 0, 0, ""}) {
-		// after go1.9 building with -gcflags='-N -l' and -a simultaneously works
-		args = append(args, "-a")
-	}
-	args = append(args, pkg)
-	return gocommand("test", args...)
-}
-
-func gocommand(command string, args ...string) error {
-	allargs := []string{command}
-	allargs = append(allargs, args...)
-	goBuild := exec.Command("go", allargs...)
-	goBuild.Stderr = os.Stderr
-	return goBuild.Run()
-}
-
 // SafeRemoveAll removes dir and its contents but only as long as dir does
 // not contain directories.
 func SafeRemoveAll(dir string) {
@@ -26,6 +26,33 @@ type SubstitutePathRule struct {
 // Slice of source code path substitution rules.
 type SubstitutePathRules []SubstitutePathRule
 
+// SignalHandling describes what happens when the target receives a signal.
+type SignalHandling struct {
+	// Stop the target.
+	Stop bool `yaml:"stop"`
+	// Report the signal.
+	Print bool `yaml:"print"`
+	// Deliver the signal to the target.
+	Pass bool `yaml:"pass"`
+}
+
+// PrettyPrinter describes how the values of a type are displayed.
+type PrettyPrinter struct {
+	// Type is the name of the type, for example main.Decimal.
+	Type string `yaml:"type"`
+	// Display replaces the value, every expression between braces is
+	// evaluated and replaced by its value.
+	Display string `yaml:"display,omitempty"`
+	// Children replace the fields of the value.
+	Children []PrettyPrinterChild `yaml:"children,omitempty"`
+}
+
+// PrettyPrinterChild is a child of a pretty printed value.
+type PrettyPrinterChild struct {
+	Name string `yaml:"name"`
+	Expr string `yaml:"expr"`
+}
+
 // Config defines all configuration options available to be set through the config file.
 type Config struct {
 	// Commands aliases.
@@ -39,6 +66,28 @@ type Config struct {
 	// MaxArrayValues is the maximum number of array items that the commands
 	// print, locals, args and vars should read (in verbose mode).
 	MaxArrayValues *int `yaml:"max-array-values,omitempty"`
+
+	// Handle maps signal names to the way the target receiving them is
+	// handled, see the handle command.
+	Handle map[string]SignalHandling `yaml:"handle,omitempty"`
+
+	// SkipPackages and SkipFiles are the package path patterns and file
+	// globs of the functions that step and stepout do not stop in, see the
+	// skip command.
+	SkipPackages []string `yaml:"skip-packages,omitempty"`
+	SkipFiles    []string `yaml:"skip-files,omitempty"`
+
+	// FollowForkMode is the initial follow-fork-mode, see the
+	// follow-fork-mode command.
+	FollowForkMode string `yaml:"follow-fork-mode,omitempty"`
+
+	// AllowFunctionCalls lets print, display and breakpoint conditions call
+	// functions of the target, on the selected goroutine.
+	AllowFunctionCalls bool `yaml:"allow-function-calls,omitempty"`
+
+	// PrettyPrinters change how the values of some types are displayed,
+	// the expressions they contain refer to the value as $v.
+	PrettyPrinters []PrettyPrinter `yaml:"pretty-printers,omitempty"`
 }
 
 // LoadConfig attempts to populate a Config object from the config.yml file.
@@ -139,6 +188,34 @@ aliases:
 # commands.
 substitute-path:
   # - {from: path, to: path}
+
+# Change what happens when the program receives a signal, signals not listed
+# here are passed to the program without stopping it.
+# handle:
+  # SIGUSR1: {stop: true, print: true, pass: true}
+
+# Functions that step never enters and stepout does not return to, by
+# package path ("..." matches any string) or by file glob.
+# skip-packages: ["runtime", "sync/...", ".../vendor/..."]
+# skip-files: ["*_test.go"]
+
+# Processes debugged when the program forks: parent, child or both. Only
+# supported by the native backend on Linux.
+# follow-fork-mode: parent
+
+# Let print, display and breakpoint conditions call functions of the
+# program, like the call command does. The calls resume the program.
+# allow-function-calls: true
+
+# Display the values of a type differently, the expressions between braces
+# and the expressions of the children refer to the value as $v.
+# pretty-printers:
+  # - type: main.Decimal
+  #   display: "{$v.units}.{$v.cents} {$v.currency}"
+  # - type: main.Ring
+  #   children:
+  #     - {name: len, expr: $v.n}
+  #     - {name: items, expr: "$v.buf[:$v.n]"}
 `)
 	return err
 }
@@ -0,0 +1,52 @@
+// Package gobuild provides utilities for building programs and tests
+// for debugging.
+package gobuild
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/derekparker/delve/pkg/config"
+	"github.com/derekparker/delve/pkg/goversion"
+)
+
+// GoBuild builds the package pkg into debugname, disabling optimizations
+// and inlining. buildflags are additional flags passed to the compiler,
+// quoted as for the --build-flags command line option.
+func GoBuild(debugname, pkg, buildflags string) error {
+	args := []string{"-gcflags", "-N -l", "-o", debugname}
+	args = append(args, extraFlags(buildflags)...)
+	args = append(args, pkg)
+	return gocommand("build", args...)
+}
+
+// GoTestBuild builds the test binary of package pkg into debugname,
+// disabling optimizations and inlining. buildflags are additional flags
+// passed to the compiler, quoted as for the --build-flags command line
+// option.
+func GoTestBuild(debugname, pkg, buildflags string) error {
+	args := []string{"-gcflags", "-N -l", "-c", "-o", debugname}
+	args = append(args, extraFlags(buildflags)...)
+	args = append(args, pkg)
+	return gocommand("test", args...)
+}
+
+func extraFlags(buildflags string) []string {
+	var args []string
+	if buildflags != "" {
+		args = append(args, config.SplitQuotedFields(buildflags, '\'')...)
+	}
+	if ver, _ := goversion.Installed(); ver.Major < 0 || ver.AfterOrEqual(goversion.GoVersion{1, 9, -1, 0, 0, ""}) {
+		// after go1.9 building with -gcflags='-N -l' and -a simultaneously works
+		args = append(args, "-a")
+	}
+	return args
+}
+
+func gocommand(command string, args ...string) error {
+	allargs := []string{command}
+	allargs = append(allargs, args...)
+	goBuild := exec.Command("go", allargs...)
+	goBuild.Stderr = os.Stderr
+	return goBuild.Run()
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 
 type BinaryInfo struct {
 	lastModified time.Time // Time the executable of this process was last modified
+	path         string    // Path of the executable
 
 	GOOS   string
 	closer io.Closer
@@ -69,6 +71,10 @@ func (bininfo *BinaryInfo) LoadBinaryInfo(path string, wg *sync.WaitGroup) error
 	if err == nil {
 		bininfo.lastModified = fi.ModTime()
 	}
+	bininfo.path = path
+	if realpath, err := filepath.EvalSymlinks(path); err == nil {
+		bininfo.path = realpath
+	}
 
 	switch bininfo.GOOS {
 	case "linux":
@@ -91,6 +97,11 @@ func (bi *BinaryInfo) LastModified() time.Time {
 	return bi.lastModified
 }
 
+// Path returns the path of the executable.
+func (bi *BinaryInfo) Path() string {
+	return bi.path
+}
+
 // DwarfReader returns a reader for the dwarf data
 func (bi *BinaryInfo) DwarfReader() *reader.Reader {
 	return reader.New(bi.dwarf)
@@ -125,6 +136,11 @@ func (bi *BinaryInfo) LineToPC(filename string, lineno int) (pc uint64, fn *gosy
 	return bi.goSymTable.LineToPC(filename, lineno)
 }
 
+// LookupFunc returns the function called name.
+func (bi *BinaryInfo) LookupFunc(name string) *gosym.Func {
+	return bi.goSymTable.LookupFunc(name)
+}
+
 // PCToFunc returns the function containing the given PC address
 func (bi *BinaryInfo) PCToFunc(pc uint64) *gosym.Func {
 	return bi.goSymTable.PCToFunc(pc)
@@ -1,11 +1,18 @@
 package proc
 
 import (
+	"debug/dwarf"
 	"errors"
 	"fmt"
 	"go/ast"
 	"go/constant"
+	"go/scanner"
+	"go/token"
 	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/derekparker/delve/pkg/dwarf/godwarf"
 )
 
 // Breakpoint represents a breakpoint. Stores information on the break
@@ -16,6 +23,8 @@ type Breakpoint struct {
 	FunctionName string
 	File         string
 	Line         int
+	// LocationSpec is the location spec used to create a user breakpoint.
+	LocationSpec string
 
 	Addr         uint64         // Address breakpoint is set for.
 	OriginalData []byte         // If software breakpoint, the data we replace with breakpoint instruction.
@@ -28,6 +37,8 @@ type Breakpoint struct {
 	Goroutine     bool     // Retrieve goroutine information
 	Stacktrace    int      // Number of stack frames to retrieve
 	Variables     []string // Variables to evaluate
+	LogMessage    string   // Format of the message printed by logpoints, Variables are its arguments
+	TraceReturn   bool     // Breakpoint on a return instruction, used to retrieve the return values of a function
 	LoadArgs      *LoadConfig
 	LoadLocals    *LoadConfig
 	HitCount      map[int]uint64 // Number of times a breakpoint has been reached in a certain goroutine
@@ -45,6 +56,78 @@ type Breakpoint struct {
 	DeferReturns []uint64
 	// Cond: if not nil the breakpoint will be triggered only if evaluating Cond returns true
 	Cond ast.Expr
+	// HitCond: if not nil the breakpoint will be triggered only if the
+	// value of TotalHitCount satisfies HitCond.
+	// Only hits that satisfy Cond are counted.
+	HitCond *HitCondition
+
+	// Watchpoint information, WatchType is zero for breakpoints that aren't
+	// watchpoints.
+	WatchExpr    string    // Expression used to set the watchpoint
+	WatchType    WatchType // Kind of memory access that triggers the watchpoint
+	HWBreakIndex uint8     // Index of the debug register used by the watchpoint
+
+	watchVarType godwarf.Type // Type of the watched expression
+	watchData    []byte       // Value of the watched memory the last time it was reported
+	watchScope   *Breakpoint  // WatchOutOfScopeBreakpoint for this watchpoint, if it is on the stack
+	// watchScopeCond evaluates to true when the frame containing the watched
+	// variable returns into its caller.
+	watchScopeCond ast.Expr
+
+	// watchpoints: when kind == WatchOutOfScopeBreakpoint, or for a user
+	// breakpoint set on the return address of the frame of a watched
+	// variable, this is the list of watchpoints that could go out of scope
+	// when this breakpoint is reached.
+	watchpoints []*Breakpoint
+	// WatchOutOfScope is the list of watchpoints that went out of scope the
+	// last time this breakpoint was reached, Continue clears them.
+	WatchOutOfScope []*Breakpoint
+
+	// Disabled: the breakpoint was removed from the target process by
+	// DisableBreakpoint, it can be set again with EnableBreakpoint.
+	Disabled bool
+
+	// logical: if the breakpoint was created by SetLogicalBreakpoint this is
+	// the list of breakpoints (including this one) that share its ID. They
+	// are reported as a single breakpoint and share hit counts.
+	logical []*Breakpoint
+
+	// returnInfo is set on the breakpoint StepOut sets on the return
+	// address, it is used to retrieve the return values of the function.
+	returnInfo *returnBreakpointInfo
+}
+
+type returnBreakpointInfo struct {
+	fnPC      uint64 // an address inside the function being stepped out of
+	cfaOffset int64  // CFA of the function's frame, relative to the top of the stack
+}
+
+// collect returns the return values of the function described by rbpi,
+// after it returned to its caller.
+func (rbpi *returnBreakpointInfo) collect(thread Thread) []*Variable {
+	g, err := GetG(thread)
+	if err != nil || g == nil {
+		return nil
+	}
+	// after the function returns its arguments are still at the same
+	// position relative to the CFA of its frame
+	scope := &EvalScope{rbpi.fnPC, rbpi.cfaOffset + int64(g.stackhi), thread, g.variable, thread.BinInfo(), g.stackhi, nil, nil, nil}
+	vars, err := scope.variablesByTag(dwarf.TagFormalParameter, nil)
+	if err != nil {
+		return nil
+	}
+	return filterReturnValues(vars)
+}
+
+// filterReturnValues returns the variables in vars that are return values.
+func filterReturnValues(vars []*Variable) []*Variable {
+	r := make([]*Variable, 0, len(vars))
+	for _, v := range vars {
+		if v.Flags&VariableReturnArgument != 0 {
+			r = append(r, v)
+		}
+	}
+	return r
 }
 
 // Breakpoint Kind determines the behavior of delve when the
@@ -66,9 +149,52 @@ const (
 	// Continue will set a new breakpoint (of NextBreakpoint kind) on the
 	// destination of CALL, delete this breakpoint and then continue again
 	StepBreakpoint
+	// WatchOutOfScopeBreakpoint is a breakpoint set on the return address
+	// of a frame containing watched stack variables, Continue will clear
+	// the watchpoints whose frame returned and stop.
+	WatchOutOfScopeBreakpoint
+)
+
+// WatchType is the type of memory access that triggers a watchpoint, the
+// upper four bits contain the size of the watched memory.
+type WatchType uint8
+
+const (
+	WatchRead WatchType = 1 << iota
+	WatchWrite
 )
 
+// Read returns true if the watchpoint triggers on memory reads.
+func (wtype WatchType) Read() bool {
+	return wtype&WatchRead != 0
+}
+
+// Write returns true if the watchpoint triggers on memory writes.
+func (wtype WatchType) Write() bool {
+	return wtype&WatchWrite != 0
+}
+
+// Size returns the size in bytes of the watched memory.
+func (wtype WatchType) Size() int {
+	return int(wtype >> 4)
+}
+
+func (wtype WatchType) withSize(sz uint8) WatchType {
+	return WatchType(sz<<4) | (wtype & 0xf)
+}
+
+// WatchpointsUnsupportedErr is returned by backends that can not set
+// hardware watchpoints.
+var WatchpointsUnsupportedErr = errors.New("hardware watchpoints are not supported by this backend")
+
+// SyscallCatchpointsUnsupportedErr is returned by backends that can not
+// stop the target on system calls.
+var SyscallCatchpointsUnsupportedErr = errors.New("syscall catchpoints are not supported by this backend")
+
 func (bp *Breakpoint) String() string {
+	if bp.WatchType != 0 {
+		return fmt.Sprintf("Watchpoint %d at %#v %s (%d)", bp.ID, bp.Addr, bp.WatchExpr, bp.TotalHitCount)
+	}
 	return fmt.Sprintf("Breakpoint %d at %#v %s:%d (%d)", bp.ID, bp.Addr, bp.File, bp.Line, bp.TotalHitCount)
 }
 
@@ -95,7 +221,47 @@ func (iae InvalidAddressError) Error() string {
 }
 
 // CheckCondition evaluates bp's condition on thread.
+// If the condition is met the hit counts of bp are updated before
+// evaluating its hit condition.
 func (bp *Breakpoint) CheckCondition(thread Thread) (bool, error) {
+	if bp.Kind == WatchOutOfScopeBreakpoint {
+		return bp.checkWatchScope(thread)
+	}
+	// watchpoints can also be attached to a user breakpoint that was
+	// already set on the return address of their frame
+	outOfScope, err := bp.checkWatchScope(thread)
+	if err != nil {
+		return true, err
+	}
+	active, err := bp.checkHit(thread)
+	return active || outOfScope, err
+}
+
+// checkHit evaluates the condition of bp and updates its hit counts.
+func (bp *Breakpoint) checkHit(thread Thread) (bool, error) {
+	active, err := bp.checkCond(thread)
+	if !active {
+		return false, err
+	}
+	if err == funcCallNotAllowedErr {
+		// the hit counts are updated when the condition is evaluated again
+		// with function calls
+		return true, err
+	}
+	if g, err := GetG(thread); err == nil {
+		bp.HitCount[g.ID]++
+	}
+	bp.TotalHitCount++
+	for _, lbp := range bp.logical {
+		lbp.TotalHitCount = bp.TotalHitCount
+	}
+	if err != nil || bp.HitCond == nil {
+		return active, err
+	}
+	return bp.HitCond.check(bp.TotalHitCount), nil
+}
+
+func (bp *Breakpoint) checkCond(thread Thread) (bool, error) {
 	if bp.Cond == nil {
 		return true, nil
 	}
@@ -117,6 +283,10 @@ func (bp *Breakpoint) CheckCondition(thread Thread) (bool, error) {
 			}
 		}
 	}
+	if r := thread.Common().condResult; r != nil {
+		thread.Common().condResult = nil
+		return r.active, r.err
+	}
 	return evalBreakpointCondition(thread, bp.Cond)
 }
 
@@ -125,7 +295,39 @@ func evalBreakpointCondition(thread Thread, cond ast.Expr) (bool, error) {
 	if err != nil {
 		return true, err
 	}
+	return scope.evalCondition(cond)
+}
+
+// condResult is the result of the evaluation of a breakpoint condition.
+type condResult struct {
+	active bool
+	err    error
+}
+
+// evalBreakpointConditionCall evaluates the condition of bp, the
+// breakpoint thread is stopped at, allowing function calls and updates the
+// breakpoint state of thread. Conditions that call functions can not be
+// evaluated while the stop is processed, because the calls resume the
+// target: when they are first evaluated the breakpoint is considered active
+// and funcCallNotAllowedErr is returned.
+func evalBreakpointConditionCall(p Process, thread Thread, bp *Breakpoint) error {
+	r := &condResult{active: true}
+	scope, err := GoroutineScope(thread)
+	if err == nil {
+		scope.callCtx = &callContext{p: p, checkEscape: true, retLoadCfg: loadSingleValue}
+		r.active, r.err = scope.evalCondition(bp.Cond)
+	} else {
+		r.err = err
+	}
+	thread.Common().condResult = r
+	return thread.SetCurrentBreakpoint()
+}
+
+func (scope *EvalScope) evalCondition(cond ast.Expr) (bool, error) {
 	v, err := scope.evalAST(cond)
+	if err == funcCallNotAllowedErr {
+		return true, err
+	}
 	if err != nil {
 		return true, fmt.Errorf("error evaluating expression: %v", err)
 	}
@@ -138,6 +340,22 @@ func evalBreakpointCondition(thread Thread, cond ast.Expr) (bool, error) {
 	return constant.BoolVal(v.Value), nil
 }
 
+// checkWatchScope collects in bp.WatchOutOfScope the watchpoints whose
+// frame is returning on thread.
+func (bp *Breakpoint) checkWatchScope(thread Thread) (bool, error) {
+	bp.WatchOutOfScope = nil
+	for _, wp := range bp.watchpoints {
+		returning, err := evalBreakpointCondition(thread, wp.watchScopeCond)
+		if err != nil {
+			return true, err
+		}
+		if returning {
+			bp.WatchOutOfScope = append(bp.WatchOutOfScope, wp)
+		}
+	}
+	return len(bp.WatchOutOfScope) > 0, nil
+}
+
 // Internal returns true for breakpoints not set directly by the user.
 func (bp *Breakpoint) Internal() bool {
 	return bp.Kind != UserBreakpoint
@@ -152,3 +370,572 @@ type NoBreakpointError struct {
 func (nbp NoBreakpointError) Error() string {
 	return fmt.Sprintf("no breakpoint at %#v", nbp.Addr)
 }
+
+// SetWatchpoint sets a watchpoint of type wtype on the memory occupied by
+// the result of evaluating expr in the specified goroutine and frame.
+// If expr is a variable stored on the stack the watchpoint will be
+// cleared when its frame returns.
+func SetWatchpoint(dbp Process, gid, frame int, expr string, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
+	scope, err := ConvertEvalScope(dbp, gid, frame)
+	if err != nil {
+		return nil, err
+	}
+	v, err := scope.EvalExpression(expr, loadSingleValue)
+	if err != nil {
+		return nil, err
+	}
+	if v.Addr == 0 {
+		return nil, fmt.Errorf("can not watch %s: expression has no address", expr)
+	}
+	sz := v.RealType.Size()
+	switch sz {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("can not watch %s: watched memory must be 1, 2, 4 or 8 bytes long (was %d)", expr, sz)
+	}
+	if uint64(v.Addr)%uint64(sz) != 0 {
+		return nil, fmt.Errorf("can not watch %s: address %#x is not aligned to %d bytes", expr, v.Addr, sz)
+	}
+	data := make([]byte, sz)
+	if _, err := scope.Mem.ReadMemory(data, v.Addr); err != nil {
+		return nil, err
+	}
+
+	var frames []Stackframe
+	g, err := FindGoroutine(dbp, gid)
+	if err != nil {
+		return nil, err
+	}
+	if g != nil && uint64(v.Addr) >= g.stacklo && uint64(v.Addr) < g.stackhi {
+		frames, err = g.Stacktrace(frame + 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(frames) < frame+2 || frames[frame].Ret == 0 {
+			return nil, fmt.Errorf("can not watch %s: could not find the return address of frame %d", expr, frame)
+		}
+	}
+
+	wp, err := dbp.SetWatchpoint(uint64(v.Addr), wtype.withSize(uint8(sz)), cond)
+	if err != nil {
+		return nil, err
+	}
+	wp.WatchExpr = expr
+	wp.watchVarType = v.DwarfType
+	wp.watchData = data
+
+	if frames != nil {
+		retframe := frames[frame+1]
+		scopebp, err := dbp.SetBreakpoint(frames[frame].Ret, WatchOutOfScopeBreakpoint, nil)
+		if err != nil {
+			// a user breakpoint on the return address is reused, the internal
+			// breakpoints of next, step and stepout are cleared when they
+			// complete and can not be
+			if _, isexists := err.(BreakpointExistsError); !isexists || (scopebp.Kind != WatchOutOfScopeBreakpoint && scopebp.Kind != UserBreakpoint) {
+				dbp.ClearBreakpoint(wp.Addr)
+				return nil, fmt.Errorf("can not watch %s: %v", expr, err)
+			}
+		}
+		wp.watchScope = scopebp
+		wp.watchScopeCond = andFrameoffCondition(SameGoroutineCondition(g), retframe.CFA-int64(retframe.StackHi))
+		scopebp.watchpoints = append(scopebp.watchpoints, wp)
+	}
+
+	return wp, nil
+}
+
+// ClearWatchpoint clears the watchpoint at addr, along with the breakpoint
+// used to detect when its frame returns, if no other watchpoint needs it.
+func ClearWatchpoint(dbp Process, addr uint64) (*Breakpoint, error) {
+	wp, err := dbp.ClearBreakpoint(addr)
+	if err != nil {
+		return nil, err
+	}
+	scopebp := wp.watchScope
+	if scopebp == nil {
+		return wp, nil
+	}
+	wp.watchScope = nil
+	for i := range scopebp.watchpoints {
+		if scopebp.watchpoints[i] == wp {
+			scopebp.watchpoints = append(scopebp.watchpoints[:i], scopebp.watchpoints[i+1:]...)
+			break
+		}
+	}
+	if len(scopebp.watchpoints) == 0 && scopebp.Kind == WatchOutOfScopeBreakpoint {
+		if _, err := dbp.ClearBreakpoint(scopebp.Addr); err != nil {
+			return wp, err
+		}
+	}
+	return wp, nil
+}
+
+// clearWatchpointsOutOfScope clears the watchpoints that went out of scope
+// when bp, a WatchOutOfScopeBreakpoint, was reached.
+func clearWatchpointsOutOfScope(dbp Process, bp *Breakpoint) error {
+	for _, wp := range bp.WatchOutOfScope {
+		if _, err := ClearWatchpoint(dbp, wp.Addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchValues returns the value of the memory watched by bp the last time
+// WatchValues was called (or when the watchpoint was set) and its current
+// value.
+func (bp *Breakpoint) WatchValues(thread Thread, cfg LoadConfig) (oldv, newv *Variable, err error) {
+	if bp.WatchType == 0 {
+		return nil, nil, fmt.Errorf("breakpoint %d is not a watchpoint", bp.ID)
+	}
+	data := make([]byte, bp.WatchType.Size())
+	if _, err := thread.ReadMemory(data, uintptr(bp.Addr)); err != nil {
+		return nil, nil, err
+	}
+	oldmem := &memCache{uintptr(bp.Addr), bp.watchData, thread}
+	oldv = newVariable(bp.WatchExpr, uintptr(bp.Addr), bp.watchVarType, thread.BinInfo(), oldmem)
+	oldv.loadValue(cfg)
+	newv = newVariableFromThread(thread, bp.WatchExpr, uintptr(bp.Addr), bp.watchVarType)
+	newv.loadValue(cfg)
+	bp.watchData = data
+	return oldv, newv, nil
+}
+
+// setUserBreakpoint sets a user breakpoint at addr, with the given ID if
+// id is not zero. If a WatchOutOfScopeBreakpoint is set at addr it is
+// replaced by the user breakpoint, which takes over its watchpoints.
+func setUserBreakpoint(dbp Process, id int, addr uint64) (*Breakpoint, error) {
+	var watchpoints []*Breakpoint
+	if scopebp := dbp.Breakpoints()[addr]; scopebp != nil && scopebp.Kind == WatchOutOfScopeBreakpoint {
+		if _, err := dbp.ClearBreakpoint(addr); err != nil {
+			return nil, err
+		}
+		watchpoints = scopebp.watchpoints
+	}
+	var bp *Breakpoint
+	var err error
+	if id == 0 {
+		bp, err = dbp.SetBreakpoint(addr, UserBreakpoint, nil)
+	} else {
+		bp, err = dbp.SetBreakpointWithID(id, addr)
+	}
+	if err != nil {
+		if len(watchpoints) > 0 {
+			if scopebp, err := dbp.SetBreakpoint(addr, WatchOutOfScopeBreakpoint, nil); err == nil {
+				attachWatchpoints(scopebp, watchpoints)
+			}
+		}
+		return nil, err
+	}
+	attachWatchpoints(bp, watchpoints)
+	return bp, nil
+}
+
+// releaseWatchpoints is called after the user breakpoint bp is cleared,
+// it sets a WatchOutOfScopeBreakpoint in its place for the watchpoints
+// attached to it.
+func releaseWatchpoints(dbp Process, bp *Breakpoint) error {
+	if len(bp.watchpoints) == 0 {
+		return nil
+	}
+	scopebp, err := dbp.SetBreakpoint(bp.Addr, WatchOutOfScopeBreakpoint, nil)
+	if err != nil {
+		return err
+	}
+	attachWatchpoints(scopebp, bp.watchpoints)
+	bp.watchpoints = nil
+	return nil
+}
+
+func attachWatchpoints(bp *Breakpoint, watchpoints []*Breakpoint) {
+	bp.watchpoints = watchpoints
+	for _, wp := range watchpoints {
+		wp.watchScope = bp
+	}
+}
+
+// Logical returns the breakpoints that form the logical breakpoint bp
+// belongs to, bp included, see SetLogicalBreakpoint.
+func (bp *Breakpoint) Logical() []*Breakpoint {
+	if len(bp.logical) == 0 {
+		return []*Breakpoint{bp}
+	}
+	return bp.logical
+}
+
+// Addrs returns the addresses of the logical breakpoint bp belongs to.
+func (bp *Breakpoint) Addrs() []uint64 {
+	lbps := bp.Logical()
+	r := make([]uint64, len(lbps))
+	for i := range lbps {
+		r[i] = lbps[i].Addr
+	}
+	return r
+}
+
+// SetLogicalBreakpoint sets a user breakpoint on every address in addrs.
+// All the breakpoints will have the same ID and will share their hit
+// counts, the first one is returned.
+// If one of the breakpoints can not be set the ones that were already set
+// are cleared.
+func SetLogicalBreakpoint(dbp Process, addrs []uint64) (*Breakpoint, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no address specified")
+	}
+	bps := make([]*Breakpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		var bp *Breakpoint
+		var err error
+		if len(bps) == 0 {
+			bp, err = setUserBreakpoint(dbp, 0, addr)
+		} else {
+			bp, err = setUserBreakpoint(dbp, bps[0].ID, addr)
+		}
+		if err != nil {
+			for _, bp := range bps {
+				dbp.ClearBreakpoint(bp.Addr)
+				releaseWatchpoints(dbp, bp)
+			}
+			return nil, err
+		}
+		bps = append(bps, bp)
+	}
+	if len(bps) > 1 {
+		for _, bp := range bps {
+			bp.HitCount = bps[0].HitCount
+			bp.logical = bps
+		}
+	}
+	return bps[0], nil
+}
+
+// ForkBreakpoints returns the breakpoints of a process forked from the
+// process that bps belong to, whose memory, breakpoints included, is a
+// copy of the memory of the parent. User breakpoints are copied, each copy
+// has its own logical breakpoint and hit counts and is not associated with
+// watchpoints. The other breakpoints set in the memory of the parent, only
+// meaningful to it, are returned in stale and must be cleared from the
+// memory of the child. Watchpoints are not inherited.
+func ForkBreakpoints(bps map[uint64]*Breakpoint) (copies map[uint64]*Breakpoint, stale []*Breakpoint) {
+	copies = make(map[uint64]*Breakpoint)
+	for addr, bp := range bps {
+		switch {
+		case bp.WatchType != 0:
+			// debug registers are not inherited
+		case bp.Kind != UserBreakpoint:
+			stale = append(stale, bp)
+		default:
+			nbp := *bp
+			nbp.HitCount = map[int]uint64{}
+			nbp.TotalHitCount = 0
+			nbp.watchpoints = nil
+			nbp.WatchOutOfScope = nil
+			nbp.logical = nil
+			copies[addr] = &nbp
+		}
+	}
+	for addr, bp := range bps {
+		nbp := copies[addr]
+		if nbp == nil || len(bp.logical) == 0 {
+			continue
+		}
+		logical := make([]*Breakpoint, 0, len(bp.logical))
+		for _, lbp := range bp.logical {
+			if c := copies[lbp.Addr]; c != nil {
+				logical = append(logical, c)
+			}
+		}
+		nbp.logical = logical
+		nbp.HitCount = logical[0].HitCount
+	}
+	return copies, stale
+}
+
+// ClearLogicalBreakpoint clears the breakpoint at addr and all the other
+// breakpoints that share its ID, see SetLogicalBreakpoint.
+func ClearLogicalBreakpoint(dbp Process, addr uint64) (*Breakpoint, error) {
+	bp, err := dbp.ClearBreakpoint(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := releaseWatchpoints(dbp, bp); err != nil {
+		return bp, err
+	}
+	for _, lbp := range bp.logical {
+		if lbp == bp {
+			continue
+		}
+		if _, err := dbp.ClearBreakpoint(lbp.Addr); err != nil {
+			return bp, err
+		}
+		if err := releaseWatchpoints(dbp, lbp); err != nil {
+			return bp, err
+		}
+	}
+	return bp, nil
+}
+
+// DisableBreakpoint removes the logical breakpoint bp belongs to from the
+// target process. The breakpoints keep their conditions and hit counts
+// and can be set again with EnableBreakpoint.
+func DisableBreakpoint(dbp Process, bp *Breakpoint) error {
+	if bp.WatchType != 0 {
+		return errors.New("watchpoints can not be disabled")
+	}
+	if bp.Disabled {
+		return nil
+	}
+	for _, lbp := range bp.Logical() {
+		if _, err := dbp.ClearBreakpoint(lbp.Addr); err != nil {
+			return err
+		}
+		if err := releaseWatchpoints(dbp, lbp); err != nil {
+			return err
+		}
+		lbp.Disabled = true
+	}
+	return nil
+}
+
+// EnableBreakpoint sets again a logical breakpoint removed by
+// DisableBreakpoint, with the same ID, conditions and hit counts.
+// Returns the breakpoint that replaces bp.
+func EnableBreakpoint(dbp Process, bp *Breakpoint) (*Breakpoint, error) {
+	if !bp.Disabled {
+		return bp, nil
+	}
+	var r *Breakpoint
+	oldbps := bp.Logical()
+	bps := make([]*Breakpoint, 0, len(oldbps))
+	for _, oldbp := range oldbps {
+		newbp, err := setUserBreakpoint(dbp, oldbp.ID, oldbp.Addr)
+		if err != nil {
+			for _, newbp := range bps {
+				dbp.ClearBreakpoint(newbp.Addr)
+				releaseWatchpoints(dbp, newbp)
+			}
+			return nil, err
+		}
+		// Everything but the original data, which belongs to the backend, and
+		// the watchpoints set on the same address while bp was disabled is
+		// copied from the disabled breakpoint.
+		originalData, watchpoints := newbp.OriginalData, newbp.watchpoints
+		*newbp = *oldbp
+		newbp.OriginalData = originalData
+		attachWatchpoints(newbp, watchpoints)
+		newbp.Disabled = false
+		if oldbp == bp {
+			r = newbp
+		}
+		bps = append(bps, newbp)
+	}
+	if len(bps) > 1 {
+		for _, newbp := range bps {
+			newbp.logical = bps
+		}
+	}
+	return r, nil
+}
+
+// HitCondition is a condition on the number of times a breakpoint has
+// been hit.
+type HitCondition struct {
+	Op  token.Token // One of ==, !=, <, <=, >, >= or %
+	Val uint64
+}
+
+// ParseHitCondition parses a hit condition of the form "<op> <n>", where
+// <op> is one of ==, !=, <, <=, >, >=, or "% <n> == 0" which is satisfied
+// every <n> hits. The trailing "== 0" can be omitted.
+func ParseHitCondition(s string) (*HitCondition, error) {
+	var sc scanner.Scanner
+	src := []byte(s)
+	fset := token.NewFileSet()
+	var scanErr error
+	sc.Init(fset.AddFile("", fset.Base(), len(src)), src, func(pos token.Position, msg string) {
+		scanErr = errors.New(msg)
+	}, 0)
+
+	type item struct {
+		tok token.Token
+		lit string
+	}
+	items := []item{}
+	for {
+		_, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			// automatically inserted semicolon
+			continue
+		}
+		items = append(items, item{tok, lit})
+	}
+	if scanErr != nil {
+		return nil, fmt.Errorf("invalid hit condition %q: %v", s, scanErr)
+	}
+
+	invalid := fmt.Errorf("invalid hit condition %q", s)
+
+	if len(items) < 2 || items[1].tok != token.INT {
+		return nil, invalid
+	}
+	val, err := strconv.ParseUint(items[1].lit, 0, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	hc := &HitCondition{Op: items[0].tok, Val: val}
+	switch hc.Op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if len(items) != 2 {
+			return nil, invalid
+		}
+	case token.REM:
+		if hc.Val == 0 {
+			return nil, fmt.Errorf("invalid hit condition %q: division by zero", s)
+		}
+		switch len(items) {
+		case 2:
+			// ok
+		case 4:
+			if items[2].tok != token.EQL || items[3].tok != token.INT || items[3].lit != "0" {
+				return nil, invalid
+			}
+		default:
+			return nil, invalid
+		}
+	default:
+		return nil, invalid
+	}
+	return hc, nil
+}
+
+func (hc *HitCondition) String() string {
+	if hc.Op == token.REM {
+		return fmt.Sprintf("%% %d == 0", hc.Val)
+	}
+	return fmt.Sprintf("%s %d", hc.Op, hc.Val)
+}
+
+func (hc *HitCondition) check(n uint64) bool {
+	switch hc.Op {
+	case token.EQL:
+		return n == hc.Val
+	case token.NEQ:
+		return n != hc.Val
+	case token.LSS:
+		return n < hc.Val
+	case token.LEQ:
+		return n <= hc.Val
+	case token.GTR:
+		return n > hc.Val
+	case token.GEQ:
+		return n >= hc.Val
+	case token.REM:
+		return n%hc.Val == 0
+	}
+	return false
+}
+
+// CatchpointPrefix is the prefix of the name of catchpoints, the name of
+// a catchpoint is CatchpointPrefix followed by its kind.
+const CatchpointPrefix = "catch-"
+
+// IDs of the breakpoints set by delve itself rather than by the user.
+// They are negative so that they can never be assigned to a user
+// breakpoint, new ones must be added here.
+const (
+	UnrecoveredPanicID = -1
+	PanicCatchpointID  = -2
+	ThrowCatchpointID  = -3
+	ExitCatchpointID   = -4
+)
+
+// catchpointFunc is a function a catchpoint is set on, along with the
+// expression that is reported when the catchpoint is hit there.
+type catchpointFunc struct {
+	fn   string
+	expr string
+}
+
+// catchpoints maps the kind of each catchpoint to its ID and to the
+// functions it is set on. Functions that do not exist in the target, like
+// those that were added or removed by some version of Go, are skipped.
+var catchpoints = map[string]struct {
+	id  int
+	fns []catchpointFunc
+}{
+	"panic": {PanicCatchpointID, []catchpointFunc{{"runtime.gopanic", "e"}}},
+	// Fatal errors go through runtime.throw or, since Go 1.21, through
+	// runtime.fatal, the only callers of runtime.fatalthrow. Unrecovered
+	// panics end in runtime.fatalpanic since Go 1.11, before that they were
+	// reported by the unrecovered-panic breakpoint.
+	"throw": {ThrowCatchpointID, []catchpointFunc{
+		{"runtime.throw", "s"},
+		{"runtime.fatal", "s"},
+		{"runtime.fatalpanic", "msgs.arg"},
+	}},
+	"exit": {ExitCatchpointID, []catchpointFunc{{"os.Exit", "code"}}},
+}
+
+// CatchpointExpr returns the expression reported when bp, one of the
+// breakpoints of a catchpoint, is hit. It depends on the function bp is set
+// on.
+func CatchpointExpr(bp *Breakpoint) (string, bool) {
+	if bp.ID >= 0 || !strings.HasPrefix(bp.Name, CatchpointPrefix) {
+		return "", false
+	}
+	for _, cpfn := range catchpoints[bp.Name[len(CatchpointPrefix):]].fns {
+		if cpfn.fn == bp.FunctionName {
+			return cpfn.expr, true
+		}
+	}
+	return "", false
+}
+
+// SetCatchpoint sets a catchpoint of the specified kind:
+// "panic" stops when a panic starts, even if it is later recovered,
+// "throw" stops when the runtime raises a fatal error or the program
+// terminates because of an unrecovered panic,
+// "exit" stops when the program calls os.Exit.
+// Like the unrecovered-panic breakpoint catchpoints have negative IDs
+// and their only variable is the panic value, the error message or the
+// exit code, respectively.
+func SetCatchpoint(dbp Process, kind string) (*Breakpoint, error) {
+	cp, ok := catchpoints[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown catchpoint %q, must be one of panic, throw or exit", kind)
+	}
+	var bps []*Breakpoint
+	var err error
+	for _, cpfn := range cp.fns {
+		addr, err1 := FindFunctionLocation(dbp, cpfn.fn, true, 0)
+		if err1 != nil {
+			if err == nil {
+				err = err1
+			}
+			continue
+		}
+		bp, err1 := dbp.SetBreakpointWithID(cp.id, addr)
+		if err1 != nil {
+			for _, bp := range bps {
+				dbp.ClearBreakpoint(bp.Addr)
+			}
+			return nil, err1
+		}
+		bp.Name = CatchpointPrefix + kind
+		bp.Variables = []string{cpfn.expr}
+		bps = append(bps, bp)
+	}
+	if len(bps) == 0 {
+		return nil, err
+	}
+	if len(bps) > 1 {
+		for _, bp := range bps {
+			bp.HitCount = bps[0].HitCount
+			bp.logical = bps
+		}
+	}
+	return bps[0], nil
+}
@@ -0,0 +1,220 @@
+package proc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// convVarPrefix replaces the '$' of convenience variables before an
+// expression is parsed, since '$' can not appear in Go identifiers.
+const convVarPrefix = "__dlv_conv_"
+
+// ConvenienceVariables stores the values that expressions can refer to
+// with $N, the N-th value in the value history, and $name, a value saved
+// by the user.
+// Values are snapshots: the memory of the value itself is copied when it
+// is stored, memory it points to is read from the target when needed.
+// Once the process a value was read from is no longer the one being
+// inspected, because it was restarted or another target was selected,
+// only the copy can be read.
+type ConvenienceVariables struct {
+	history []*Variable
+	named   map[string]*Variable
+}
+
+// Record appends v to the value history and returns its index.
+func (cv *ConvenienceVariables) Record(v *Variable) int {
+	cv.history = append(cv.history, snapshot(v, "$"+strconv.Itoa(len(cv.history)+1)))
+	return len(cv.history)
+}
+
+// Set saves v as $name.
+func (cv *ConvenienceVariables) Set(name string, v *Variable) error {
+	if !validConvVarName(name) {
+		return fmt.Errorf("invalid convenience variable name $%s", name)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return errors.New("can not assign to the value history")
+	}
+	if IsRegister(name) {
+		return fmt.Errorf("$%s is a register", name)
+	}
+	if cv.named == nil {
+		cv.named = make(map[string]*Variable)
+	}
+	cv.named[name] = snapshot(v, "$"+name)
+	return nil
+}
+
+// Lookup returns the value of $name.
+func (cv *ConvenienceVariables) Lookup(name string) (*Variable, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		if n <= 0 || n > len(cv.history) {
+			return nil, fmt.Errorf("history has no value $%d", n)
+		}
+		return cv.history[n-1].clone(), nil
+	}
+	v, ok := cv.named[name]
+	if !ok {
+		return nil, fmt.Errorf("no convenience variable $%s", name)
+	}
+	return v.clone(), nil
+}
+
+// List returns the value history, in order, followed by the named values
+// sorted by name.
+func (cv *ConvenienceVariables) List() []*Variable {
+	r := make([]*Variable, 0, len(cv.history)+len(cv.named))
+	r = append(r, cv.history...)
+	names := make([]string, 0, len(cv.named))
+	for name := range cv.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r = append(r, cv.named[name])
+	}
+	return r
+}
+
+// Clear removes $name, if name is empty it removes all named values and
+// the value history.
+func (cv *ConvenienceVariables) Clear(name string) error {
+	if name == "" {
+		cv.history = nil
+		cv.named = nil
+		return nil
+	}
+	if _, ok := cv.named[name]; !ok {
+		return fmt.Errorf("no convenience variable $%s", name)
+	}
+	delete(cv.named, name)
+	return nil
+}
+
+// snapshot returns a copy of v, named name, that does not change when the
+// target memory changes.
+func snapshot(v *Variable, name string) *Variable {
+	r := v.clone()
+	r.Name = name
+	if v.Addr == 0 || v.RealType == nil || v.mem == nil {
+		return r
+	}
+	size := v.RealType.Size()
+	if size <= 0 {
+		return r
+	}
+	buf := make([]byte, size)
+	if _, err := v.mem.ReadMemory(buf, v.Addr); err != nil {
+		return r
+	}
+	r.mem = &snapshotMemory{memCache{v.Addr, buf, v.mem}}
+	return r
+}
+
+// snapshotMemory serves reads of a stored value from the copy taken when
+// the value was stored and refuses to modify it.
+type snapshotMemory struct {
+	memCache
+}
+
+func (m *snapshotMemory) WriteMemory(addr uintptr, data []byte) (int, error) {
+	if addr < m.cacheAddr+uintptr(len(m.cache)) && addr+uintptr(len(data)) > m.cacheAddr {
+		return 0, errors.New("can not modify a convenience variable")
+	}
+	return m.mem.WriteMemory(addr, data)
+}
+
+// staleMemory is the memory of a process that convenience variables can
+// no longer read from.
+type staleMemory struct{}
+
+var errStaleConvVar = errors.New("memory of a convenience variable read from a different process")
+
+func (staleMemory) ReadMemory(data []byte, addr uintptr) (int, error) {
+	return 0, errStaleConvVar
+}
+
+func (staleMemory) WriteMemory(addr uintptr, data []byte) (int, error) {
+	return 0, errStaleConvVar
+}
+
+func (scope *EvalScope) evalConvVar(node *ast.Ident) (*Variable, error) {
+	name := node.Name[len(convVarPrefix):]
+	if scope.ConvVars == nil {
+		return nil, fmt.Errorf("convenience variable $%s not available", name)
+	}
+	v, err := scope.ConvVars.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if v.bi != scope.BinInfo && v.mem != nil {
+		// Each process has its own BinaryInfo, v was read from a process
+		// that is not being inspected and could be gone.
+		if m, ok := v.mem.(*snapshotMemory); ok {
+			v.mem = &snapshotMemory{memCache{m.cacheAddr, m.cache, staleMemory{}}}
+		} else {
+			v.mem = staleMemory{}
+		}
+	}
+	return v, nil
+}
+
+// ParseExpr parses expr, which can refer to convenience variables and
+// registers.
+func ParseExpr(expr string) (ast.Expr, error) {
+	return parser.ParseExpr(rewriteConvVars(expr))
+}
+
+// rewriteConvVars replaces the '$' of the convenience variables in expr,
+// outside of string and character literals, with convVarPrefix.
+func rewriteConvVars(expr string) string {
+	if !strings.Contains(expr, "$") {
+		return expr
+	}
+	var buf bytes.Buffer
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		ch := expr[i]
+		switch {
+		case quote != 0:
+			buf.WriteByte(ch)
+			if ch == '\\' && quote != '`' && i+1 < len(expr) {
+				i++
+				buf.WriteByte(expr[i])
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+			buf.WriteByte(ch)
+		case ch == '$' && i+1 < len(expr) && isConvVarChar(expr[i+1]):
+			buf.WriteString(convVarPrefix)
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	return buf.String()
+}
+
+func validConvVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isConvVarChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isConvVarChar(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
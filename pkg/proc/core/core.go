@@ -148,17 +148,20 @@ type Process struct {
 	currentThread     *Thread
 	selectedGoroutine *proc.G
 	allGCache         []*proc.G
+	common            proc.CommonProcess
 }
 
 type Thread struct {
 	th     *LinuxPrStatus
 	fpregs []proc.Register
 	p      *Process
+	common proc.CommonThread
 }
 
 var ErrWriteCore = errors.New("can not to core process")
 var ErrShortRead = errors.New("short read")
 var ErrContinueCore = errors.New("can not continue execution of core process")
+var ErrChangeRegisterCore = errors.New("can not change register values of core process")
 
 func OpenCore(corePath, exePath string) (*Process, error) {
 	core, err := readCore(corePath, exePath)
@@ -193,18 +196,31 @@ func OpenCore(corePath, exePath string) (*Process, error) {
 	return p, nil
 }
 
+func (p *Process) Common() *proc.CommonProcess {
+	return &p.common
+}
+
 func (p *Process) BinInfo() *proc.BinaryInfo {
 	return &p.bi
 }
 
 func (p *Process) Recorded() (bool, string)                { return true, "" }
 func (p *Process) Restart(string) error                    { return ErrContinueCore }
-func (p *Process) Direction(proc.Direction) error          { return ErrContinueCore }
+func (p *Process) GetDirection() proc.Direction            { return proc.Forward }
 func (p *Process) When() (string, error)                   { return "", nil }
 func (p *Process) Checkpoint(string) (int, error)          { return -1, ErrContinueCore }
 func (p *Process) Checkpoints() ([]proc.Checkpoint, error) { return nil, nil }
 func (p *Process) ClearCheckpoint(int) error               { return errors.New("checkpoint not found") }
 
+// Direction changes execution direction, a core file can not be executed
+// in either direction but setting the direction to Forward is allowed.
+func (p *Process) Direction(dir proc.Direction) error {
+	if dir != proc.Forward {
+		return ErrContinueCore
+	}
+	return nil
+}
+
 func (thread *Thread) ReadMemory(data []byte, addr uintptr) (n int, err error) {
 	n, err = thread.p.core.ReadMemory(data, addr)
 	if err == nil && n != len(data) {
@@ -258,6 +274,26 @@ func (t *Thread) SetCurrentBreakpoint() error {
 	return nil
 }
 
+func (t *Thread) Common() *proc.CommonThread {
+	return &t.common
+}
+
+func (t *Thread) SetReg(regNum int, value uint64) error {
+	return ErrChangeRegisterCore
+}
+
+func (t *Thread) RestoreRegisters(proc.Registers) error {
+	return ErrChangeRegisterCore
+}
+
+func (t *Thread) Syscall() *proc.Syscall {
+	return nil
+}
+
+func (t *Thread) Signal() *proc.Signal {
+	return nil
+}
+
 func (p *Process) Breakpoints() map[uint64]*proc.Breakpoint {
 	return p.breakpoints
 }
@@ -325,6 +361,48 @@ func (p *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.
 	return nil, ErrWriteCore
 }
 
+func (p *Process) SetBreakpointWithID(id int, addr uint64) (*proc.Breakpoint, error) {
+	return nil, ErrWriteCore
+}
+
+func (p *Process) SetWatchpoint(addr uint64, wtype proc.WatchType, cond ast.Expr) (*proc.Breakpoint, error) {
+	return nil, ErrWriteCore
+}
+
+func (p *Process) CatchSyscalls(names []string, enabled bool) error {
+	return ErrContinueCore
+}
+
+func (p *Process) SetSignalHandling(sig string, h proc.SignalHandling) error {
+	return ErrContinueCore
+}
+
+func (p *Process) ReceivedSignals() []proc.Signal {
+	return nil
+}
+
+func (p *Process) SetFollowForkMode(mode proc.FollowForkMode) error {
+	if mode != proc.FollowParent {
+		return proc.FollowForkUnsupportedErr
+	}
+	return nil
+}
+
+func (p *Process) Inferiors() []proc.Process {
+	return []proc.Process{p}
+}
+
+func (p *Process) CurrentInferior() proc.Process {
+	return p
+}
+
+func (p *Process) SwitchInferior(pid int) error {
+	if pid != p.Pid() {
+		return fmt.Errorf("no inferior with pid %d", pid)
+	}
+	return nil
+}
+
 func (p *Process) SwitchGoroutine(gid int) error {
 	g, err := proc.FindGoroutine(p, gid)
 	if err != nil {
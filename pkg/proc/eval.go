@@ -8,24 +8,26 @@ import (
 	"fmt"
 	"go/ast"
 	"go/constant"
-	"go/parser"
 	"go/printer"
 	"go/token"
 	"reflect"
+	"strings"
 
 	"github.com/derekparker/delve/pkg/dwarf/godwarf"
-	"github.com/derekparker/delve/pkg/dwarf/reader"
 )
 
 var OperationOnSpecialFloatError = errors.New("operations on non-finite floats not implemented")
 
 // EvalExpression returns the value of the given expression.
 func (scope *EvalScope) EvalExpression(expr string, cfg LoadConfig) (*Variable, error) {
-	t, err := parser.ParseExpr(expr)
+	t, err := ParseExpr(expr)
 	if err != nil {
 		return nil, err
 	}
 
+	if scope.callCtx != nil {
+		scope.callCtx.retLoadCfg = cfg
+	}
 	ev, err := scope.evalAST(t)
 	if err != nil {
 		return nil, err
@@ -37,25 +39,55 @@ func (scope *EvalScope) EvalExpression(expr string, cfg LoadConfig) (*Variable,
 	return ev, nil
 }
 
+// EvalAddress evaluates expr and returns the address it represents: the
+// value of an integer or the address a pointer points to.
+func (scope *EvalScope) EvalAddress(expr string) (uintptr, error) {
+	t, err := ParseExpr(expr)
+	if err != nil {
+		return 0, err
+	}
+	v, err := scope.evalAST(t)
+	if err != nil {
+		return 0, err
+	}
+	v.loadValue(loadSingleValue)
+	if v.Unreadable != nil {
+		return 0, fmt.Errorf("Expression \"%s\" is unreadable: %v", expr, v.Unreadable)
+	}
+	switch v.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, _ := constant.Int64Val(v.Value)
+		return uintptr(n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, _ := constant.Uint64Val(v.Value)
+		return uintptr(n), nil
+	case reflect.Ptr, reflect.UnsafePointer:
+		if len(v.Children) > 0 {
+			return v.Children[0].Addr, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("expression \"%s\" of type %s is not an address", expr, v.TypeString())
+}
+
+// EvalExpressionOnValue evaluates expr, which refers to v as $v. Only v,
+// types and builtin functions can be used by expr.
+func EvalExpressionOnValue(v *Variable, expr string, cfg LoadConfig) (*Variable, error) {
+	cv := &ConvenienceVariables{named: map[string]*Variable{"v": v}}
+	scope := &EvalScope{0, 0, v.mem, nil, v.bi, 0, nil, cv, nil}
+	return scope.EvalExpression(expr, cfg)
+}
+
 func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 	switch node := t.(type) {
 	case *ast.CallExpr:
-		if len(node.Args) == 1 {
-			v, err := scope.evalTypeCast(node)
-			if err == nil {
-				return v, nil
-			}
-			_, isident := node.Fun.(*ast.Ident)
-			// we don't support function calls at the moment except for a few
-			// builtin functions so just return the type error here if the function
-			// isn't an identifier.
-			// More sophisticated logic will be required when function calls
-			// are implemented.
-			if err != reader.TypeNotFoundErr || !isident {
-				return v, err
-			}
+		if len(node.Args) == 1 && scope.isTypeExpr(node.Fun) {
+			return scope.evalTypeCast(node)
+		}
+		if !scope.isFunctionCall(node) {
+			return scope.evalBuiltinCall(node)
 		}
-		return scope.evalBuiltinCall(node)
+		return scope.evalFunctionCall(node)
 
 	case *ast.Ident:
 		return scope.evalIdent(node)
@@ -122,7 +154,13 @@ func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 func exprToString(t ast.Expr) string {
 	var buf bytes.Buffer
 	printer.Fprint(&buf, token.NewFileSet(), t)
-	return buf.String()
+	return strings.Replace(buf.String(), convVarPrefix, "$", -1)
+}
+
+// ExprString returns the source text of t, an expression returned by
+// ParseExpr.
+func ExprString(t ast.Expr) string {
+	return exprToString(t)
 }
 
 // Eval type cast expressions
@@ -439,6 +477,13 @@ func realBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 
 // Evaluates identifier expressions
 func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
+	if strings.HasPrefix(node.Name, convVarPrefix) {
+		name := node.Name[len(convVarPrefix):]
+		if reg, ok := registerNames[name]; ok {
+			return scope.evalRegister(reg, name)
+		}
+		return scope.evalConvVar(node)
+	}
 	switch node.Name {
 	case "true", "false":
 		return newConstant(constant.MakeBool(node.Name == "true"), scope.Mem), nil
@@ -0,0 +1,965 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"debug/gosym"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/arch/x86/x86asm"
+
+	"github.com/derekparker/delve/pkg/dwarf/godwarf"
+)
+
+// This file implements the function call injection protocol of
+// runtime.debugCallV1, see its description in
+// $GOROOT/src/runtime/asm_amd64.s.
+//
+// To start a call we push the current PC on the stack of the goroutine
+// and jump to debugCallV1. The runtime checks that the goroutine is
+// stopped at a point where it is safe to call a function and then talks
+// to the debugger by setting RAX and executing an INT3 instruction,
+// Continue calls functionCallState.step for each one of those stops until
+// the call is finished and the registers of the thread are restored.
+
+const (
+	debugCallFunctionNamePrefix = "runtime.debugCall"
+	debugCallFunctionName       = "runtime.debugCallV1"
+	mallocgcFunctionName        = "runtime.mallocgc"
+)
+
+// values of RAX when debugCallV1 stops
+const (
+	debugCallAXPrecheckFailed   = 8
+	debugCallAXCompleteCall     = 0
+	debugCallAXReadReturn       = 1
+	debugCallAXReadPanic        = 2
+	debugCallAXRestoreRegisters = 16
+)
+
+// fakeAddress is the address at which the argument frame of a function
+// call is placed while we build it.
+const fakeAddress = 0xbeef0000
+
+var (
+	funcCallUnsupportedErr        = errors.New("function calls not supported by this version of Go")
+	funcCallUnsupportedBackendErr = errors.New("backend does not support function calls")
+	funcCallNotAllowedErr         = errors.New("function calls not allowed without using 'call' or the allow-function-calls option")
+	funcCallInProgressErr         = errors.New("cannot call function while another function call is already in progress")
+	funcCallInterruptedErr        = errors.New("function call interrupted, it will complete when execution is resumed")
+	funcCallNoGoroutineErr        = errors.New("no goroutine selected")
+	funcCallNotRunningErr         = errors.New("selected goroutine not running on a thread")
+	funcCallNotEnoughStackErr     = errors.New("not enough stack space")
+	funcCallNoAddrErr             = errors.New("arguments to a function call must have an address")
+)
+
+// callContext is the state of an evaluation that is allowed to call
+// functions.
+type callContext struct {
+	p Process
+	// checkEscape is true if pointers to the stack of the goroutine should
+	// not be passed as arguments.
+	checkEscape bool
+	// retLoadCfg is the load configuration used for return values.
+	retLoadCfg LoadConfig
+}
+
+// functionCallState is the state of the function call in progress.
+type functionCallState struct {
+	// inProgress is true if a function call is in progress
+	inProgress bool
+	// finished is true if the function call terminated
+	finished bool
+	// savedRegs contains the registers of the thread before the call
+	savedRegs Registers
+	// fn is the function being called
+	fn *gosym.Func
+	// closureAddr is the address of the closure being called, or 0
+	closureAddr uint64
+	// argmem contains the argument frame of the call
+	argmem []byte
+	// strs are the string constants passed to fn. Before fn is called they
+	// are copied to a single block of memory allocated by calling
+	// runtime.mallocgc, with argument frame alloc, from the same frame of
+	// debugCallV1.
+	strs        []funcCallString
+	alloc       []byte
+	mallocgc    *gosym.Func
+	mallocgcRet int64
+	// allocated is true once the string constants have been copied
+	allocated bool
+	// callRet is the return address of the calls made when debugCallV1
+	// asks to complete the call
+	callRet uint64
+	// retLoadCfg is the load configuration used for return values
+	retLoadCfg LoadConfig
+	// retvars contains the return values of the call
+	retvars []*Variable
+	// panicvar contains the value of the panic, if the called function
+	// panicked
+	panicvar *Variable
+	// err contains the first error that happened during the call
+	err error
+}
+
+// CallFunction evaluates expr, an expression that can contain function
+// calls, on the selected goroutine. If expr is itself a function call
+// its return values, or the value of the panic if the function panicked,
+// become the return values of the current thread, otherwise the value of
+// expr does.
+// If checkEscape is true pointers to the stack of the goroutine can not be
+// passed as arguments.
+func CallFunction(p Process, expr string, retLoadCfg *LoadConfig, checkEscape bool) error {
+	if !p.Common().fncallEnabled {
+		return funcCallUnsupportedBackendErr
+	}
+	if p.Common().fncallState.inProgress {
+		return funcCallInProgressErr
+	}
+	g, err := funcCallGoroutine(p)
+	if err != nil {
+		return err
+	}
+
+	t, err := ParseExpr(expr)
+	if err != nil {
+		return err
+	}
+
+	scope, err := GoroutineScope(g.Thread)
+	if err != nil {
+		return err
+	}
+	cfg := loadFullValue
+	if retLoadCfg != nil {
+		cfg = *retLoadCfg
+	}
+	scope.callCtx = &callContext{p: p, checkEscape: checkEscape, retLoadCfg: cfg}
+
+	var retvars []*Variable
+	if node, iscall := t.(*ast.CallExpr); iscall && scope.isFunctionCall(node) {
+		var panicvar *Variable
+		retvars, panicvar, err = scope.funcCall(node)
+		if err != nil {
+			return err
+		}
+		if panicvar != nil {
+			retvars = []*Variable{panicvar}
+		}
+	} else {
+		v, err := scope.evalAST(t)
+		if err != nil {
+			return err
+		}
+		v.loadValue(cfg)
+		if v.Name == "" {
+			v.Name = expr
+		}
+		retvars = []*Variable{v}
+	}
+
+	p.CurrentThread().Common().returnValues = retvars
+	return nil
+}
+
+// AllowFunctionCalls changes whether expressions evaluated in the scopes
+// returned by ConvertEvalScope for the selected goroutine, and the
+// conditions of breakpoints, can call functions. Function calls resume the
+// target, other goroutines can run and change its state.
+// Conditions that call functions are evaluated once the stop that hit the
+// breakpoint has been processed, only for the thread that becomes current.
+func AllowFunctionCalls(p Process, allow bool) {
+	p.Common().fncallAllowed = allow
+}
+
+// funcCallGoroutine returns the goroutine where function calls are injected.
+func funcCallGoroutine(p Process) (*G, error) {
+	g := p.SelectedGoroutine()
+	if g == nil {
+		return nil, funcCallNoGoroutineErr
+	}
+	if g.Status != Grunning || g.Thread == nil {
+		return nil, funcCallNotRunningErr
+	}
+	return g, nil
+}
+
+// isFunctionCall returns true if node is a call to a function, rather
+// than a type conversion or a call to a builtin function.
+func (scope *EvalScope) isFunctionCall(node *ast.CallExpr) bool {
+	if len(node.Args) == 1 && scope.isTypeExpr(node.Fun) {
+		return false
+	}
+	if ident, isident := node.Fun.(*ast.Ident); isident {
+		switch ident.Name {
+		case "cap", "len", "complex", "imag", "real":
+			return false
+		}
+	}
+	return true
+}
+
+// isTypeExpr returns true if expr is the name of a type.
+func (scope *EvalScope) isTypeExpr(expr ast.Expr) bool {
+	for {
+		p, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = p.X
+	}
+	switch expr.(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType, *ast.StructType, *ast.StarExpr, *ast.BasicLit:
+		return true
+	}
+	_, err := scope.BinInfo.findTypeExpr(expr)
+	return err == nil
+}
+
+// evalFunctionCall evaluates a function call in an expression.
+func (scope *EvalScope) evalFunctionCall(node *ast.CallExpr) (*Variable, error) {
+	retvars, panicvar, err := scope.funcCall(node)
+	if err != nil {
+		return nil, err
+	}
+	fnname := exprToString(node.Fun)
+	if panicvar != nil {
+		if len(panicvar.Children) == 1 && panicvar.Children[0].Value != nil {
+			return nil, fmt.Errorf("%s panicked: %s", fnname, panicvar.Children[0].Value.ExactString())
+		}
+		return nil, fmt.Errorf("%s panicked", fnname)
+	}
+	switch len(retvars) {
+	case 0:
+		return nil, fmt.Errorf("%s has no return values", fnname)
+	case 1:
+		return retvars[0], nil
+	default:
+		// a variable without type or address holding all the return values
+		r := &Variable{Name: exprToString(node), bi: scope.BinInfo, mem: scope.Mem, loaded: true}
+		r.Children = make([]Variable, len(retvars))
+		for i := range retvars {
+			r.Children[i] = *retvars[i]
+		}
+		return r, nil
+	}
+}
+
+// funcCall calls the function described by node on the selected goroutine
+// and returns its return values or the value of its panic.
+func (scope *EvalScope) funcCall(node *ast.CallExpr) (retvars []*Variable, panicvar *Variable, err error) {
+	if scope.callCtx == nil {
+		return nil, nil, funcCallNotAllowedErr
+	}
+	if scope.BinInfo.LookupFunc(debugCallFunctionName) == nil {
+		return nil, nil, funcCallUnsupportedErr
+	}
+
+	fn, closureAddr, recv, err := scope.funcCallEvalFuncExpr(node.Fun)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	argvars := make([]*Variable, 0, len(node.Args)+1)
+	if recv != nil {
+		argvars = append(argvars, recv)
+	}
+	for i := range node.Args {
+		argv, err := scope.evalAST(node.Args[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		argv.Name = exprToString(node.Args[i])
+		argvars = append(argvars, argv)
+	}
+
+	frame, err := scope.funcCallArgFrame(fn, argvars, recv != nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scope.funcCallInject(fn, closureAddr, frame)
+}
+
+// funcCallEvalFuncExpr finds the function called by fun. If fun is a
+// method expression the receiver is also returned, if it is a function
+// value the address of the closure is returned.
+func (scope *EvalScope) funcCallEvalFuncExpr(fun ast.Expr) (fn *gosym.Func, closureAddr uint64, recv *Variable, err error) {
+	bi := scope.BinInfo
+
+	if sel, issel := fun.(*ast.SelectorExpr); issel {
+		xv, err := scope.evalAST(sel.X)
+		if err != nil {
+			// sel.X is not a variable, try a package function
+			if pkg, isident := sel.X.(*ast.Ident); isident {
+				if fn := scope.findPackageFunction(pkg.Name, sel.Sel.Name); fn != nil {
+					return fn, 0, nil, nil
+				}
+			}
+			return nil, 0, nil, err
+		}
+		if fn, recv := scope.findMethod(xv, sel.Sel.Name); fn != nil {
+			return fn, 0, recv, nil
+		}
+	}
+
+	fnvar, err := scope.evalAST(fun)
+	if err != nil {
+		if ident, isident := fun.(*ast.Ident); isident {
+			// not a variable, try a function of the current package
+			if curfn := bi.PCToFunc(scope.PC); curfn != nil {
+				if fn := bi.LookupFunc(curfn.PackageName() + "." + ident.Name); fn != nil {
+					return fn, 0, nil, nil
+				}
+			}
+		}
+		return nil, 0, nil, err
+	}
+	if fnvar.Kind != reflect.Func {
+		return nil, 0, nil, fmt.Errorf("expression %q is not a function", exprToString(fun))
+	}
+	fnvar.loadValue(loadSingleValue)
+	if fnvar.Unreadable != nil {
+		return nil, 0, nil, fnvar.Unreadable
+	}
+	if fnvar.Base == 0 {
+		return nil, 0, nil, errors.New("nil pointer dereference")
+	}
+	fn = bi.PCToFunc(uint64(fnvar.Base))
+	if fn == nil {
+		return nil, 0, nil, fmt.Errorf("could not find function %q", exprToString(fun))
+	}
+	// the value of a func variable is a pointer to the closure
+	closureAddr, err = readUintRaw(fnvar.mem, fnvar.Addr, int64(bi.Arch.PtrSize()))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return fn, closureAddr, nil, nil
+}
+
+// findPackageFunction returns the function called name in package pkg,
+// pkg can be a package name or a package path.
+func (scope *EvalScope) findPackageFunction(pkg, name string) *gosym.Func {
+	bi := scope.BinInfo
+	bi.loadPackageMap()
+	if path, ok := bi.packageMap[pkg]; ok {
+		if fn := bi.LookupFunc(path + "." + name); fn != nil {
+			return fn
+		}
+	}
+	if fn := bi.LookupFunc(pkg + "." + name); fn != nil {
+		return fn
+	}
+	suffix := "/" + pkg + "." + name
+	for i := range bi.Funcs() {
+		if fn := &bi.Funcs()[i]; strings.HasSuffix(fn.Name, suffix) {
+			return fn
+		}
+	}
+	return nil
+}
+
+// findMethod returns the method called name of the type of xv and the
+// receiver to use to call it. If xv is an interface the method of its
+// concrete type is returned.
+func (scope *EvalScope) findMethod(xv *Variable, name string) (*gosym.Func, *Variable) {
+	if xv.Kind == reflect.Interface {
+		xv.loadValue(LoadConfig{false, 0, 0, 0, 0})
+		if xv.Unreadable != nil || len(xv.Children) != 1 || xv.Children[0].DwarfType == nil {
+			return nil, nil
+		}
+		xv = &xv.Children[0]
+	}
+	if xv.DwarfType == nil {
+		return nil, nil
+	}
+	typ := xv.DwarfType
+	if ptyp, isptr := typ.(*godwarf.PtrType); isptr {
+		typ = ptyp.Type
+	}
+	typename := typ.String()
+	dot := strings.LastIndex(typename, ".")
+	if dot < 0 || dot < strings.LastIndex(typename, "/") {
+		return nil, nil
+	}
+	pkg, tname := typename[:dot], typename[dot+1:]
+	for _, fnname := range []string{
+		pkg + "." + tname + "." + name,
+		pkg + ".(*" + tname + ")." + name,
+	} {
+		if fn := scope.BinInfo.LookupFunc(fnname); fn != nil {
+			return fn, xv
+		}
+	}
+	return nil, nil
+}
+
+// funcCallFormalArgs returns the arguments and return values of fn, as
+// variables backed by mem, an argument frame at fakeAddress.
+func (scope *EvalScope) funcCallFormalArgs(fn *gosym.Func, mem MemoryReadWriter) ([]*Variable, error) {
+	fscope := &EvalScope{fn.Entry, fakeAddress, mem, nil, scope.BinInfo, 0, nil, nil, nil}
+	vars, err := fscope.variablesByTag(dwarf.TagFormalParameter, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not read arguments of %s: %v", fn.Name, err)
+	}
+	sort.Stable(variablesByAddr(vars))
+	return vars, nil
+}
+
+type variablesByAddr []*Variable
+
+func (vars variablesByAddr) Len() int           { return len(vars) }
+func (vars variablesByAddr) Less(i, j int) bool { return vars[i].Addr < vars[j].Addr }
+func (vars variablesByAddr) Swap(i, j int)      { vars[i], vars[j] = vars[j], vars[i] }
+
+// funcCallRetOffset returns the offset of the first return value of fn in
+// its argument frame.
+func (scope *EvalScope) funcCallRetOffset(fn *gosym.Func) (int64, error) {
+	formals, err := scope.funcCallFormalArgs(fn, &argFrameMemory{base: fakeAddress})
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range formals {
+		if v.Flags&VariableReturnArgument != 0 {
+			return int64(v.Addr - fakeAddress), nil
+		}
+	}
+	return 0, fmt.Errorf("%s has no return values", fn.Name)
+}
+
+// funcCallArgFrame returns the argument frame for a call to fn with
+// arguments actualArgs. If isMethod is true the first argument is the
+// receiver.
+func (scope *EvalScope) funcCallArgFrame(fn *gosym.Func, actualArgs []*Variable, isMethod bool) (*argFrameMemory, error) {
+	frame := &argFrameMemory{base: fakeAddress}
+	formals, err := scope.funcCallFormalArgs(fn, frame)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int64
+	formalArgs := make([]*Variable, 0, len(formals))
+	for _, v := range formals {
+		if end := int64(v.Addr-fakeAddress) + v.RealType.Size(); end > size {
+			size = end
+		}
+		if v.Flags&VariableReturnArgument == 0 {
+			formalArgs = append(formalArgs, v)
+		}
+	}
+	if ptrSize := int64(scope.PtrSize()); size%ptrSize != 0 {
+		size += ptrSize - size%ptrSize
+	}
+	frame.buf = make([]byte, size)
+
+	if len(actualArgs) > len(formalArgs) {
+		return nil, fmt.Errorf("too many arguments in call to %s", fn.Name)
+	}
+	if len(actualArgs) < len(formalArgs) {
+		return nil, fmt.Errorf("not enough arguments in call to %s", fn.Name)
+	}
+
+	for i, formalArg := range formalArgs {
+		actualArg := actualArgs[i]
+		if isMethod && i == 0 {
+			var done bool
+			actualArg, done, err = scope.funcCallReceiver(formalArg, actualArg)
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				continue
+			}
+		}
+		if scope.callCtx.checkEscape {
+			if err := scope.escapeCheck(actualArg, actualArg.Name); err != nil {
+				return nil, fmt.Errorf("cannot use %s as argument %s in call to %s: %v", actualArg.Name, formalArg.Name, fn.Name, err)
+			}
+		}
+		if err := scope.funcCallCopyArg(frame, formalArg, actualArg); err != nil {
+			return nil, fmt.Errorf("cannot use %s as argument %s in call to %s: %v", actualArg.Name, formalArg.Name, fn.Name, err)
+		}
+	}
+
+	return frame, nil
+}
+
+// funcCallReceiver converts the receiver of a method call to the type of
+// the receiver of the method, taking its address or dereferencing it as
+// needed. If the receiver was written to the argument frame done is true.
+func (scope *EvalScope) funcCallReceiver(formal, actual *Variable) (recv *Variable, done bool, err error) {
+	formalPtr := formal.Kind == reflect.Ptr
+	actualPtr := actual.Kind == reflect.Ptr
+	switch {
+	case formalPtr && !actualPtr:
+		if actual.Addr == 0 {
+			return nil, false, fmt.Errorf("cannot take the address of %s", actual.Name)
+		}
+		if scope.callCtx.checkEscape {
+			if err := scope.escapeCheckPointer(actual.Addr, actual.Name); err != nil {
+				return nil, false, err
+			}
+		}
+		return nil, true, formal.writeUint(uint64(actual.Addr), int64(scope.PtrSize()))
+	case !formalPtr && actualPtr:
+		name := actual.Name
+		actual = actual.maybeDereference()
+		actual.Name = name
+	}
+	return actual, false, nil
+}
+
+// funcCallCopyArg writes the value of actual to the argument formal of
+// frame.
+func (scope *EvalScope) funcCallCopyArg(frame *argFrameMemory, formal, actual *Variable) error {
+	if actual.Unreadable != nil {
+		return actual.Unreadable
+	}
+	if err := actual.isType(formal.RealType, formal.Kind); err != nil {
+		return err
+	}
+	if actual == nilVariable {
+		// the argument frame is already zeroed
+		return nil
+	}
+
+	switch formal.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Bool:
+		actual.loadValue(loadSingleValue)
+		if actual.Unreadable != nil {
+			return actual.Unreadable
+		}
+		return formal.setValue(actual)
+	case reflect.Ptr:
+		if actual.Addr == 0 && len(actual.Children) == 1 {
+			// pointers created with the '&' operator
+			return formal.writeUint(uint64(actual.Children[0].Addr), int64(scope.PtrSize()))
+		}
+	case reflect.String:
+		if actual.Addr == 0 && actual.Value != nil {
+			// a string constant, it is copied to the target's memory right
+			// before the call, see functionCallState.callNext
+			str := funcCallString{off: int64(formal.Addr - frame.base), s: constant.StringVal(actual.Value)}
+			if len(str.s) > 0 {
+				frame.strs = append(frame.strs, str)
+			}
+			binary.LittleEndian.PutUint64(frame.buf[str.off+int64(scope.PtrSize()):], uint64(len(str.s)))
+			return nil
+		}
+	}
+
+	if actual.Addr == 0 {
+		return funcCallNoAddrErr
+	}
+	buf := make([]byte, formal.RealType.Size())
+	if _, err := actual.mem.ReadMemory(buf, actual.Addr); err != nil {
+		return err
+	}
+	_, err := formal.mem.WriteMemory(formal.Addr, buf)
+	return err
+}
+
+// escapeCheck returns an error if v contains a pointer to the stack of
+// the goroutine. The runtime can move the stack during the call and the
+// called function could let such a pointer escape.
+func (scope *EvalScope) escapeCheck(v *Variable, name string) error {
+	ptrSize := int64(scope.PtrSize())
+	switch v.Kind {
+	case reflect.Ptr:
+		if len(v.Children) == 1 {
+			// pointers created with the '&' operator
+			return scope.escapeCheckPointer(v.Children[0].Addr, name)
+		}
+		return scope.escapeCheckPointer(v.maybeDereference().Addr, name)
+	case reflect.Chan, reflect.String, reflect.Slice:
+		v.loadValue(LoadConfig{false, 0, 0, 0, 0})
+		return scope.escapeCheckPointer(v.Base, name)
+	case reflect.Map, reflect.Func, reflect.UnsafePointer:
+		if v.Addr == 0 {
+			return nil
+		}
+		ptr, err := readUintRaw(v.mem, v.Addr, ptrSize)
+		if err != nil {
+			return err
+		}
+		return scope.escapeCheckPointer(uintptr(ptr), name)
+	case reflect.Interface:
+		if v.Addr == 0 {
+			return nil
+		}
+		data, err := readUintRaw(v.mem, v.Addr+uintptr(ptrSize), ptrSize)
+		if err != nil {
+			return err
+		}
+		return scope.escapeCheckPointer(uintptr(data), name)
+	case reflect.Struct:
+		t := v.RealType.(*godwarf.StructType)
+		for _, field := range t.Field {
+			fv, err := v.toField(field)
+			if err != nil {
+				return err
+			}
+			if err := scope.escapeCheck(fv, fmt.Sprintf("%s.%s", name, field.Name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		for i := int64(0); i < v.Len; i++ {
+			sv, err := v.sliceAccess(int(i))
+			if err != nil {
+				return err
+			}
+			if err := scope.escapeCheck(sv, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (scope *EvalScope) escapeCheckPointer(addr uintptr, name string) error {
+	g, err := funcCallGoroutine(scope.callCtx.p)
+	if err != nil {
+		return err
+	}
+	if uint64(addr) >= g.stacklo && uint64(addr) < g.stackhi {
+		return fmt.Errorf("stack object passed to escaping pointer: %s", name)
+	}
+	return nil
+}
+
+// funcCallInject injects a call to fn, with the argument frame frame, on
+// the selected goroutine and resumes the target until the call is finished.
+func (scope *EvalScope) funcCallInject(fn *gosym.Func, closureAddr uint64, frame *argFrameMemory) (retvars []*Variable, panicvar *Variable, err error) {
+	p := scope.callCtx.p
+	bi := scope.BinInfo
+	fncall := &p.Common().fncallState
+	if fncall.inProgress {
+		return nil, nil, funcCallInProgressErr
+	}
+	dbgcallfn := bi.LookupFunc(debugCallFunctionName)
+	if dbgcallfn == nil {
+		return nil, nil, funcCallUnsupportedErr
+	}
+	g, err := funcCallGoroutine(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mallocgc *gosym.Func
+	var mallocgcRet int64
+	var alloc []byte
+	if len(frame.strs) > 0 {
+		mallocgc = bi.LookupFunc(mallocgcFunctionName)
+		if mallocgc == nil {
+			return nil, nil, errors.New("could not find runtime.mallocgc")
+		}
+		mallocgcRet, err = scope.funcCallRetOffset(mallocgc)
+		if err != nil {
+			return nil, nil, err
+		}
+		size := 0
+		for _, str := range frame.strs {
+			size += len(str.s)
+		}
+		args := []*Variable{
+			newConstant(constant.MakeInt64(int64(size)), scope.Mem),
+			nilVariable,
+			newConstant(constant.MakeBool(false), scope.Mem),
+		}
+		mframe, err := scope.funcCallArgFrame(mallocgc, args, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		alloc = mframe.buf
+	}
+
+	thread := g.Thread
+	regs, err := thread.Registers(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if regs.SP()-256 <= g.stacklo {
+		return nil, nil, funcCallNotEnoughStackErr
+	}
+
+	if err := callOP(bi, thread, regs, dbgcallfn.Entry, regs.PC()); err != nil {
+		thread.RestoreRegisters(regs)
+		return nil, nil, err
+	}
+	// debugCallV1 reads the size of the argument frame at SP-2*pointer
+	// size, using the SP after the return address was pushed. The frame it
+	// reserves is also large enough for the call to mallocgc.
+	if err := writePointer(bi, thread, regs.SP()-3*uint64(bi.Arch.PtrSize()), uint64(len(frame.buf))); err != nil {
+		thread.RestoreRegisters(regs)
+		return nil, nil, err
+	}
+
+	*fncall = functionCallState{
+		inProgress:  true,
+		savedRegs:   regs,
+		fn:          fn,
+		closureAddr: closureAddr,
+		argmem:      frame.buf,
+		strs:        frame.strs,
+		alloc:       alloc,
+		mallocgc:    mallocgc,
+		mallocgcRet: mallocgcRet,
+		retLoadCfg:  scope.callCtx.retLoadCfg,
+	}
+
+	if err := Continue(p); err != nil {
+		return nil, nil, err
+	}
+	if !fncall.finished {
+		return nil, nil, funcCallInterruptedErr
+	}
+
+	// the runtime could have moved the stack of the goroutine during the
+	// call, adjust the frame of the scope
+	if g, _ := GetG(p.CurrentThread()); g != nil && scope.StackHi != 0 && g.stackhi != scope.StackHi {
+		scope.CFA += int64(g.stackhi) - int64(scope.StackHi)
+		scope.StackHi = g.stackhi
+		scope.Gvar = g.variable
+	}
+
+	return fncall.retvars, fncall.panicvar, nil
+}
+
+// callOP simulates a CALL instruction to callAddr on thread, that returns
+// to retAddr.
+func callOP(bi *BinaryInfo, thread Thread, regs Registers, callAddr, retAddr uint64) error {
+	sp := regs.SP() - uint64(bi.Arch.PtrSize())
+	if err := thread.SetReg(int(x86asm.RSP), sp); err != nil {
+		return err
+	}
+	if err := writePointer(bi, thread, sp, retAddr); err != nil {
+		return err
+	}
+	return thread.SetReg(int(x86asm.RIP), callAddr)
+}
+
+func writePointer(bi *BinaryInfo, mem MemoryReadWriter, addr, val uint64) error {
+	buf := make([]byte, bi.Arch.PtrSize())
+	binary.LittleEndian.PutUint64(buf, val)
+	_, err := mem.WriteMemory(uintptr(addr), buf)
+	return err
+}
+
+// onDebugCallFunction returns true if thread is stopped inside
+// runtime.debugCallV1 or one of its helpers.
+func onDebugCallFunction(thread Thread) bool {
+	loc, err := thread.Location()
+	if err != nil || loc.Fn == nil {
+		return false
+	}
+	return strings.HasPrefix(loc.Fn.Name, debugCallFunctionNamePrefix)
+}
+
+func (fncall *functionCallState) setErr(err error) {
+	if fncall.err == nil {
+		fncall.err = err
+	}
+}
+
+// step executes the next step of the function call protocol, thread is
+// stopped at one of the INT3 instructions of debugCallV1.
+func (fncall *functionCallState) step(p Process, thread Thread) {
+	bi := p.BinInfo()
+
+	regs, err := thread.Registers(false)
+	if err != nil {
+		fncall.setErr(err)
+		fncall.finished = true
+		return
+	}
+	rax, _ := regs.Get(int(x86asm.RAX))
+	sp := regs.SP()
+
+	switch rax {
+	case debugCallAXPrecheckFailed:
+		// the reason why the call can not be made is a string at SP, the
+		// runtime will then ask us to restore the registers
+		reason, err := readTopstackVariable(bi, thread, sp, "string", loadFullValue)
+		if err != nil {
+			fncall.setErr(fmt.Errorf("could not get precheck error reason: %v", err))
+			break
+		}
+		fncall.setErr(errors.New(constant.StringVal(reason.Value)))
+
+	case debugCallAXCompleteCall:
+		fncall.callRet = regs.PC()
+		fncall.callNext(bi, thread, regs)
+
+	case debugCallAXReadReturn:
+		if len(fncall.strs) > 0 && !fncall.allocated {
+			// runtime.mallocgc returned. All the string constants are
+			// copied to the single block it allocated and fn is called
+			// right away, no other call is made in between that could let
+			// the garbage collector free the block before fn's argument
+			// frame references it.
+			if fncall.err == nil {
+				fncall.allocStrings(bi, thread, sp)
+			}
+			if fncall.err == nil {
+				fncall.callNext(bi, thread, regs)
+			}
+			break
+		}
+		// the return values are in the argument frame at SP, they are read
+		// now because the frame is gone once the call finishes
+		mem := cacheMemory(thread, uintptr(sp), len(fncall.argmem))
+		scope := &EvalScope{fncall.fn.Entry, int64(sp), mem, nil, bi, 0, nil, nil, nil}
+		vars, err := scope.variablesByTag(dwarf.TagFormalParameter, nil)
+		if err != nil {
+			fncall.setErr(fmt.Errorf("could not read return values: %v", err))
+			break
+		}
+		fncall.retvars = filterReturnValues(vars)
+		for _, v := range fncall.retvars {
+			v.loadValue(fncall.retLoadCfg)
+		}
+
+	case debugCallAXReadPanic:
+		if len(fncall.strs) > 0 && !fncall.allocated {
+			fncall.setErr(fmt.Errorf("%s panicked", mallocgcFunctionName))
+			break
+		}
+		// the value of the panic is an interface{} at SP
+		fncall.panicvar, err = readTopstackVariable(bi, thread, sp, "interface {}", fncall.retLoadCfg)
+		if err != nil {
+			fncall.setErr(fmt.Errorf("could not read panic: %v", err))
+			break
+		}
+		fncall.panicvar.Name = "~panic"
+
+	case debugCallAXRestoreRegisters:
+		// last step of the protocol: restore all registers except PC and SP
+		// and let debugCallV1 return to where the goroutine was stopped
+		fncall.finished = true
+		pc := regs.PC()
+		if err := thread.RestoreRegisters(fncall.savedRegs); err != nil {
+			fncall.setErr(fmt.Errorf("could not restore registers: %v", err))
+		}
+		if err := thread.SetReg(int(x86asm.RIP), pc); err != nil {
+			fncall.setErr(fmt.Errorf("could not restore PC: %v", err))
+		}
+		if err := thread.SetReg(int(x86asm.RSP), sp); err != nil {
+			fncall.setErr(fmt.Errorf("could not restore SP: %v", err))
+		}
+		for onDebugCallFunction(thread) {
+			if err := thread.StepInstruction(); err != nil {
+				fncall.setErr(fmt.Errorf("could not step out of %s: %v", debugCallFunctionName, err))
+				break
+			}
+		}
+
+	default:
+		// an unknown value of RAX, the safest thing to do is to ignore it
+	}
+}
+
+// callNext makes the next call from the frame of debugCallV1 at the SP of
+// regs: a call to runtime.mallocgc for the string constants passed to fn,
+// if there are any, then the call to fn. Both return to the instruction
+// that follows the stop where debugCallV1 asked to complete the call.
+func (fncall *functionCallState) callNext(bi *BinaryInfo, thread Thread, regs Registers) {
+	sp := regs.SP()
+	if len(fncall.strs) > 0 && !fncall.allocated {
+		if _, err := thread.WriteMemory(uintptr(sp), fncall.alloc); err != nil {
+			fncall.setErr(fmt.Errorf("could not write arguments: %v", err))
+			return
+		}
+		if err := callOP(bi, thread, regs, fncall.mallocgc.Entry, fncall.callRet); err != nil {
+			fncall.setErr(err)
+		}
+		return
+	}
+	// write the arguments at SP and call the function
+	if _, err := thread.WriteMemory(uintptr(sp), fncall.argmem); err != nil {
+		fncall.setErr(fmt.Errorf("could not write arguments: %v", err))
+	}
+	if fncall.closureAddr != 0 {
+		// closures expect the address of the closure in DX
+		if err := thread.SetReg(int(x86asm.RDX), fncall.closureAddr); err != nil {
+			fncall.setErr(err)
+		}
+	}
+	if err := callOP(bi, thread, regs, fncall.fn.Entry, fncall.callRet); err != nil {
+		fncall.setErr(err)
+	}
+}
+
+// allocStrings copies the string constants, one after the other, to the
+// memory returned by the call to runtime.mallocgc, whose argument frame is
+// at sp, and points their string headers in the argument frame of fn to
+// it.
+func (fncall *functionCallState) allocStrings(bi *BinaryInfo, thread Thread, sp uint64) {
+	fncall.allocated = true
+	addr, err := readUintRaw(thread, uintptr(sp)+uintptr(fncall.mallocgcRet), int64(bi.Arch.PtrSize()))
+	if err != nil {
+		fncall.setErr(fmt.Errorf("could not allocate strings: %v", err))
+		return
+	}
+	var buf []byte
+	for _, str := range fncall.strs {
+		binary.LittleEndian.PutUint64(fncall.argmem[str.off:], addr+uint64(len(buf)))
+		buf = append(buf, str.s...)
+	}
+	if _, err := thread.WriteMemory(uintptr(addr), buf); err != nil {
+		fncall.setErr(fmt.Errorf("could not allocate strings: %v", err))
+	}
+}
+
+// readTopstackVariable reads a variable of type typename at sp.
+func readTopstackVariable(bi *BinaryInfo, thread Thread, sp uint64, typename string, cfg LoadConfig) (*Variable, error) {
+	typ, err := bi.findType(typename)
+	if err != nil {
+		return nil, err
+	}
+	mem := cacheMemory(thread, uintptr(sp), int(typ.Size()))
+	v := newVariable("", uintptr(sp), typ, bi, mem)
+	v.loadValue(cfg)
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	return v, nil
+}
+
+// argFrameMemory is the memory of an argument frame being built.
+type argFrameMemory struct {
+	base uintptr
+	buf  []byte
+	// strs are the string constants that have to be copied to the
+	// target's memory before the call
+	strs []funcCallString
+}
+
+// funcCallString is a string constant passed as argument to a function
+// call, off is the offset of its string header in the argument frame.
+type funcCallString struct {
+	off int64
+	s   string
+}
+
+func (mem *argFrameMemory) ReadMemory(data []byte, addr uintptr) (int, error) {
+	if addr < mem.base || addr+uintptr(len(data)) > mem.base+uintptr(len(mem.buf)) {
+		return 0, fmt.Errorf("read out of bounds of the argument frame %#x", addr)
+	}
+	return copy(data, mem.buf[addr-mem.base:]), nil
+}
+
+func (mem *argFrameMemory) WriteMemory(addr uintptr, data []byte) (int, error) {
+	if addr < mem.base || addr+uintptr(len(data)) > mem.base+uintptr(len(mem.buf)) {
+		return 0, fmt.Errorf("write out of bounds of the argument frame %#x", addr)
+	}
+	return copy(mem.buf[addr-mem.base:], data), nil
+}
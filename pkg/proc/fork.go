@@ -0,0 +1,58 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FollowForkMode selects which processes are debugged after the target
+// forks.
+type FollowForkMode uint8
+
+const (
+	// FollowParent keeps debugging the parent, children run untraced.
+	FollowParent FollowForkMode = iota
+	// FollowChild detaches from the parent and debugs the child.
+	FollowChild
+	// FollowBoth debugs both the parent and the child.
+	FollowBoth
+)
+
+func (mode FollowForkMode) String() string {
+	switch mode {
+	case FollowParent:
+		return "parent"
+	case FollowChild:
+		return "child"
+	case FollowBoth:
+		return "both"
+	default:
+		return fmt.Sprintf("FollowForkMode(%d)", uint8(mode))
+	}
+}
+
+// ParseFollowForkMode converts "parent", "child" or "both" into a
+// FollowForkMode.
+func ParseFollowForkMode(s string) (FollowForkMode, error) {
+	switch s {
+	case "parent":
+		return FollowParent, nil
+	case "child":
+		return FollowChild, nil
+	case "both":
+		return FollowBoth, nil
+	default:
+		return FollowParent, fmt.Errorf("unknown follow-fork-mode %q, must be parent, child or both", s)
+	}
+}
+
+// FollowForkUnsupportedErr is returned by backends that can not debug the
+// children of the target.
+var FollowForkUnsupportedErr = errors.New("following forked processes is only supported by the native backend on Linux")
+
+// Fork returns the CommonProcess of a child forked by the process that
+// owns c: the settings of c carry over to the child, the state of a
+// function call in progress does not.
+func (c *CommonProcess) Fork() CommonProcess {
+	return CommonProcess{fncallEnabled: c.fncallEnabled, fncallAllowed: c.fncallAllowed, stepFilter: c.stepFilter}
+}
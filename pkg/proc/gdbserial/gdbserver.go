@@ -125,6 +125,7 @@ type Process struct {
 	waitChan chan *os.ProcessState
 
 	allGCache []*proc.G
+	common    proc.CommonProcess
 }
 
 // Thread is a thread.
@@ -137,6 +138,7 @@ type Thread struct {
 	BreakpointConditionError error
 	p                        *Process
 	setbp                    bool // thread was stopped because of a breakpoint
+	proc.CommonThread
 }
 
 // gdbRegisters represents the current value of the registers of a thread.
@@ -323,7 +325,7 @@ func (p *Process) Connect(conn net.Conn, path string, pid int) error {
 		if err == nil {
 			bp.Name = proc.UnrecoveredPanic
 			bp.Variables = []string{"runtime.curg._panic.arg"}
-			bp.ID = -1
+			bp.ID = proc.UnrecoveredPanicID
 			p.breakpointIDCounter--
 		}
 	}
@@ -505,6 +507,10 @@ func (p *Process) BinInfo() *proc.BinaryInfo {
 	return &p.bi
 }
 
+func (p *Process) Common() *proc.CommonProcess {
+	return &p.common
+}
+
 func (p *Process) Recorded() (bool, string) {
 	return p.tracedir != "", p.tracedir
 }
@@ -910,14 +916,17 @@ func (p *Process) ClearCheckpoint(id int) error {
 
 func (p *Process) Direction(dir proc.Direction) error {
 	if p.tracedir == "" {
-		return proc.NotRecordedErr
-	}
-	if p.conn.conn == nil {
-		return proc.ProcessExitedError{Pid: p.conn.pid}
+		if dir != proc.Forward {
+			return proc.NotRecordedErr
+		}
+		return nil
 	}
 	if p.conn.direction == dir {
 		return nil
 	}
+	if p.conn.conn == nil {
+		return proc.ProcessExitedError{Pid: p.conn.pid}
+	}
 	for _, bp := range p.Breakpoints() {
 		if bp.Internal() {
 			return ErrDirChange
@@ -927,6 +936,10 @@ func (p *Process) Direction(dir proc.Direction) error {
 	return nil
 }
 
+func (p *Process) GetDirection() proc.Direction {
+	return p.conn.direction
+}
+
 func (p *Process) Breakpoints() map[uint64]*proc.Breakpoint {
 	return p.breakpoints
 }
@@ -944,6 +957,30 @@ func (p *Process) FindBreakpoint(pc uint64) (*proc.Breakpoint, bool) {
 }
 
 func (p *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.Expr) (*proc.Breakpoint, error) {
+	bp, err := p.setBreakpoint(addr, kind, cond)
+	if err != nil {
+		return bp, err
+	}
+	if kind != proc.UserBreakpoint {
+		p.internalBreakpointIDCounter++
+		bp.ID = p.internalBreakpointIDCounter
+	} else {
+		p.breakpointIDCounter++
+		bp.ID = p.breakpointIDCounter
+	}
+	return bp, nil
+}
+
+func (p *Process) SetBreakpointWithID(id int, addr uint64) (*proc.Breakpoint, error) {
+	bp, err := p.setBreakpoint(addr, proc.UserBreakpoint, nil)
+	if err != nil {
+		return bp, err
+	}
+	bp.ID = id
+	return bp, nil
+}
+
+func (p *Process) setBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.Expr) (*proc.Breakpoint, error) {
 	if bp, ok := p.breakpoints[addr]; ok {
 		return bp, proc.BreakpointExistsError{bp.File, bp.Line, bp.Addr}
 	}
@@ -962,14 +999,6 @@ func (p *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.
 		HitCount:     map[int]uint64{},
 	}
 
-	if kind != proc.UserBreakpoint {
-		p.internalBreakpointIDCounter++
-		newBreakpoint.ID = p.internalBreakpointIDCounter
-	} else {
-		p.breakpointIDCounter++
-		newBreakpoint.ID = p.breakpointIDCounter
-	}
-
 	if err := p.conn.setBreakpoint(addr); err != nil {
 		return nil, err
 	}
@@ -977,6 +1006,44 @@ func (p *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.
 	return newBreakpoint, nil
 }
 
+func (p *Process) SetWatchpoint(addr uint64, wtype proc.WatchType, cond ast.Expr) (*proc.Breakpoint, error) {
+	return nil, proc.WatchpointsUnsupportedErr
+}
+
+func (p *Process) CatchSyscalls(names []string, enabled bool) error {
+	return proc.SyscallCatchpointsUnsupportedErr
+}
+
+func (p *Process) SetSignalHandling(sig string, h proc.SignalHandling) error {
+	return proc.SignalHandlingUnsupportedErr
+}
+
+func (p *Process) ReceivedSignals() []proc.Signal {
+	return nil
+}
+
+func (p *Process) SetFollowForkMode(mode proc.FollowForkMode) error {
+	if mode != proc.FollowParent {
+		return proc.FollowForkUnsupportedErr
+	}
+	return nil
+}
+
+func (p *Process) Inferiors() []proc.Process {
+	return []proc.Process{p}
+}
+
+func (p *Process) CurrentInferior() proc.Process {
+	return p
+}
+
+func (p *Process) SwitchInferior(pid int) error {
+	if pid != p.Pid() {
+		return fmt.Errorf("no inferior with pid %d", pid)
+	}
+	return nil
+}
+
 func (p *Process) ClearBreakpoint(addr uint64) (*proc.Breakpoint, error) {
 	if p.exited {
 		return nil, &proc.ProcessExitedError{Pid: p.conn.pid}
@@ -1484,16 +1551,42 @@ func (thread *Thread) SetCurrentBreakpoint() error {
 		}
 		thread.CurrentBreakpoint = bp
 		thread.BreakpointConditionMet, thread.BreakpointConditionError = bp.CheckCondition(thread)
-		if thread.CurrentBreakpoint != nil && thread.BreakpointConditionMet {
-			if g, err := proc.GetG(thread); err == nil {
-				thread.CurrentBreakpoint.HitCount[g.ID]++
-			}
-			thread.CurrentBreakpoint.TotalHitCount++
-		}
 	}
 	return nil
 }
 
+func (thread *Thread) Common() *proc.CommonThread {
+	return &thread.CommonThread
+}
+
+// SetReg changes the value of one of the 64-bit general purpose registers
+// or of the instruction pointer.
+func (thread *Thread) SetReg(regNum int, value uint64) error {
+	reg := x86asm.Reg(regNum)
+	if (reg < x86asm.RAX || reg > x86asm.R15) && reg != x86asm.RIP {
+		return proc.UnknownRegisterError
+	}
+	name := strings.ToLower(reg.String())
+	r, ok := thread.regs.regs[name]
+	if !ok {
+		return proc.UnknownRegisterError
+	}
+	binary.LittleEndian.PutUint64(r.value, value)
+	return thread.writeSomeRegisters(name)
+}
+
+func (thread *Thread) RestoreRegisters(proc.Registers) error {
+	return proc.ChangeRegisterUnsupportedErr
+}
+
+func (thread *Thread) Syscall() *proc.Syscall {
+	return nil
+}
+
+func (thread *Thread) Signal() *proc.Signal {
+	return nil
+}
+
 func (regs *gdbRegisters) PC() uint64 {
 	return binary.LittleEndian.Uint64(regs.regs[regnamePC].value)
 }
@@ -30,6 +30,8 @@ type RecordingManipulation interface {
 	Restart(pos string) error
 	// Direction changes execution direction.
 	Direction(Direction) error
+	// GetDirection returns the current execution direction.
+	GetDirection() Direction
 	// When returns current recording position.
 	When() (string, error)
 	// Checkpoint sets a checkpoint at the current position.
@@ -62,6 +64,8 @@ type Info interface {
 	ResumeNotify(chan<- struct{})
 	Exited() bool
 	BinInfo() *BinaryInfo
+	// Common returns the CommonProcess structure for this process
+	Common() *CommonProcess
 
 	ThreadInfo
 	GoroutineInfo
@@ -93,12 +97,41 @@ type ProcessManipulation interface {
 	Halt() error
 	Kill() error
 	Detach(bool) error
+	// SetSignalHandling changes what happens when the target receives sig,
+	// a signal name like "SIGUSR1" or a signal number.
+	SetSignalHandling(sig string, h SignalHandling) error
+	// ReceivedSignals returns the signals that were reported without
+	// stopping the target since the last call to ReceivedSignals.
+	ReceivedSignals() []Signal
+	// SetFollowForkMode changes which processes are debugged after the
+	// target forks.
+	SetFollowForkMode(mode FollowForkMode) error
+	// Inferiors returns the processes being debugged: the target and the
+	// children it forked that are being followed.
+	Inferiors() []Process
+	// CurrentInferior returns the inferior that caused the last stop, or
+	// the one selected with SwitchInferior. Resuming any inferior resumes
+	// all of them.
+	CurrentInferior() Process
+	// SwitchInferior makes the inferior with the specified pid the current
+	// inferior.
+	SwitchInferior(pid int) error
 }
 
 // BreakpointManipulation is an interface for managing breakpoints.
 type BreakpointManipulation interface {
 	Breakpoints() map[uint64]*Breakpoint
 	SetBreakpoint(addr uint64, kind BreakpointKind, cond ast.Expr) (*Breakpoint, error)
+	// SetBreakpointWithID sets a user breakpoint at addr with the
+	// specified ID, the ID of an existing breakpoint.
+	SetBreakpointWithID(id int, addr uint64) (*Breakpoint, error)
+	// SetWatchpoint sets a hardware watchpoint on the wtype.Size() bytes
+	// starting at addr.
+	SetWatchpoint(addr uint64, wtype WatchType, cond ast.Expr) (*Breakpoint, error)
 	ClearBreakpoint(addr uint64) (*Breakpoint, error)
 	ClearInternalBreakpoints() error
+	// CatchSyscalls enables or disables stopping the target when a thread
+	// enters or exits one of the named system calls, or any system call if
+	// names is empty.
+	CatchSyscalls(names []string, enabled bool) error
 }
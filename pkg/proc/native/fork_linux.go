@@ -0,0 +1,176 @@
+package native
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"syscall"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+// ptraceOptions returns the options set on every traced thread.
+func (dbp *Process) ptraceOptions() int {
+	opts := syscall.PTRACE_O_TRACECLONE | syscall.PTRACE_O_TRACESYSGOOD
+	if dbp.group.followForkMode != proc.FollowParent {
+		opts |= sys.PTRACE_O_TRACEFORK | sys.PTRACE_O_TRACEVFORK | sys.PTRACE_O_TRACEEXEC
+	}
+	return opts
+}
+
+func (dbp *Process) setFollowForkMode(mode proc.FollowForkMode) error {
+	old := dbp.group.followForkMode
+	dbp.group.followForkMode = mode
+	for _, p := range dbp.group.procs {
+		opts := p.ptraceOptions()
+		for _, th := range p.threads {
+			var err error
+			p.execPtraceFunc(func() { err = syscall.PtraceSetOptions(th.ID, opts) })
+			if err != nil && err != sys.ESRCH {
+				dbp.group.followForkMode = old
+				return fmt.Errorf("could not set options for thread %d: %v", th.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// forked is called when th, a thread of dbp, stops after creating the
+// child process childpid with fork or vfork. The child, which is traced
+// automatically, is either debugged along with dbp or replaces it,
+// depending on the follow-fork-mode.
+func (dbp *Process) forked(th *Thread, childpid int, vfork bool) error {
+	g := dbp.group
+	if g.pendingStops[childpid] {
+		delete(g.pendingStops, childpid)
+	} else if _, _, err := dbp.waitFast(childpid); err != nil {
+		return fmt.Errorf("could not wait for forked process %d: %v", childpid, err)
+	}
+
+	if g.followForkMode == proc.FollowChild {
+		if err := dbp.becomeChild(th, childpid, vfork); err != nil {
+			return err
+		}
+		return dbp.threads[childpid].resume()
+	}
+
+	child := &Process{
+		pid:         childpid,
+		threads:     make(map[int]*Thread),
+		breakpoints: make(map[uint64]*proc.Breakpoint),
+		os: &OSProcessDetails{
+			catchSyscalls:  dbp.os.catchSyscalls,
+			syscallFilter:  dbp.os.syscallFilter,
+			signalHandling: dbp.os.signalHandling,
+		},
+		ptraceChan:     dbp.ptraceChan,
+		ptraceDoneChan: dbp.ptraceDoneChan,
+		bi:             proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH),
+		childProcess:   dbp.childProcess,
+		common:         dbp.common.Fork(),
+		group:          g,
+		parentPid:      dbp.pid,
+	}
+	// The memory of the child is a copy of the memory of the parent,
+	// breakpoints included.
+	// A vfork child shares the memory of the parent until it calls exec,
+	// the breakpoints are left to the parent so that clearing them in the
+	// child, or detaching from it, does not remove them from the parent.
+	var stale []*proc.Breakpoint
+	if !vfork {
+		child.breakpoints, stale = proc.ForkBreakpoints(dbp.breakpoints)
+	}
+	if err := child.LoadInformation(""); err != nil {
+		return fmt.Errorf("could not load forked process %d: %v", childpid, err)
+	}
+	if _, err := child.addThread(childpid, false); err != nil {
+		return err
+	}
+	for _, bp := range stale {
+		if _, err := child.threads[childpid].ClearBreakpoint(bp); err != nil {
+			return fmt.Errorf("could not clear breakpoint at %#x in forked process %d: %v", bp.Addr, childpid, err)
+		}
+	}
+	child.selectedGoroutine, _ = proc.GetG(child.currentThread)
+	g.add(child)
+	return child.threads[childpid].resume()
+}
+
+// becomeChild detaches dbp from its process and makes it debug childpid, a
+// child forked by th, instead. The child runs the same executable, the
+// breakpoints set in its copy of the parent memory are kept.
+func (dbp *Process) becomeChild(th *Thread, childpid int, vfork bool) error {
+	if err := dbp.Halt(); err != nil {
+		return err
+	}
+	for addr, bp := range dbp.breakpoints {
+		if bp.WatchType != 0 {
+			for _, thread := range dbp.threads {
+				if err := thread.clearHardwareBreakpoint(bp.HWBreakIndex); err != nil {
+					return err
+				}
+			}
+			delete(dbp.breakpoints, addr)
+			continue
+		}
+		if _, err := th.ClearBreakpoint(bp); err != nil {
+			return err
+		}
+		if vfork {
+			// the child shares the memory of the parent until it calls exec
+			delete(dbp.breakpoints, addr)
+		}
+	}
+	var err error
+	dbp.execPtraceFunc(func() { err = dbp.detach(false) })
+	if err != nil {
+		return err
+	}
+
+	dbp.parentPid = dbp.pid
+	dbp.pid = childpid
+	dbp.threads = make(map[int]*Thread)
+	dbp.currentThread = nil
+	dbp.allGCache = nil
+	if _, err := dbp.addThread(childpid, false); err != nil {
+		return err
+	}
+	dbp.selectedGoroutine, _ = proc.GetG(dbp.currentThread)
+	return nil
+}
+
+// execed is called when th, a thread of dbp, stops after a successful
+// call to exec: every thread but th is gone and the breakpoints went away
+// with the old program. If the new program can be debugged the stop is
+// reported as the exit of the execve system call, otherwise dbp is
+// detached.
+func (dbp *Process) execed(th *Thread) error {
+	dbp.threads = map[int]*Thread{th.ID: th}
+	dbp.currentThread = th
+	dbp.breakpoints = make(map[uint64]*proc.Breakpoint)
+	dbp.allGCache = nil
+	dbp.common = dbp.common.Fork()
+	th.CurrentBreakpoint = nil
+	// the thread is still inside execve, its syscall-exit-stop follows
+	th.os = &OSSpecificDetails{inSyscall: th.os.inSyscall}
+
+	dbp.bi.Close()
+	dbp.bi = proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	path, _ := os.Readlink(findExecutable("", dbp.pid))
+	if _, err := initializeDebugProcess(dbp, ""); err != nil {
+		var derr error
+		dbp.execPtraceFunc(func() { derr = dbp.detach(false) })
+		dbp.exited = true
+		dbp.group.remove(dbp)
+		if derr != nil {
+			return derr
+		}
+		return fmt.Errorf("process %d executed a program that can not be debugged: %v", dbp.pid, err)
+	}
+	th.os.syscall = &proc.Syscall{Num: sys.SYS_EXECVE, Name: "execve", Args: []string{strconv.Quote(path)}, Exit: true}
+	th.running = false
+	return nil
+}
@@ -0,0 +1,104 @@
+package native
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+// processGroup contains the processes debugged together: the target and
+// the children it forked that are being followed, see SetFollowForkMode.
+// They share the goroutine executing ptrace requests and are resumed and
+// stopped together.
+type processGroup struct {
+	mu             sync.Mutex
+	procs          []*Process
+	current        *Process
+	followForkMode proc.FollowForkMode
+	// pendingStops contains the pids of new children that were seen
+	// stopping before the event announcing their creation.
+	pendingStops map[int]bool
+	// breakpointIDCounter and internalBreakpointIDCounter are shared by
+	// the inferiors so that their breakpoint IDs do not collide.
+	breakpointIDCounter         int
+	internalBreakpointIDCounter int
+}
+
+func newProcessGroup(dbp *Process) *processGroup {
+	return &processGroup{procs: []*Process{dbp}, current: dbp}
+}
+
+func (g *processGroup) getCurrent() *Process {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.current
+}
+
+func (g *processGroup) setCurrent(dbp *Process) {
+	g.mu.Lock()
+	g.current = dbp
+	g.mu.Unlock()
+}
+
+func (g *processGroup) add(dbp *Process) {
+	g.procs = append(g.procs, dbp)
+}
+
+// remove removes dbp from the group, if dbp was the current inferior it
+// stays current until another inferior stops or is selected.
+func (g *processGroup) remove(dbp *Process) {
+	for i := range g.procs {
+		if g.procs[i] == dbp {
+			copy(g.procs[i:], g.procs[i+1:])
+			g.procs = g.procs[:len(g.procs)-1]
+			return
+		}
+	}
+}
+
+// find returns the inferior with the specified pid.
+func (g *processGroup) find(pid int) *Process {
+	for _, p := range g.procs {
+		if p.pid == pid {
+			return p
+		}
+	}
+	return nil
+}
+
+// findThread returns the thread with the specified id, searching all
+// inferiors.
+func (g *processGroup) findThread(tid int) *Thread {
+	for _, p := range g.procs {
+		if th, ok := p.threads[tid]; ok {
+			return th
+		}
+	}
+	return nil
+}
+
+// Inferiors returns the target and the forked children being followed.
+func (dbp *Process) Inferiors() []proc.Process {
+	r := make([]proc.Process, 0, len(dbp.group.procs))
+	for _, p := range dbp.group.procs {
+		r = append(r, p)
+	}
+	return r
+}
+
+// CurrentInferior returns the inferior that stopped last or was selected
+// with SwitchInferior.
+func (dbp *Process) CurrentInferior() proc.Process {
+	return dbp.group.getCurrent()
+}
+
+// SwitchInferior makes the inferior with the specified pid current.
+func (dbp *Process) SwitchInferior(pid int) error {
+	p := dbp.group.find(pid)
+	if p == nil {
+		return fmt.Errorf("no inferior with pid %d", pid)
+	}
+	dbp.group.setCurrent(p)
+	return nil
+}
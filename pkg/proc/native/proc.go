@@ -29,19 +29,24 @@ type Process struct {
 	// Normally selectedGoroutine is currentThread.GetG, it will not be only if SwitchGoroutine is called with a goroutine that isn't attached to a thread
 	selectedGoroutine *proc.G
 
-	allGCache                   []*proc.G
-	os                          *OSProcessDetails
-	breakpointIDCounter         int
-	internalBreakpointIDCounter int
-	firstStart                  bool
-	haltMu                      sync.Mutex
-	halt                        bool
-	resumeChan                  chan<- struct{}
-	exited                      bool
-	ptraceChan                  chan func()
-	ptraceDoneChan              chan interface{}
-	childProcess                bool // this process was launched, not attached to
-	manualStopRequested         bool
+	allGCache           []*proc.G
+	os                  *OSProcessDetails
+	firstStart          bool
+	haltMu              sync.Mutex
+	halt                bool
+	resumeChan          chan<- struct{}
+	exited              bool
+	ptraceChan          chan func()
+	ptraceDoneChan      chan interface{}
+	childProcess        bool // this process was launched, not attached to
+	manualStopRequested bool
+	common              proc.CommonProcess
+
+	// group contains this process and the other processes debugged with
+	// it, parentPid is the pid of the process that forked this one if it
+	// is a followed child.
+	group     *processGroup
+	parentPid int
 }
 
 // New returns an initialized Process struct. Before returning,
@@ -58,7 +63,9 @@ func New(pid int) *Process {
 		ptraceChan:     make(chan func()),
 		ptraceDoneChan: make(chan interface{}),
 		bi:             proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH),
+		common:         proc.NewCommonProcess(runtime.GOOS == "linux"),
 	}
+	dbp.group = newProcessGroup(dbp)
 	go dbp.handlePtraceFuncs()
 	return dbp
 }
@@ -67,16 +74,42 @@ func (dbp *Process) BinInfo() *proc.BinaryInfo {
 	return &dbp.bi
 }
 
+// Common returns common information across Process
+// implementations.
+func (dbp *Process) Common() *proc.CommonProcess {
+	return &dbp.common
+}
+
 func (dbp *Process) Recorded() (bool, string)                { return false, "" }
 func (dbp *Process) Restart(string) error                    { return proc.NotRecordedErr }
-func (dbp *Process) Direction(proc.Direction) error          { return proc.NotRecordedErr }
+func (dbp *Process) GetDirection() proc.Direction            { return proc.Forward }
 func (dbp *Process) When() (string, error)                   { return "", nil }
 func (dbp *Process) Checkpoint(string) (int, error)          { return -1, proc.NotRecordedErr }
 func (dbp *Process) Checkpoints() ([]proc.Checkpoint, error) { return nil, proc.NotRecordedErr }
 func (dbp *Process) ClearCheckpoint(int) error               { return proc.NotRecordedErr }
 
-// Detach from the process being debugged, optionally killing it.
+// Direction changes execution direction, only Forward is supported.
+func (dbp *Process) Direction(dir proc.Direction) error {
+	if dir != proc.Forward {
+		return proc.NotRecordedErr
+	}
+	return nil
+}
+
+// Detach from the process being debugged, optionally killing it. The
+// other inferiors are detached, or killed, as well.
 func (dbp *Process) Detach(kill bool) (err error) {
+	for _, p := range append([]*Process(nil), dbp.group.procs...) {
+		if p != dbp {
+			if err := p.detachInferior(kill); err != nil {
+				return err
+			}
+		}
+	}
+	return dbp.detachInferior(kill)
+}
+
+func (dbp *Process) detachInferior(kill bool) (err error) {
 	if dbp.exited {
 		return nil
 	}
@@ -108,6 +141,7 @@ func (dbp *Process) Detach(kill bool) (err error) {
 			err = killProcess(dbp.pid)
 		}
 	})
+	dbp.group.remove(dbp)
 	dbp.bi.Close()
 	return
 }
@@ -197,6 +231,32 @@ func (dbp *Process) ManualStopRequested() bool {
 // break point table. Setting a break point must be thread specific due to
 // ptrace actions needing the thread to be in a signal-delivery-stop.
 func (dbp *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.Expr) (*proc.Breakpoint, error) {
+	bp, err := dbp.setBreakpoint(addr, kind, cond)
+	if err != nil {
+		return bp, err
+	}
+	if kind != proc.UserBreakpoint {
+		dbp.group.internalBreakpointIDCounter++
+		bp.ID = dbp.group.internalBreakpointIDCounter
+	} else {
+		dbp.group.breakpointIDCounter++
+		bp.ID = dbp.group.breakpointIDCounter
+	}
+	return bp, nil
+}
+
+// SetBreakpointWithID sets a user breakpoint at addr with the specified
+// ID, it is used to add addresses to an existing logical breakpoint.
+func (dbp *Process) SetBreakpointWithID(id int, addr uint64) (*proc.Breakpoint, error) {
+	bp, err := dbp.setBreakpoint(addr, proc.UserBreakpoint, nil)
+	if err != nil {
+		return bp, err
+	}
+	bp.ID = id
+	return bp, nil
+}
+
+func (dbp *Process) setBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.Expr) (*proc.Breakpoint, error) {
 	tid := dbp.currentThread.ID
 
 	if bp, ok := dbp.FindBreakpoint(addr); ok {
@@ -218,14 +278,6 @@ func (dbp *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond as
 		HitCount:     map[int]uint64{},
 	}
 
-	if kind != proc.UserBreakpoint {
-		dbp.internalBreakpointIDCounter++
-		newBreakpoint.ID = dbp.internalBreakpointIDCounter
-	} else {
-		dbp.breakpointIDCounter++
-		newBreakpoint.ID = dbp.breakpointIDCounter
-	}
-
 	thread := dbp.threads[tid]
 	originalData := make([]byte, dbp.bi.Arch.BreakpointSize())
 	_, err := thread.ReadMemory(originalData, uintptr(addr))
@@ -241,17 +293,78 @@ func (dbp *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond as
 	return newBreakpoint, nil
 }
 
+// SetWatchpoint sets a watchpoint at addr, using the same debug register
+// on every thread of the process. Threads created later will inherit it,
+// see addThread.
+func (dbp *Process) SetWatchpoint(addr uint64, wtype proc.WatchType, cond ast.Expr) (*proc.Breakpoint, error) {
+	if bp, ok := dbp.breakpoints[addr]; ok {
+		return bp, proc.BreakpointExistsError{bp.File, bp.Line, bp.Addr}
+	}
+
+	used := make([]bool, maxHardwareBreakpoints)
+	for _, bp := range dbp.breakpoints {
+		if bp.WatchType != 0 {
+			used[bp.HWBreakIndex] = true
+		}
+	}
+	idx := uint8(0)
+	for int(idx) < len(used) && used[idx] {
+		idx++
+	}
+	if int(idx) >= len(used) {
+		return nil, fmt.Errorf("no free debug registers, at most %d watchpoints can be set", maxHardwareBreakpoints)
+	}
+
+	for _, thread := range dbp.threads {
+		if err := thread.writeHardwareBreakpoint(addr, wtype, idx); err != nil {
+			for _, thread := range dbp.threads {
+				thread.clearHardwareBreakpoint(idx)
+			}
+			return nil, err
+		}
+	}
+
+	dbp.group.breakpointIDCounter++
+	newBreakpoint := &proc.Breakpoint{
+		ID:           dbp.group.breakpointIDCounter,
+		Addr:         addr,
+		Kind:         proc.UserBreakpoint,
+		Cond:         cond,
+		HitCount:     map[int]uint64{},
+		WatchType:    wtype,
+		HWBreakIndex: idx,
+	}
+	dbp.breakpoints[addr] = newBreakpoint
+
+	return newBreakpoint, nil
+}
+
+func (dbp *Process) hasWatchpoints() bool {
+	for _, bp := range dbp.breakpoints {
+		if bp.WatchType != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // ClearBreakpoint clears the breakpoint at addr.
 func (dbp *Process) ClearBreakpoint(addr uint64) (*proc.Breakpoint, error) {
 	if dbp.exited {
 		return nil, &proc.ProcessExitedError{Pid: dbp.Pid()}
 	}
-	bp, ok := dbp.FindBreakpoint(addr)
+	bp, ok := dbp.breakpoints[addr]
 	if !ok {
 		return nil, proc.NoBreakpointError{Addr: addr}
 	}
 
-	if _, err := dbp.currentThread.ClearBreakpoint(bp); err != nil {
+	if bp.WatchType != 0 {
+		for _, thread := range dbp.threads {
+			if err := thread.clearHardwareBreakpoint(bp.HWBreakIndex); err != nil {
+				return nil, err
+			}
+		}
+	} else if _, err := dbp.currentThread.ClearBreakpoint(bp); err != nil {
 		return nil, err
 	}
 
@@ -265,13 +378,18 @@ func (dbp *Process) ContinueOnce() (proc.Thread, error) {
 		return nil, &proc.ProcessExitedError{Pid: dbp.Pid()}
 	}
 
-	if err := dbp.resume(); err != nil {
-		return nil, err
+	// all inferiors are resumed together
+	for _, p := range dbp.group.procs {
+		if err := p.resume(); err != nil {
+			return nil, err
+		}
 	}
 
-	dbp.allGCache = nil
-	for _, th := range dbp.threads {
-		th.clearBreakpointState()
+	for _, p := range dbp.group.procs {
+		p.allGCache = nil
+		for _, th := range p.threads {
+			th.clearBreakpointState()
+		}
 	}
 
 	if dbp.resumeChan != nil {
@@ -283,11 +401,23 @@ func (dbp *Process) ContinueOnce() (proc.Thread, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := dbp.Halt(); err != nil {
-		return nil, dbp.exitGuard(err)
+	for _, p := range dbp.group.procs {
+		if err := p.Halt(); err != nil {
+			return nil, p.exitGuard(err)
+		}
+		if err := p.setCurrentBreakpoints(trapthread); err != nil {
+			return nil, err
+		}
 	}
-	if err := dbp.setCurrentBreakpoints(trapthread); err != nil {
-		return nil, err
+	if trapthread.dbp != dbp {
+		// the stop happened in another inferior, a next or step in progress
+		// in this one is abandoned
+		if !dbp.exited {
+			if err := dbp.ClearInternalBreakpoints(); err != nil {
+				return nil, err
+			}
+		}
+		dbp.group.setCurrent(trapthread.dbp)
 	}
 	return trapthread, err
 }
@@ -410,8 +540,8 @@ func initializeDebugProcess(dbp *Process, path string) (*Process, error) {
 		if err == nil {
 			bp.Name = proc.UnrecoveredPanic
 			bp.Variables = []string{"runtime.curg._panic.arg"}
-			bp.ID = -1
-			dbp.breakpointIDCounter--
+			bp.ID = proc.UnrecoveredPanicID
+			dbp.group.breakpointIDCounter--
 		}
 	}
 
@@ -420,7 +550,7 @@ func initializeDebugProcess(dbp *Process, path string) (*Process, error) {
 
 func (dbp *Process) ClearInternalBreakpoints() error {
 	for _, bp := range dbp.breakpoints {
-		if !bp.Internal() {
+		if !bp.Internal() || bp.Kind == proc.WatchOutOfScopeBreakpoint {
 			continue
 		}
 		if _, err := dbp.ClearBreakpoint(bp.Addr); err != nil {
@@ -435,6 +565,36 @@ func (dbp *Process) ClearInternalBreakpoints() error {
 	return nil
 }
 
+// CatchSyscalls enables or disables syscall catchpoints, see
+// proc.BreakpointManipulation.
+func (dbp *Process) CatchSyscalls(names []string, enabled bool) error {
+	if dbp.exited {
+		return &proc.ProcessExitedError{Pid: dbp.Pid()}
+	}
+	return dbp.catchSyscalls(names, enabled)
+}
+
+// SetSignalHandling changes how sig is handled, see
+// proc.ProcessManipulation.
+func (dbp *Process) SetSignalHandling(sig string, h proc.SignalHandling) error {
+	return dbp.setSignalHandling(sig, h)
+}
+
+// ReceivedSignals returns the signals that were reported without stopping
+// the target since the last call.
+func (dbp *Process) ReceivedSignals() []proc.Signal {
+	return dbp.receivedSignals()
+}
+
+// SetFollowForkMode changes which processes are debugged after the target
+// forks, see proc.ProcessManipulation.
+func (dbp *Process) SetFollowForkMode(mode proc.FollowForkMode) error {
+	if dbp.exited {
+		return &proc.ProcessExitedError{Pid: dbp.Pid()}
+	}
+	return dbp.setFollowForkMode(mode)
+}
+
 func (dbp *Process) handlePtraceFuncs() {
 	// We must ensure here that we are running on the same thread during
 	// while invoking the ptrace(2) syscall. This is due to the fact that ptrace(2) expects
@@ -454,8 +614,12 @@ func (dbp *Process) execPtraceFunc(fn func()) {
 
 func (dbp *Process) postExit() {
 	dbp.exited = true
-	close(dbp.ptraceChan)
-	close(dbp.ptraceDoneChan)
+	dbp.group.remove(dbp)
+	if len(dbp.group.procs) == 0 {
+		// the goroutine executing ptrace requests is shared by all inferiors
+		close(dbp.ptraceChan)
+		close(dbp.ptraceDoneChan)
+	}
 	dbp.bi.Close()
 }
 
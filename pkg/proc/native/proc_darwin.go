@@ -444,3 +444,22 @@ func (dbp *Process) resume() error {
 func (dbp *Process) detach(kill bool) error {
 	return PtraceDetach(dbp.pid, 0)
 }
+
+func (dbp *Process) catchSyscalls(names []string, enabled bool) error {
+	return proc.SyscallCatchpointsUnsupportedErr
+}
+
+func (dbp *Process) setSignalHandling(sig string, h proc.SignalHandling) error {
+	return proc.SignalHandlingUnsupportedErr
+}
+
+func (dbp *Process) receivedSignals() []proc.Signal {
+	return nil
+}
+
+func (dbp *Process) setFollowForkMode(mode proc.FollowForkMode) error {
+	if mode != proc.FollowParent {
+		return proc.FollowForkUnsupportedErr
+	}
+	return nil
+}
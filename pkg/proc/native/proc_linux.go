@@ -38,6 +38,18 @@ const (
 // process details.
 type OSProcessDetails struct {
 	comm string
+
+	// catchSyscalls is set when threads are resumed with PTRACE_SYSCALL,
+	// if syscallFilter is not nil only the system calls it contains stop
+	// the target.
+	catchSyscalls bool
+	syscallFilter map[uint64]bool
+
+	// signalHandling contains the signals that are not simply passed to
+	// the target, signals lists the signals received and reported without
+	// stopping the target.
+	signalHandling map[syscall.Signal]proc.SignalHandling
+	signals        []proc.Signal
 }
 
 // Launch creates and begins debugging a new process. First entry in
@@ -106,7 +118,12 @@ func (dbp *Process) Kill() (err error) {
 	if !dbp.threads[dbp.pid].Stopped() {
 		return errors.New("process must be stopped in order to kill it")
 	}
-	if err = sys.Kill(-dbp.pid, sys.SIGKILL); err != nil {
+	// a followed child shares the process group of its parent
+	killpid := -dbp.pid
+	if dbp.parentPid != 0 {
+		killpid = dbp.pid
+	}
+	if err = sys.Kill(killpid, sys.SIGKILL); err != nil {
 		return errors.New("could not deliver signal " + err.Error())
 	}
 	if _, _, err = dbp.wait(dbp.pid, 0); err != nil {
@@ -117,7 +134,7 @@ func (dbp *Process) Kill() (err error) {
 }
 
 func (dbp *Process) requestManualStop() (err error) {
-	return sys.Kill(dbp.pid, sys.SIGTRAP)
+	return sys.Kill(dbp.group.getCurrent().pid, sys.SIGTRAP)
 }
 
 // Attach to a newly created thread, and store that thread in our list of
@@ -146,12 +163,13 @@ func (dbp *Process) addThread(tid int, attach bool) (*Thread, error) {
 		}
 	}
 
-	dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
+	opts := dbp.ptraceOptions()
+	dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, opts) })
 	if err == syscall.ESRCH {
 		if _, _, err = dbp.waitFast(tid); err != nil {
 			return nil, fmt.Errorf("error while waiting after adding thread: %d %s", tid, err)
 		}
-		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
+		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, opts) })
 		if err == syscall.ESRCH {
 			return nil, err
 		}
@@ -165,6 +183,14 @@ func (dbp *Process) addThread(tid int, attach bool) (*Thread, error) {
 		dbp: dbp,
 		os:  new(OSSpecificDetails),
 	}
+	// debug registers are not inherited by new threads
+	for _, bp := range dbp.breakpoints {
+		if bp.WatchType != 0 {
+			if err := dbp.threads[tid].writeHardwareBreakpoint(bp.Addr, bp.WatchType, bp.HWBreakIndex); err != nil {
+				return nil, err
+			}
+		}
+	}
 	if dbp.currentThread == nil {
 		dbp.SwitchThread(tid)
 	}
@@ -194,6 +220,7 @@ func findExecutable(path string, pid int) string {
 }
 
 func (dbp *Process) trapWait(pid int) (*Thread, error) {
+	g := dbp.group
 	for {
 		wpid, status, err := dbp.wait(pid, 0)
 		if err != nil {
@@ -202,16 +229,22 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 		if wpid == 0 {
 			continue
 		}
-		th, ok := dbp.threads[wpid]
-		if ok {
+		th := g.findThread(wpid)
+		if th != nil {
 			th.Status = (*WaitStatus)(status)
 		}
 		if status.Exited() {
-			if wpid == dbp.pid {
-				dbp.postExit()
-				return nil, proc.ProcessExitedError{Pid: wpid, Status: status.ExitStatus()}
+			if p := g.find(wpid); p != nil {
+				p.postExit()
+				if len(g.procs) == 0 {
+					return nil, proc.ProcessExitedError{Pid: wpid, Status: status.ExitStatus()}
+				}
+				// other inferiors are still running, keep going
+				continue
+			}
+			if th != nil {
+				delete(th.dbp.threads, wpid)
 			}
-			delete(dbp.threads, wpid)
 			continue
 		}
 		if status.StopSignal() == sys.SIGTRAP && status.TrapCause() == sys.PTRACE_EVENT_CLONE {
@@ -226,7 +259,11 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 				}
 				return nil, fmt.Errorf("could not get event message: %s", err)
 			}
-			th, err = dbp.addThread(int(cloned), false)
+			p := dbp
+			if th != nil {
+				p = th.dbp
+			}
+			th, err = p.addThread(int(cloned), false)
 			if err != nil {
 				if err == sys.ESRCH {
 					// thread died while we were adding it
@@ -237,12 +274,12 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 			if err = th.Continue(); err != nil {
 				if err == sys.ESRCH {
 					// thread died while we were adding it
-					delete(dbp.threads, th.ID)
+					delete(p.threads, th.ID)
 					continue
 				}
 				return nil, fmt.Errorf("could not continue new thread %d %s", cloned, err)
 			}
-			if err = dbp.threads[int(wpid)].Continue(); err != nil {
+			if err = p.threads[int(wpid)].Continue(); err != nil {
 				if err != sys.ESRCH {
 					return nil, fmt.Errorf("could not continue existing thread %d %s", wpid, err)
 				}
@@ -250,9 +287,66 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 			continue
 		}
 		if th == nil {
+			if status.Stopped() && status.StopSignal() == sys.SIGSTOP && g.followForkMode != proc.FollowParent {
+				// probably a new child stopping before we are told about it
+				if g.pendingStops == nil {
+					g.pendingStops = make(map[int]bool)
+				}
+				g.pendingStops[wpid] = true
+			}
 			// Sometimes we get an unknown thread, ignore it?
 			continue
 		}
+		if status.StopSignal() == sys.SIGTRAP {
+			switch status.TrapCause() {
+			case sys.PTRACE_EVENT_FORK, sys.PTRACE_EVENT_VFORK:
+				var childpid uint
+				dbp.execPtraceFunc(func() { childpid, err = sys.PtraceGetEventMsg(wpid) })
+				if err != nil {
+					return nil, fmt.Errorf("could not get event message: %s", err)
+				}
+				p := th.dbp
+				if err := p.forked(th, int(childpid), status.TrapCause() == sys.PTRACE_EVENT_VFORK); err != nil {
+					return nil, err
+				}
+				if p.pid == int(childpid) {
+					// followed the child, the parent was detached
+					continue
+				}
+				if err := th.resume(); err != nil && err != sys.ESRCH {
+					return nil, err
+				}
+				continue
+			case sys.PTRACE_EVENT_EXEC:
+				p := th.dbp
+				if err := p.execed(th); err != nil {
+					if p == dbp {
+						return nil, err
+					}
+					continue
+				}
+				return th, nil
+			}
+		}
+		if status.StopSignal() == sys.SIGTRAP|0x80 {
+			// syscall-stop, PTRACE_O_TRACESYSGOOD sets bit 7 of the signal
+			sc, err := th.syscallStop()
+			if err != nil {
+				if err == sys.ESRCH {
+					continue
+				}
+				return nil, err
+			}
+			if sc == nil {
+				if err := th.resume(); err != nil && err != sys.ESRCH {
+					return nil, err
+				}
+				continue
+			}
+			th.os.syscall = sc
+			th.running = false
+			return th, nil
+		}
 		dbp.haltMu.Lock()
 		halt := dbp.halt
 		dbp.haltMu.Unlock()
@@ -266,13 +360,16 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 			return th, nil
 		}
 		if th != nil {
-			// TODO(dp) alert user about unexpected signals here.
-			if err := th.resumeWithSig(int(status.StopSignal())); err != nil {
+			stop, err := th.dbp.handleSignal(th, status.StopSignal())
+			if err != nil {
 				if err == sys.ESRCH {
-					return nil, proc.ProcessExitedError{Pid: dbp.pid}
+					return nil, proc.ProcessExitedError{Pid: th.dbp.pid}
 				}
 				return nil, err
 			}
+			if stop {
+				return th, nil
+			}
 		}
 	}
 }
@@ -395,8 +492,17 @@ func (dbp *Process) resume() error {
 	// all threads stopped over a breakpoint are made to step over it
 	for _, thread := range dbp.threads {
 		if thread.CurrentBreakpoint != nil {
-			if err := thread.StepInstruction(); err != nil {
-				return err
+			// watchpoints trigger after the instruction accessing memory
+			// has been executed, there is nothing to step over
+			if thread.CurrentBreakpoint.WatchType == 0 {
+				if err := thread.StepInstruction(); err != nil {
+					return err
+				}
+				// discard watchpoints triggered while stepping over the
+				// breakpoint, they would be reported at the wrong location
+				if _, err := thread.findHardwareBreakpoint(); err != nil {
+					return err
+				}
 			}
 			thread.CurrentBreakpoint = nil
 		}
@@ -487,3 +487,22 @@ func killProcess(pid int) error {
 
 	return p.Kill()
 }
+
+func (dbp *Process) catchSyscalls(names []string, enabled bool) error {
+	return proc.SyscallCatchpointsUnsupportedErr
+}
+
+func (dbp *Process) setSignalHandling(sig string, h proc.SignalHandling) error {
+	return proc.SignalHandlingUnsupportedErr
+}
+
+func (dbp *Process) receivedSignals() []proc.Signal {
+	return nil
+}
+
+func (dbp *Process) setFollowForkMode(mode proc.FollowForkMode) error {
+	if mode != proc.FollowParent {
+		return proc.FollowForkUnsupportedErr
+	}
+	return nil
+}
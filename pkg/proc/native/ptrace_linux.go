@@ -28,9 +28,14 @@ func PtraceCont(tid, sig int) error {
 	return sys.PtraceCont(tid, sig)
 }
 
-// PtraceSingleStep executes ptrace PTRACE_SINGLE_STEP.
-func PtraceSingleStep(tid int) error {
-	return sys.PtraceSingleStep(tid)
+// PtraceSingleStep executes ptrace PTRACE_SINGLE_STEP, delivering sig to
+// the thread.
+func PtraceSingleStep(tid, sig int) error {
+	_, _, err := sys.Syscall6(sys.SYS_PTRACE, sys.PTRACE_SINGLESTEP, uintptr(tid), 1, uintptr(sig), 0, 0)
+	if err != syscall.Errno(0) {
+		return err
+	}
+	return nil
 }
 
 // PtracePokeUser execute ptrace PTRACE_POKE_USER.
@@ -72,6 +77,38 @@ func PtraceGetRegset(tid int) (regset proc.LinuxX86Xstate, err error) {
 		err = nil
 	}
 
-	err = proc.LinuxX86XstateRead(xstateargs[:iov.Len], false, &regset)
+	regset.Xsave = xstateargs[:iov.Len]
+	err = proc.LinuxX86XstateRead(regset.Xsave, false, &regset)
 	return regset, err
 }
+
+// PtraceSetRegset writes the XSAVE area xsave, as returned by
+// PtraceGetRegset, to the specified thread.
+func PtraceSetRegset(tid int, xsave []byte) error {
+	iov := sys.Iovec{Base: &xsave[0], Len: uint64(len(xsave))}
+	_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETREGSET, uintptr(tid), _NT_X86_XSTATE, uintptr(unsafe.Pointer(&iov)), 0, 0)
+	if err != syscall.Errno(0) {
+		return err
+	}
+	return nil
+}
+
+const (
+	_PTRACE_GET_SYSCALL_INFO = 0x420e
+
+	_PTRACE_SYSCALL_INFO_ENTRY = 1
+	_PTRACE_SYSCALL_INFO_EXIT  = 2
+)
+
+// PtraceGetSyscallInfoOp calls ptrace(PTRACE_GET_SYSCALL_INFO) and returns
+// the kind of stop the thread is in, one of the _PTRACE_SYSCALL_INFO_*
+// constants. Available since Linux 5.3.
+func PtraceGetSyscallInfoOp(tid int) (uint8, error) {
+	// struct ptrace_syscall_info, op is its first byte
+	var info [88]byte
+	_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, _PTRACE_GET_SYSCALL_INFO, uintptr(tid), uintptr(len(info)), uintptr(unsafe.Pointer(&info[0])), 0, 0)
+	if err != syscall.Errno(0) {
+		return 0, err
+	}
+	return info[0], nil
+}
@@ -9,8 +9,9 @@ import (
 
 // Regs is a wrapper for sys.PtraceRegs.
 type Regs struct {
-	regs   *sys.PtraceRegs
-	fpregs []proc.Register
+	regs     *sys.PtraceRegs
+	fpregs   []proc.Register
+	fpregset []byte
 }
 
 func (r *Regs) Slice() []proc.Register {
@@ -261,9 +262,9 @@ func registers(thread *Thread, floatingPoint bool) (proc.Registers, error) {
 	if err != nil {
 		return nil, err
 	}
-	r := &Regs{&regs, nil}
+	r := &Regs{&regs, nil, nil}
 	if floatingPoint {
-		r.fpregs, err = thread.fpRegisters()
+		r.fpregs, r.fpregset, err = thread.fpRegisters()
 		if err != nil {
 			return nil, err
 		}
@@ -281,9 +282,10 @@ const (
 	_XSAVE_SSE_REGION_LEN        = 416
 )
 
-func (thread *Thread) fpRegisters() (regs []proc.Register, err error) {
+func (thread *Thread) fpRegisters() (regs []proc.Register, fpregset []byte, err error) {
 	var fpregs proc.LinuxX86Xstate
 	thread.dbp.execPtraceFunc(func() { fpregs, err = PtraceGetRegset(thread.ID) })
 	regs = fpregs.Decode()
+	fpregset = fpregs.Xsave
 	return
 }
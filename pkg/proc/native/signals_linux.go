@@ -0,0 +1,126 @@
+package native
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+var signalNames = map[syscall.Signal]string{
+	sys.SIGHUP:    "SIGHUP",
+	sys.SIGINT:    "SIGINT",
+	sys.SIGQUIT:   "SIGQUIT",
+	sys.SIGILL:    "SIGILL",
+	sys.SIGTRAP:   "SIGTRAP",
+	sys.SIGABRT:   "SIGABRT",
+	sys.SIGBUS:    "SIGBUS",
+	sys.SIGFPE:    "SIGFPE",
+	sys.SIGKILL:   "SIGKILL",
+	sys.SIGUSR1:   "SIGUSR1",
+	sys.SIGSEGV:   "SIGSEGV",
+	sys.SIGUSR2:   "SIGUSR2",
+	sys.SIGPIPE:   "SIGPIPE",
+	sys.SIGALRM:   "SIGALRM",
+	sys.SIGTERM:   "SIGTERM",
+	sys.SIGSTKFLT: "SIGSTKFLT",
+	sys.SIGCHLD:   "SIGCHLD",
+	sys.SIGCONT:   "SIGCONT",
+	sys.SIGSTOP:   "SIGSTOP",
+	sys.SIGTSTP:   "SIGTSTP",
+	sys.SIGTTIN:   "SIGTTIN",
+	sys.SIGTTOU:   "SIGTTOU",
+	sys.SIGURG:    "SIGURG",
+	sys.SIGXCPU:   "SIGXCPU",
+	sys.SIGXFSZ:   "SIGXFSZ",
+	sys.SIGVTALRM: "SIGVTALRM",
+	sys.SIGPROF:   "SIGPROF",
+	sys.SIGWINCH:  "SIGWINCH",
+	sys.SIGIO:     "SIGIO",
+	sys.SIGPWR:    "SIGPWR",
+	sys.SIGSYS:    "SIGSYS",
+}
+
+func signalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("SIG%d", int(sig))
+}
+
+// parseSignal converts a signal name, with or without the SIG prefix, or a
+// signal number to a signal.
+func parseSignal(s string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 || n > 64 {
+			return 0, fmt.Errorf("invalid signal number %d", n)
+		}
+		return syscall.Signal(n), nil
+	}
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for sig, signame := range signalNames {
+		if signame == name {
+			return sig, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal %q", s)
+}
+
+func (dbp *Process) setSignalHandling(s string, h proc.SignalHandling) error {
+	sig, err := parseSignal(s)
+	if err != nil {
+		return err
+	}
+	switch sig {
+	case sys.SIGTRAP, sys.SIGSTOP, sys.SIGKILL:
+		return fmt.Errorf("%s is used by the debugger, its handling can not be changed", signalName(sig))
+	}
+	if dbp.os.signalHandling == nil {
+		dbp.os.signalHandling = make(map[syscall.Signal]proc.SignalHandling)
+	}
+	dbp.os.signalHandling[sig] = h
+	return nil
+}
+
+// handling returns how sig should be handled.
+func (dbp *Process) handling(sig syscall.Signal) proc.SignalHandling {
+	if h, ok := dbp.os.signalHandling[sig]; ok {
+		return h
+	}
+	return proc.SignalHandling{Pass: true}
+}
+
+func (dbp *Process) receivedSignals() []proc.Signal {
+	r := dbp.os.signals
+	dbp.os.signals = nil
+	return r
+}
+
+// handleSignal decides what to do with a signal received by th, it returns
+// true if the target should stop.
+func (dbp *Process) handleSignal(th *Thread, sig syscall.Signal) (stop bool, err error) {
+	h := dbp.handling(sig)
+	signal := proc.Signal{ThreadID: th.ID, Num: int(sig), Name: signalName(sig)}
+	if h.Stop {
+		th.os.signal = &signal
+		if h.Pass {
+			th.os.pendingSignal = int(sig)
+		}
+		th.running = false
+		return true, nil
+	}
+	if h.Print {
+		dbp.os.signals = append(dbp.os.signals, signal)
+	}
+	if !h.Pass {
+		sig = 0
+	}
+	return false, th.resumeWithSig(int(sig))
+}
@@ -0,0 +1,262 @@
+//go:generate go run ../../../scripts/gen-syscall-names.go
+
+package native
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+// syscallArg describes how the argument of a system call is decoded.
+type syscallArg uint8
+
+const (
+	argHex    syscallArg = iota // unknown argument, printed in hexadecimal
+	argInt                      // signed integer
+	argFd                       // file descriptor, possibly AT_FDCWD
+	argString                   // pointer to a NUL terminated string
+	argBuf                      // pointer to a buffer, its length is the next argument
+)
+
+const (
+	maxSyscallStringLen = 256
+	maxSyscallBufLen    = 64
+)
+
+// syscallArgs contains the arguments of the most common system calls,
+// arguments of system calls not listed here are printed in hexadecimal.
+var syscallArgs = map[string][]syscallArg{
+	"read":              {argFd, argHex, argInt},
+	"write":             {argFd, argBuf, argInt},
+	"open":              {argString, argHex, argHex},
+	"close":             {argFd},
+	"stat":              {argString, argHex},
+	"fstat":             {argFd, argHex},
+	"lstat":             {argString, argHex},
+	"lseek":             {argFd, argInt, argInt},
+	"mmap":              {argHex, argInt, argHex, argHex, argFd, argInt},
+	"munmap":            {argHex, argInt},
+	"mprotect":          {argHex, argInt, argHex},
+	"pread64":           {argFd, argHex, argInt, argInt},
+	"pwrite64":          {argFd, argBuf, argInt, argInt},
+	"access":            {argString, argHex},
+	"pipe2":             {argHex, argHex},
+	"dup":               {argFd},
+	"dup2":              {argFd, argFd},
+	"dup3":              {argFd, argFd, argHex},
+	"nanosleep":         {argHex, argHex},
+	"getpid":            {},
+	"gettid":            {},
+	"socket":            {argInt, argInt, argInt},
+	"connect":           {argFd, argHex, argInt},
+	"accept":            {argFd, argHex, argHex},
+	"accept4":           {argFd, argHex, argHex, argHex},
+	"sendto":            {argFd, argBuf, argInt, argHex, argHex, argInt},
+	"recvfrom":          {argFd, argHex, argInt, argHex, argHex, argHex},
+	"shutdown":          {argFd, argInt},
+	"bind":              {argFd, argHex, argInt},
+	"listen":            {argFd, argInt},
+	"execve":            {argString, argHex, argHex},
+	"exit":              {argInt},
+	"exit_group":        {argInt},
+	"kill":              {argInt, argInt},
+	"tkill":             {argInt, argInt},
+	"tgkill":            {argInt, argInt, argInt},
+	"fcntl":             {argFd, argInt, argHex},
+	"ioctl":             {argFd, argHex, argHex},
+	"fsync":             {argFd},
+	"ftruncate":         {argFd, argInt},
+	"chdir":             {argString},
+	"fchdir":            {argFd},
+	"rename":            {argString, argString},
+	"mkdir":             {argString, argHex},
+	"rmdir":             {argString},
+	"unlink":            {argString},
+	"readlink":          {argString, argHex, argInt},
+	"chmod":             {argString, argHex},
+	"openat":            {argFd, argString, argHex, argHex},
+	"mkdirat":           {argFd, argString, argHex},
+	"newfstatat":        {argFd, argString, argHex, argHex},
+	"unlinkat":          {argFd, argString, argHex},
+	"renameat":          {argFd, argString, argFd, argString},
+	"readlinkat":        {argFd, argString, argHex, argInt},
+	"faccessat":         {argFd, argString, argHex},
+	"fchmodat":          {argFd, argString, argHex},
+	"epoll_create1":     {argHex},
+	"epoll_ctl":         {argFd, argInt, argFd, argHex},
+	"epoll_wait":        {argFd, argHex, argInt, argInt},
+	"epoll_pwait":       {argFd, argHex, argInt, argInt, argHex},
+	"rt_sigaction":      {argInt, argHex, argHex, argInt},
+	"rt_sigprocmask":    {argInt, argHex, argHex, argInt},
+	"sigaltstack":       {argHex, argHex},
+	"sched_yield":       {},
+	"sched_getaffinity": {argInt, argInt, argHex},
+}
+
+// syscallNumber returns the number of the system call called name.
+func syscallNumber(name string) (uint64, bool) {
+	for i := range syscallNames {
+		if syscallNames[i] == name {
+			return uint64(i), true
+		}
+	}
+	return 0, false
+}
+
+// catchSyscalls makes threads stop on entry and exit of the system calls
+// listed in names, or of every system call if names is empty.
+func (dbp *Process) catchSyscalls(names []string, enabled bool) error {
+	if !enabled {
+		dbp.os.catchSyscalls = false
+		dbp.os.syscallFilter = nil
+		return nil
+	}
+	var filter map[uint64]bool
+	if len(names) > 0 {
+		filter = make(map[uint64]bool)
+		var unknown []string
+		for _, name := range names {
+			num, ok := syscallNumber(name)
+			if !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+			filter[num] = true
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("unknown system call %s", strings.Join(unknown, ", "))
+		}
+	}
+	dbp.os.catchSyscalls = true
+	dbp.os.syscallFilter = filter
+	return nil
+}
+
+// syscallStop decodes the system call the thread is stopped at after a
+// syscall-stop. Returns nil if the system call is not one of the system
+// calls we are catching.
+func (t *Thread) syscallStop() (*proc.Syscall, error) {
+	var regs sys.PtraceRegs
+	var err error
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(t.ID, &regs) })
+	if err != nil {
+		return nil, err
+	}
+	t.os.inSyscall = t.syscallEnterStop()
+	num := regs.Orig_rax
+	if t.dbp.os.syscallFilter != nil && !t.dbp.os.syscallFilter[num] {
+		return nil, nil
+	}
+
+	sc := &proc.Syscall{Num: num}
+	if num < uint64(len(syscallNames)) {
+		sc.Name = syscallNames[num]
+	}
+	if sc.Name == "" {
+		sc.Name = fmt.Sprintf("syscall_%d", num)
+	}
+
+	// On exit rax contains the return value, the arguments are preserved
+	// across the system call.
+	if !t.os.inSyscall {
+		ret := int64(regs.Rax)
+		sc.Exit = true
+		sc.Ret = ret
+		if ret < 0 && ret > -4096 {
+			sc.Err = syscall.Errno(-ret)
+		}
+	}
+
+	rawArgs := []uint64{regs.Rdi, regs.Rsi, regs.Rdx, regs.R10, regs.R8, regs.R9}
+	kinds, ok := syscallArgs[sc.Name]
+	if !ok {
+		kinds = make([]syscallArg, len(rawArgs))
+	}
+	sc.Args = make([]string, len(kinds))
+	for i, kind := range kinds {
+		var next uint64
+		if i+1 < len(rawArgs) {
+			next = rawArgs[i+1]
+		}
+		sc.Args[i] = t.formatSyscallArg(kind, rawArgs[i], next)
+	}
+	return sc, nil
+}
+
+// syscallEnterStop returns true if the syscall-stop the thread is in is a
+// syscall-enter-stop. Before Linux 5.3 the kernel does not tell
+// syscall-enter-stops and syscall-exit-stops apart, they alternate for as
+// long as the thread is resumed with PTRACE_SYSCALL. The rax == -ENOSYS
+// test used by some tracers is wrong for system calls that fail with
+// ENOSYS.
+func (t *Thread) syscallEnterStop() bool {
+	var op uint8
+	var err error
+	t.dbp.execPtraceFunc(func() { op, err = PtraceGetSyscallInfoOp(t.ID) })
+	if err == nil {
+		switch op {
+		case _PTRACE_SYSCALL_INFO_ENTRY:
+			return true
+		case _PTRACE_SYSCALL_INFO_EXIT:
+			return false
+		}
+	}
+	return !t.os.inSyscall
+}
+
+func (t *Thread) formatSyscallArg(kind syscallArg, arg, next uint64) string {
+	switch kind {
+	case argInt:
+		return strconv.FormatInt(int64(arg), 10)
+	case argFd:
+		fd := int32(arg)
+		if fd == sys.AT_FDCWD {
+			return "AT_FDCWD"
+		}
+		return strconv.Itoa(int(fd))
+	case argString:
+		if arg == 0 {
+			return "NULL"
+		}
+		buf := t.peekSyscallMemory(arg, maxSyscallStringLen)
+		if i := bytes.IndexByte(buf, 0); i >= 0 {
+			return strconv.Quote(string(buf[:i]))
+		}
+		return strconv.Quote(string(buf)) + "..."
+	case argBuf:
+		if arg == 0 {
+			return "NULL"
+		}
+		sz := next
+		if sz > maxSyscallBufLen {
+			sz = maxSyscallBufLen
+		}
+		buf := t.peekSyscallMemory(arg, int(sz))
+		if len(buf) == 0 && sz != 0 {
+			return fmt.Sprintf("%#x", arg)
+		}
+		s := strconv.Quote(string(buf))
+		if uint64(len(buf)) < next {
+			s += "..."
+		}
+		return s
+	default:
+		return fmt.Sprintf("%#x", arg)
+	}
+}
+
+// peekSyscallMemory reads up to sz bytes at addr, stopping at the first
+// unreadable word.
+func (t *Thread) peekSyscallMemory(addr uint64, sz int) []byte {
+	buf := make([]byte, sz)
+	var n int
+	t.dbp.execPtraceFunc(func() { n, _ = sys.PtracePeekData(t.ID, uintptr(addr), buf) })
+	return buf[:n]
+}
@@ -6,6 +6,10 @@ import (
 	"github.com/derekparker/delve/pkg/proc"
 )
 
+// maxHardwareBreakpoints is the number of debug registers that can hold
+// the address of a watchpoint.
+const maxHardwareBreakpoints = 4
+
 // Thread represents a single thread in the traced process
 // ID represents the thread id or port, Process holds a reference to the
 // Process struct that contains info on the process as
@@ -18,12 +22,30 @@ type Thread struct {
 	BreakpointConditionMet   bool             // Output of evaluating the breakpoint's condition
 	BreakpointConditionError error            // Error evaluating the breakpoint's condition
 
+	proc.CommonThread
+
 	dbp            *Process
 	singleStepping bool
 	running        bool
 	os             *OSSpecificDetails
 }
 
+// Common returns information common across Process
+// implementations.
+func (thread *Thread) Common() *proc.CommonThread {
+	return &thread.CommonThread
+}
+
+// SetReg changes the value of the register regNum.
+func (thread *Thread) SetReg(regNum int, value uint64) error {
+	return thread.setReg(regNum, value)
+}
+
+// RestoreRegisters restores the registers of the thread to savedRegs.
+func (thread *Thread) RestoreRegisters(savedRegs proc.Registers) error {
+	return thread.setRegisters(savedRegs)
+}
+
 // Continue the execution of this thread.
 //
 // If we are currently at a breakpoint, we'll clear it
@@ -142,23 +164,26 @@ func (thread *Thread) Halt() (err error) {
 // thread is stopped at as CurrentBreakpoint on the thread struct.
 func (thread *Thread) SetCurrentBreakpoint() error {
 	thread.CurrentBreakpoint = nil
-	pc, err := thread.PC()
+	bp, err := thread.findHardwareBreakpoint()
 	if err != nil {
 		return err
 	}
-	if bp, ok := thread.dbp.FindBreakpoint(pc); ok {
-		thread.CurrentBreakpoint = bp
-		if err = thread.SetPC(bp.Addr); err != nil {
+	if bp == nil {
+		pc, err := thread.PC()
+		if err != nil {
 			return err
 		}
-		thread.BreakpointConditionMet, thread.BreakpointConditionError = bp.CheckCondition(thread)
-		if thread.CurrentBreakpoint != nil && thread.BreakpointConditionMet {
-			if g, err := proc.GetG(thread); err == nil {
-				thread.CurrentBreakpoint.HitCount[g.ID]++
+		var ok bool
+		if bp, ok = thread.dbp.FindBreakpoint(pc); ok {
+			if err = thread.SetPC(bp.Addr); err != nil {
+				return err
 			}
-			thread.CurrentBreakpoint.TotalHitCount++
 		}
 	}
+	if bp != nil {
+		thread.CurrentBreakpoint = bp
+		thread.BreakpointConditionMet, thread.BreakpointConditionError = bp.CheckCondition(thread)
+	}
 	return nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"unsafe"
 
+	"golang.org/x/arch/x86/x86asm"
 	sys "golang.org/x/sys/unix"
 
 	"github.com/derekparker/delve/pkg/proc"
@@ -143,3 +144,81 @@ func (t *Thread) ReadMemory(buf []byte, addr uintptr) (int, error) {
 	}
 	return len(buf), nil
 }
+
+func (t *Thread) writeHardwareBreakpoint(addr uint64, wtype proc.WatchType, idx uint8) error {
+	return proc.WatchpointsUnsupportedErr
+}
+
+func (t *Thread) clearHardwareBreakpoint(idx uint8) error {
+	return proc.WatchpointsUnsupportedErr
+}
+
+func (t *Thread) findHardwareBreakpoint() (*proc.Breakpoint, error) {
+	return nil, nil
+}
+
+func (t *Thread) Syscall() *proc.Syscall {
+	return nil
+}
+
+func (t *Thread) Signal() *proc.Signal {
+	return nil
+}
+
+func (t *Thread) setReg(regNum int, value uint64) error {
+	var state C.x86_thread_state64_t
+	kret := C.get_registers(C.mach_port_name_t(t.os.threadAct), &state)
+	if kret != C.KERN_SUCCESS {
+		return fmt.Errorf("could not get registers")
+	}
+
+	v := C.__uint64_t(value)
+	switch x86asm.Reg(regNum) {
+	case x86asm.RAX:
+		state.__rax = v
+	case x86asm.RBX:
+		state.__rbx = v
+	case x86asm.RCX:
+		state.__rcx = v
+	case x86asm.RDX:
+		state.__rdx = v
+	case x86asm.RSI:
+		state.__rsi = v
+	case x86asm.RDI:
+		state.__rdi = v
+	case x86asm.RBP:
+		state.__rbp = v
+	case x86asm.RSP:
+		state.__rsp = v
+	case x86asm.R8:
+		state.__r8 = v
+	case x86asm.R9:
+		state.__r9 = v
+	case x86asm.R10:
+		state.__r10 = v
+	case x86asm.R11:
+		state.__r11 = v
+	case x86asm.R12:
+		state.__r12 = v
+	case x86asm.R13:
+		state.__r13 = v
+	case x86asm.R14:
+		state.__r14 = v
+	case x86asm.R15:
+		state.__r15 = v
+	case x86asm.RIP:
+		state.__rip = v
+	default:
+		return proc.UnknownRegisterError
+	}
+
+	kret = C.set_registers(C.mach_port_name_t(t.os.threadAct), &state)
+	if kret != C.KERN_SUCCESS {
+		return fmt.Errorf("could not set registers")
+	}
+	return nil
+}
+
+func (t *Thread) setRegisters(savedRegs proc.Registers) error {
+	return proc.ChangeRegisterUnsupportedErr
+}
@@ -3,6 +3,7 @@ package native
 import (
 	"fmt"
 
+	"golang.org/x/arch/x86/x86asm"
 	sys "golang.org/x/sys/unix"
 
 	"github.com/derekparker/delve/pkg/proc"
@@ -14,6 +15,11 @@ type WaitStatus sys.WaitStatus
 // process details.
 type OSSpecificDetails struct {
 	registers sys.PtraceRegs
+	syscall   *proc.Syscall // system call the thread is stopped at
+	signal    *proc.Signal  // signal that stopped the thread
+	inSyscall bool          // the last syscall-stop was a syscall-enter-stop
+	// pendingSignal is delivered to the thread when it is resumed
+	pendingSignal int
 }
 
 func (t *Thread) halt() (err error) {
@@ -22,12 +28,26 @@ func (t *Thread) halt() (err error) {
 		err = fmt.Errorf("halt err %s on thread %d", err, t.ID)
 		return
 	}
-	_, _, err = t.dbp.waitFast(t.ID)
-	if err != nil {
-		err = fmt.Errorf("wait err %s on thread %d", err, t.ID)
-		return
+	for {
+		var status *sys.WaitStatus
+		_, status, err = t.dbp.waitFast(t.ID)
+		if err != nil {
+			err = fmt.Errorf("wait err %s on thread %d", err, t.ID)
+			return
+		}
+		if status == nil || !status.Stopped() || status.StopSignal() != sys.SIGTRAP|0x80 {
+			return
+		}
+		// The thread reached a syscall-stop before the SIGSTOP, which is
+		// still pending: keep track of the stop and let the thread run
+		// until it receives the signal.
+		t.os.inSyscall = t.syscallEnterStop()
+		t.dbp.execPtraceFunc(func() { err = sys.PtraceSyscall(t.ID, 0) })
+		if err != nil {
+			err = fmt.Errorf("halt err %s on thread %d", err, t.ID)
+			return
+		}
 	}
-	return
 }
 
 func (t *Thread) stopped() bool {
@@ -36,18 +56,47 @@ func (t *Thread) stopped() bool {
 }
 
 func (t *Thread) resume() error {
-	return t.resumeWithSig(0)
+	sig := t.os.pendingSignal
+	t.os.pendingSignal = 0
+	return t.resumeWithSig(sig)
 }
 
 func (t *Thread) resumeWithSig(sig int) (err error) {
 	t.running = true
+	t.os.syscall = nil
+	t.os.signal = nil
+	if t.dbp.os.catchSyscalls {
+		t.dbp.execPtraceFunc(func() { err = sys.PtraceSyscall(t.ID, sig) })
+		return
+	}
+	// without PTRACE_SYSCALL there is no syscall-exit-stop for the system
+	// call the thread is in
+	t.os.inSyscall = false
 	t.dbp.execPtraceFunc(func() { err = PtraceCont(t.ID, sig) })
 	return
 }
 
+// Syscall returns the system call the thread is stopped at.
+func (t *Thread) Syscall() *proc.Syscall {
+	return t.os.syscall
+}
+
+// Signal returns the signal that stopped the thread.
+func (t *Thread) Signal() *proc.Signal {
+	return t.os.signal
+}
+
 func (t *Thread) singleStep() (err error) {
+	t.os.syscall = nil
+	t.os.signal = nil
+	t.os.inSyscall = false
+	// a pending signal is delivered by the first step, which stops at the
+	// first instruction of the signal handler
+	sig := t.os.pendingSignal
+	t.os.pendingSignal = 0
 	for {
-		t.dbp.execPtraceFunc(func() { err = sys.PtraceSingleStep(t.ID) })
+		t.dbp.execPtraceFunc(func() { err = PtraceSingleStep(t.ID, sig) })
+		sig = 0
 		if err != nil {
 			return err
 		}
@@ -88,7 +137,7 @@ func (t *Thread) saveRegisters() (proc.Registers, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not save register contents")
 	}
-	return &Regs{&t.os.registers, nil}, nil
+	return &Regs{&t.os.registers, nil, nil}, nil
 }
 
 func (t *Thread) restoreRegisters() (err error) {
@@ -96,6 +145,64 @@ func (t *Thread) restoreRegisters() (err error) {
 	return
 }
 
+func (t *Thread) setReg(regNum int, value uint64) (err error) {
+	var regs sys.PtraceRegs
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(t.ID, &regs) })
+	if err != nil {
+		return err
+	}
+	switch x86asm.Reg(regNum) {
+	case x86asm.RAX:
+		regs.Rax = value
+	case x86asm.RBX:
+		regs.Rbx = value
+	case x86asm.RCX:
+		regs.Rcx = value
+	case x86asm.RDX:
+		regs.Rdx = value
+	case x86asm.RSI:
+		regs.Rsi = value
+	case x86asm.RDI:
+		regs.Rdi = value
+	case x86asm.RBP:
+		regs.Rbp = value
+	case x86asm.RSP:
+		regs.Rsp = value
+	case x86asm.R8:
+		regs.R8 = value
+	case x86asm.R9:
+		regs.R9 = value
+	case x86asm.R10:
+		regs.R10 = value
+	case x86asm.R11:
+		regs.R11 = value
+	case x86asm.R12:
+		regs.R12 = value
+	case x86asm.R13:
+		regs.R13 = value
+	case x86asm.R14:
+		regs.R14 = value
+	case x86asm.R15:
+		regs.R15 = value
+	case x86asm.RIP:
+		regs.Rip = value
+	default:
+		return proc.UnknownRegisterError
+	}
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(t.ID, &regs) })
+	return err
+}
+
+func (t *Thread) setRegisters(savedRegs proc.Registers) (err error) {
+	sr := savedRegs.(*Regs)
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(t.ID, sr.regs) })
+	if err != nil || sr.fpregset == nil {
+		return err
+	}
+	t.dbp.execPtraceFunc(func() { err = PtraceSetRegset(t.ID, sr.fpregset) })
+	return err
+}
+
 func (t *Thread) WriteMemory(addr uintptr, data []byte) (written int, err error) {
 	if t.dbp.exited {
 		return 0, proc.ProcessExitedError{Pid: t.dbp.pid}
@@ -117,3 +224,94 @@ func (t *Thread) ReadMemory(data []byte, addr uintptr) (n int, err error) {
 	t.dbp.execPtraceFunc(func() { _, err = sys.PtracePeekData(t.ID, addr, data) })
 	return
 }
+
+// debugRegistersOffset is the offset of u_debugreg in struct user (see
+// sys/user.h) on linux/amd64.
+const debugRegistersOffset = 848
+
+func (t *Thread) peekDebugRegister(n int) (val uintptr, err error) {
+	t.dbp.execPtraceFunc(func() { val, err = PtracePeekUser(t.ID, uintptr(debugRegistersOffset+n*8)) })
+	return
+}
+
+func (t *Thread) pokeDebugRegister(n int, val uintptr) (err error) {
+	t.dbp.execPtraceFunc(func() { err = PtracePokeUser(t.ID, uintptr(debugRegistersOffset+n*8), val) })
+	return
+}
+
+// writeHardwareBreakpoint stores addr in debug register idx and enables
+// it in DR7 with the access type and size specified by wtype.
+func (t *Thread) writeHardwareBreakpoint(addr uint64, wtype proc.WatchType, idx uint8) error {
+	dr7, err := t.peekDebugRegister(7)
+	if err != nil {
+		return err
+	}
+	dr7 &^= dr7Mask(idx)
+	if err := t.pokeDebugRegister(7, dr7); err != nil {
+		return err
+	}
+	if err := t.pokeDebugRegister(int(idx), uintptr(addr)); err != nil {
+		return err
+	}
+
+	// x86 does not have read-only watchpoints, read watchpoints will
+	// also trigger on writes.
+	rwbits := uintptr(0x3)
+	if !wtype.Read() {
+		rwbits = 0x1
+	}
+	var lenbits uintptr
+	switch wtype.Size() {
+	case 1:
+		lenbits = 0x0
+	case 2:
+		lenbits = 0x1
+	case 4:
+		lenbits = 0x3
+	case 8:
+		lenbits = 0x2
+	default:
+		return fmt.Errorf("unsupported watchpoint size %d", wtype.Size())
+	}
+	dr7 |= 1<<(idx*2) | (rwbits|lenbits<<2)<<(16+idx*4)
+	return t.pokeDebugRegister(7, dr7)
+}
+
+// clearHardwareBreakpoint disables debug register idx.
+func (t *Thread) clearHardwareBreakpoint(idx uint8) error {
+	dr7, err := t.peekDebugRegister(7)
+	if err != nil {
+		return err
+	}
+	if err := t.pokeDebugRegister(7, dr7&^dr7Mask(idx)); err != nil {
+		return err
+	}
+	return t.pokeDebugRegister(int(idx), 0)
+}
+
+// findHardwareBreakpoint returns the watchpoint that stopped the thread,
+// if any, by reading the status register DR6. The status bits are reset
+// after being read.
+func (t *Thread) findHardwareBreakpoint() (*proc.Breakpoint, error) {
+	if !t.dbp.hasWatchpoints() {
+		return nil, nil
+	}
+	dr6, err := t.peekDebugRegister(6)
+	if err != nil || dr6&0xf == 0 {
+		return nil, err
+	}
+	if err := t.pokeDebugRegister(6, 0); err != nil {
+		return nil, err
+	}
+	for _, bp := range t.dbp.breakpoints {
+		if bp.WatchType != 0 && dr6&(1<<bp.HWBreakIndex) != 0 {
+			return bp, nil
+		}
+	}
+	return nil, nil
+}
+
+// dr7Mask returns the bits of DR7 that control debug register idx.
+func dr7Mask(idx uint8) uintptr {
+	return 0x3<<(idx*2) | 0xf<<(16+idx*4)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"syscall"
 
+	"golang.org/x/arch/x86/x86asm"
 	sys "golang.org/x/sys/windows"
 
 	"github.com/derekparker/delve/pkg/proc"
@@ -160,3 +161,78 @@ func (t *Thread) ReadMemory(buf []byte, addr uintptr) (int, error) {
 	}
 	return int(count), err
 }
+
+func (t *Thread) writeHardwareBreakpoint(addr uint64, wtype proc.WatchType, idx uint8) error {
+	return proc.WatchpointsUnsupportedErr
+}
+
+func (t *Thread) clearHardwareBreakpoint(idx uint8) error {
+	return proc.WatchpointsUnsupportedErr
+}
+
+func (t *Thread) findHardwareBreakpoint() (*proc.Breakpoint, error) {
+	return nil, nil
+}
+
+func (t *Thread) Syscall() *proc.Syscall {
+	return nil
+}
+
+func (t *Thread) Signal() *proc.Signal {
+	return nil
+}
+
+func (t *Thread) setReg(regNum int, value uint64) error {
+	context := newCONTEXT()
+	context.ContextFlags = _CONTEXT_ALL
+
+	err := _GetThreadContext(t.os.hThread, context)
+	if err != nil {
+		return err
+	}
+
+	switch x86asm.Reg(regNum) {
+	case x86asm.RAX:
+		context.Rax = value
+	case x86asm.RCX:
+		context.Rcx = value
+	case x86asm.RDX:
+		context.Rdx = value
+	case x86asm.RBX:
+		context.Rbx = value
+	case x86asm.RSP:
+		context.Rsp = value
+	case x86asm.RBP:
+		context.Rbp = value
+	case x86asm.RSI:
+		context.Rsi = value
+	case x86asm.RDI:
+		context.Rdi = value
+	case x86asm.R8:
+		context.R8 = value
+	case x86asm.R9:
+		context.R9 = value
+	case x86asm.R10:
+		context.R10 = value
+	case x86asm.R11:
+		context.R11 = value
+	case x86asm.R12:
+		context.R12 = value
+	case x86asm.R13:
+		context.R13 = value
+	case x86asm.R14:
+		context.R14 = value
+	case x86asm.R15:
+		context.R15 = value
+	case x86asm.RIP:
+		context.Rip = value
+	default:
+		return proc.UnknownRegisterError
+	}
+
+	return _SetThreadContext(t.os.hThread, context)
+}
+
+func (t *Thread) setRegisters(savedRegs proc.Registers) error {
+	return proc.ChangeRegisterUnsupportedErr
+}
@@ -19,6 +19,10 @@ type functionDebugInfo struct {
 var NotExecutableErr = errors.New("not an executable file")
 var NotRecordedErr = errors.New("not a recording")
 
+// SignalHandlingUnsupportedErr is returned by backends that do not let the
+// user change how signals are handled.
+var SignalHandlingUnsupportedErr = errors.New("signal handling can not be changed on this backend")
+
 const UnrecoveredPanic = "unrecovered-panic"
 
 // ProcessExitedError indicates that the process has exited and contains both
@@ -32,6 +36,21 @@ func (pe ProcessExitedError) Error() string {
 	return fmt.Sprintf("Process %d has exited with status %d", pe.Pid, pe.Status)
 }
 
+// CommonProcess contains fields used by this package, common to all
+// implementations of the Process interface.
+type CommonProcess struct {
+	fncallState   functionCallState
+	fncallEnabled bool
+	fncallAllowed bool
+	stepFilter    stepFilter
+}
+
+// NewCommonProcess returns a CommonProcess, fncallEnabled should be true
+// if the backend supports injecting function calls.
+func NewCommonProcess(fncallEnabled bool) CommonProcess {
+	return CommonProcess{fncallEnabled: fncallEnabled}
+}
+
 // FindFileLocation returns the PC for a given file:line.
 // Assumes that `file` is normailzed to lower case and '/' on Windows.
 func FindFileLocation(p Process, fileName string, lineno int) (uint64, error) {
@@ -45,6 +64,45 @@ func FindFileLocation(p Process, fileName string, lineno int) (uint64, error) {
 	return pc, nil
 }
 
+// FindFileLocations returns all the PCs for a given file:line, starting
+// with the one returned by FindFileLocation.
+// A single line can be compiled into several disjoint ranges of
+// instructions, for example the header of a for loop or a closure
+// defined on the same line as its caller.
+func FindFileLocations(p Process, fileName string, lineno int) ([]uint64, error) {
+	pc, err := FindFileLocation(p, fileName, lineno)
+	if err != nil {
+		return nil, err
+	}
+	pcs := []uint64{pc}
+	bi := p.BinInfo()
+	if bi.lineInfo.GetLineInfo(fileName) == nil {
+		return pcs, nil
+	}
+	for _, pc2 := range bi.lineInfo.AllPCsForFileLine(fileName, lineno) {
+		f, l, fn := bi.PCToLine(pc2)
+		if fn == nil || f != fileName || l != lineno {
+			continue
+		}
+		if _, fnline, _ := bi.PCToLine(fn.Entry); fnline == lineno {
+			// the declaration line of a function is also used for the stack
+			// growth check at the end of the function.
+			continue
+		}
+		found := false
+		for i := range pcs {
+			if pcs[i] == pc2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			pcs = append(pcs, pc2)
+		}
+	}
+	return pcs, nil
+}
+
 // FindFunctionLocation finds address of a function's line
 // If firstLine == true is passed FindFunctionLocation will attempt to find the first line of the function
 // If lineOffset is passed FindFunctionLocation will return the address of that line
@@ -75,7 +133,7 @@ func Next(dbp Process) (err error) {
 		return &ProcessExitedError{Pid: dbp.Pid()}
 	}
 	for _, bp := range dbp.Breakpoints() {
-		if bp.Internal() {
+		if bp.Internal() && bp.Kind != WatchOutOfScopeBreakpoint {
 			return fmt.Errorf("next while nexting")
 		}
 	}
@@ -96,6 +154,9 @@ func Continue(dbp Process) error {
 		return &ProcessExitedError{Pid: dbp.Pid()}
 	}
 	dbp.ManualStopRequested()
+	for _, thread := range dbp.ThreadList() {
+		thread.Common().returnValues = nil
+	}
 	for {
 		if dbp.ManualStopRequested() {
 			return nil
@@ -104,6 +165,8 @@ func Continue(dbp Process) error {
 		if err != nil {
 			return err
 		}
+		// the stop could have happened in a different inferior
+		dbp = dbp.CurrentInferior()
 
 		threads := dbp.ThreadList()
 
@@ -112,11 +175,30 @@ func Continue(dbp Process) error {
 		}
 
 		curthread := dbp.CurrentThread()
-		curbp, curbpActive, _ := curthread.Breakpoint()
+		curbp, curbpActive, bperr := curthread.Breakpoint()
+		if curbp != nil && bperr == funcCallNotAllowedErr && dbp.Common().fncallAllowed {
+			if err := evalBreakpointConditionCall(dbp, curthread, curbp); err != nil {
+				return err
+			}
+			curbp, curbpActive, _ = curthread.Breakpoint()
+		}
 
 		switch {
 		case curbp == nil:
-			// runtime.Breakpoint or manual stop
+			// runtime.Breakpoint, manual stop or a stop inside the protocol used
+			// to inject function calls
+			if fncall := &dbp.Common().fncallState; fncall.inProgress && onDebugCallFunction(curthread) {
+				fncall.step(dbp, curthread)
+				if !fncall.finished {
+					// only stop execution once the function call is finished
+					continue
+				}
+				fncall.inProgress = false
+				if fncall.err != nil {
+					return fncall.err
+				}
+				return conditionErrors(threads)
+			}
 			if recorded, _ := dbp.Recorded(); onRuntimeBreakpoint(curthread) && !recorded {
 				// Single-step current thread until we exit runtime.breakpoint and
 				// runtime.Breakpoint.
@@ -133,12 +215,34 @@ func Continue(dbp Process) error {
 				}
 			}
 			return conditionErrors(threads)
+		case curbpActive && (curbp.Kind == WatchOutOfScopeBreakpoint || len(curbp.WatchOutOfScope) > 0):
+			if err := clearWatchpointsOutOfScope(dbp, curbp); err != nil {
+				return err
+			}
+			onNextGoroutine, err := onNextGoroutine(curthread, dbp.Breakpoints())
+			if err != nil {
+				return err
+			}
+			if onNextGoroutine {
+				if err := dbp.ClearInternalBreakpoints(); err != nil {
+					return err
+				}
+			}
+			return conditionErrors(threads)
 		case curbpActive && curbp.Internal():
 			if curbp.Kind == StepBreakpoint {
 				// See description of proc.(*Process).next for the meaning of StepBreakpoints
 				if err := conditionErrors(threads); err != nil {
 					return err
 				}
+				if dbp.GetDirection() == Backward {
+					// We are at the return address of a function call, step backwards
+					// into the called function.
+					if err := dbp.ClearInternalBreakpoints(); err != nil {
+						return err
+					}
+					return dbp.StepInstruction()
+				}
 				regs, err := curthread.Registers(false)
 				if err != nil {
 					return err
@@ -155,6 +259,9 @@ func Continue(dbp Process) error {
 					return err
 				}
 			} else {
+				if curbp.returnInfo != nil {
+					curthread.Common().returnValues = curbp.returnInfo.collect(curthread)
+				}
 				if err := dbp.ClearInternalBreakpoints(); err != nil {
 					return err
 				}
@@ -223,11 +330,17 @@ func Step(dbp Process) (err error) {
 		return &ProcessExitedError{Pid: dbp.Pid()}
 	}
 	for _, bp := range dbp.Breakpoints() {
-		if bp.Internal() {
+		if bp.Internal() && bp.Kind != WatchOutOfScopeBreakpoint {
 			return fmt.Errorf("next while nexting")
 		}
 	}
 
+	if dbp.GetDirection() == Backward {
+		if stepped, err := stepBackIntoCall(dbp); err != nil || stepped {
+			return err
+		}
+	}
+
 	if err = next(dbp, true); err != nil {
 		switch err.(type) {
 		case ThreadBlockedError, NoReturnAddr: // Noop
@@ -240,6 +353,70 @@ func Step(dbp Process) (err error) {
 	return Continue(dbp)
 }
 
+// StepTo is like Step but only steps into the function selected by target,
+// calls to other functions on the current line are stepped over.
+// Target is either the name of the function or its index in the list
+// returned by StepTargets.
+func StepTo(dbp Process, target string) (err error) {
+	if dbp.Exited() {
+		return &ProcessExitedError{Pid: dbp.Pid()}
+	}
+	for _, bp := range dbp.Breakpoints() {
+		if bp.Internal() && bp.Kind != WatchOutOfScopeBreakpoint {
+			return fmt.Errorf("next while nexting")
+		}
+	}
+
+	if dbp.GetDirection() == Backward {
+		return errors.New("can not step into a specific call backwards")
+	}
+
+	targets, topframe, err := stepTargets(dbp)
+	if err != nil {
+		return err
+	}
+	tgt, err := findStepTarget(targets, target)
+	if err != nil {
+		return err
+	}
+	if skipStepInto(dbp, tgt.fn) {
+		// Continue would step over it, like Step does
+		return fmt.Errorf("can not step into %s, it is excluded by the step filter", tgt.fn.Name)
+	}
+
+	if err = next(dbp, false); err != nil {
+		dbp.ClearInternalBreakpoints()
+		return
+	}
+
+	sameGCond := SameGoroutineCondition(dbp.SelectedGoroutine())
+	if tgt.call.Loc.PC == topframe.Current.PC {
+		// We are stopped on the CALL instruction, Continue will execute it
+		// without stopping so the destination breakpoint must be set now.
+		if err := setStepIntoBreakpoint(dbp, []AsmInstruction{tgt.call}, sameGCond); err != nil {
+			dbp.ClearInternalBreakpoints()
+			return err
+		}
+		return Continue(dbp)
+	}
+
+	// Stop on the selected CALL instruction, Continue will then set a
+	// breakpoint on its destination, see the description of
+	// proc.(*Process).next for the meaning of StepBreakpoints.
+	sameFrameCond := andFrameoffCondition(sameGCond, topframe.CFA-int64(topframe.StackHi))
+	if bp, err := dbp.SetBreakpoint(tgt.call.Loc.PC, StepBreakpoint, sameFrameCond); err != nil {
+		if _, isexists := err.(BreakpointExistsError); !isexists {
+			dbp.ClearInternalBreakpoints()
+			return err
+		}
+		if bp.Kind == NextBreakpoint {
+			bp.Kind = StepBreakpoint
+		}
+	}
+
+	return Continue(dbp)
+}
+
 // SameGoroutineCondition returns an expression that evaluates to true when
 // the current goroutine is g.
 func SameGoroutineCondition(g *G) ast.Expr {
@@ -296,6 +473,30 @@ func StepOut(dbp Process) error {
 	}
 
 	sameGCond := SameGoroutineCondition(selg)
+
+	if dbp.GetDirection() == Backward {
+		retFrameCond := andFrameoffCondition(sameGCond, retframe.CFA-int64(retframe.StackHi))
+		// Executing backwards we leave the current function through the CALL
+		// instruction that called it, deferred functions do not matter.
+		if topframe.Ret == 0 {
+			return errors.New("nothing to stepout to")
+		}
+		callpc, err := callInstructionBefore(dbp, topframe.Ret)
+		if err != nil {
+			return err
+		}
+		if _, err := dbp.SetBreakpoint(callpc, NextBreakpoint, retFrameCond); err != nil {
+			if _, isexists := err.(BreakpointExistsError); !isexists {
+				return err
+			}
+		}
+		if bp, _, _ := curthread.Breakpoint(); bp == nil {
+			curthread.SetCurrentBreakpoint()
+		}
+		return Continue(dbp)
+	}
+
+	retaddr, retframe := unfilteredRetframe(dbp, selg, curthread, topframe, retframe)
 	retFrameCond := andFrameoffCondition(sameGCond, retframe.CFA-int64(retframe.StackHi))
 
 	var deferpc uint64 = 0
@@ -332,14 +533,18 @@ func StepOut(dbp Process) error {
 		}
 	}
 
-	if topframe.Ret != 0 {
-		_, err := dbp.SetBreakpoint(topframe.Ret, NextBreakpoint, retFrameCond)
+	if retaddr != 0 {
+		bp, err := dbp.SetBreakpoint(retaddr, NextBreakpoint, retFrameCond)
 		if err != nil {
 			if _, isexists := err.(BreakpointExistsError); !isexists {
 				dbp.ClearInternalBreakpoints()
 				return err
 			}
 		}
+		// return values can only be read if we return to the caller
+		if err == nil && bp != nil && retaddr == topframe.Ret {
+			bp.returnInfo = &returnBreakpointInfo{topframe.Current.PC, topframe.CFA - int64(topframe.StackHi)}
+		}
 	}
 
 	if bp, _, _ := curthread.Breakpoint(); bp == nil {
@@ -349,6 +554,79 @@ func StepOut(dbp Process) error {
 	return Continue(dbp)
 }
 
+// Jump changes the PC of the thread running the selected goroutine to addr,
+// without executing any code. Addr must be the first instruction of a line
+// of the function being executed, after its prologue: the stack frame
+// stays the same, only the next statement to execute changes.
+func Jump(dbp Process, addr uint64) error {
+	if dbp.Exited() {
+		return &ProcessExitedError{Pid: dbp.Pid()}
+	}
+	if recorded, _ := dbp.Recorded(); recorded {
+		return errors.New("can not jump in a recording")
+	}
+	for _, bp := range dbp.Breakpoints() {
+		if bp.Internal() && bp.Kind != WatchOutOfScopeBreakpoint {
+			return errors.New("can not jump while nexting")
+		}
+	}
+	if dbp.Common().fncallState.inProgress {
+		return errors.New("can not jump while a function call is in progress")
+	}
+
+	thread := dbp.CurrentThread()
+	if g := dbp.SelectedGoroutine(); g != nil {
+		if g.Thread == nil {
+			return fmt.Errorf("goroutine %d is not running on a thread", g.ID)
+		}
+		thread = g.Thread
+	}
+	regs, err := thread.Registers(false)
+	if err != nil {
+		return err
+	}
+
+	bi := dbp.BinInfo()
+	curfn := bi.PCToFunc(regs.PC())
+	fn := bi.PCToFunc(addr)
+	if curfn == nil {
+		return errors.New("could not find the current function")
+	}
+	if fn != curfn {
+		return fmt.Errorf("can not jump outside of the current function %s", curfn.Name)
+	}
+	if pc, _ := FirstPCAfterPrologue(dbp, fn, false); addr < pc {
+		return fmt.Errorf("can not jump into the prologue of %s", fn.Name)
+	}
+	file, line, _ := bi.PCToLine(addr)
+	pcs, err := bi.lineInfo.AllPCsBetween(fn.Entry, fn.End-1, file)
+	if err != nil {
+		return err
+	}
+	isLineStart := false
+	for _, pc := range pcs {
+		if pc == addr {
+			isLineStart = true
+			break
+		}
+	}
+	if !isLineStart {
+		return fmt.Errorf("%#x is not the start of line %s:%d", addr, file, line)
+	}
+
+	if err := regs.SetPC(thread, addr); err != nil {
+		return err
+	}
+	if err := thread.SetCurrentBreakpoint(); err != nil {
+		return err
+	}
+	// the cached goroutines have the old PC
+	if dbp, ok := dbp.(AllGCache); ok {
+		*dbp.AllGCache() = nil
+	}
+	return dbp.SwitchThread(thread.ThreadID())
+}
+
 // If the argument of GoroutinesInfo implements AllGCache GoroutinesInfo
 // will use the pointer returned by AllGCache as a cache.
 type AllGCache interface {
@@ -493,10 +771,26 @@ func ConvertEvalScope(dbp Process, gid, frame int) (*EvalScope, error) {
 
 	PC, CFA := locs[frame].Current.PC, locs[frame].CFA
 
-	return &EvalScope{PC, CFA, thread, g.variable, dbp.BinInfo(), g.stackhi}, nil
+	var regs *frameRegs
+	switch {
+	case frame > 0:
+		// the stack pointer of an outer frame is the CFA of the frame it called
+		regs = &frameRegs{pc: PC, sp: uint64(locs[frame-1].CFA)}
+	case g.Thread != nil:
+		regs = &frameRegs{thread: g.Thread}
+	default:
+		regs = &frameRegs{pc: g.PC, sp: g.SP}
+	}
+
+	scope := &EvalScope{PC, CFA, thread, g.variable, dbp.BinInfo(), g.stackhi, nil, nil, regs}
+	if sg := dbp.SelectedGoroutine(); dbp.Common().fncallAllowed && sg != nil && sg.ID == g.ID {
+		// calls are injected on the selected goroutine
+		scope.callCtx = &callContext{p: dbp, checkEscape: true, retLoadCfg: loadFullValue}
+	}
+	return scope, nil
 }
 
 // FrameToScope returns a new EvalScope for this frame
 func FrameToScope(p Process, frame Stackframe) *EvalScope {
-	return &EvalScope{frame.Current.PC, frame.CFA, p.CurrentThread(), nil, p.BinInfo(), frame.StackHi}
+	return &EvalScope{frame.Current.PC, frame.CFA, p.CurrentThread(), nil, p.BinInfo(), frame.StackHi, nil, nil, nil}
 }
@@ -1,7 +1,11 @@
 package proc
 
 import (
+	"debug/gosym"
+	"go/ast"
 	"testing"
+
+	"github.com/derekparker/delve/pkg/dwarf/godwarf"
 )
 
 func TestIssue554(t *testing.T) {
@@ -12,3 +16,157 @@ func TestIssue554(t *testing.T) {
 		t.Fatalf("should be false")
 	}
 }
+
+func TestStepFilterMatch(t *testing.T) {
+	var f stepFilter
+	for _, pattern := range []string{"runtime", "sync/...", ".../vendor/..."} {
+		rx, err := packagePatternRegexp(pattern)
+		if err != nil {
+			t.Fatalf("packagePatternRegexp(%q): %v", pattern, err)
+		}
+		f.packages = append(f.packages, rx)
+	}
+	f.files = []string{"*_test.go", "/usr/local/go/src/*/*.go"}
+
+	testcases := []struct {
+		fn   string
+		file string
+		skip bool
+	}{
+		{"runtime.gopark", "/go/src/runtime/proc.go", true},
+		{"runtime/internal/atomic.Load", "/go/src/runtime/internal/atomic/atomic.go", false},
+		{"sync.(*Mutex).Lock", "/go/src/sync/mutex.go", true},
+		{"sync/atomic.AddInt32", "/go/src/sync/atomic/doc.go", true},
+		{"github.com/a/b/vendor/github.com/c/d.F", "/src/github.com/a/b/vendor/github.com/c/d/d.go", true},
+		{"github.com/a/b.F", "/src/github.com/a/b/b.go", false},
+		{"github.com/a/b.TestF", "/src/github.com/a/b/b_test.go", true},
+		{"fmt.Println", "/usr/local/go/src/fmt/print.go", true},
+		{"main.main", "/src/main.go", false},
+	}
+	for _, tc := range testcases {
+		fn := &gosym.Func{Sym: &gosym.Sym{Name: tc.fn}}
+		if skip := f.skip(fn, tc.file); skip != tc.skip {
+			t.Errorf("%s (%s): expected skip %v got %v", tc.fn, tc.file, tc.skip, skip)
+		}
+	}
+}
+
+func TestRewriteConvVars(t *testing.T) {
+	testcases := []struct {
+		in, out string
+	}{
+		{"a + b", "a + b"},
+		{"$1", convVarPrefix + "1"},
+		{"$1.A + $x[2]", convVarPrefix + "1.A + " + convVarPrefix + "x[2]"},
+		{`s == "$1" && c == '$'`, `s == "$1" && c == '$'`},
+		{"s == `$x\\` && $y", "s == `$x\\` && " + convVarPrefix + "y"},
+		{`"\"$a" + $b`, `"\"$a" + ` + convVarPrefix + "b"},
+		{"$ + 1", "$ + 1"},
+	}
+	for _, tc := range testcases {
+		if out := rewriteConvVars(tc.in); out != tc.out {
+			t.Errorf("rewriteConvVars(%q) = %q, expected %q", tc.in, out, tc.out)
+		}
+	}
+}
+
+func TestConvenienceVariables(t *testing.T) {
+	var cv ConvenienceVariables
+	mem := &memCache{0x1000, []byte{1, 2, 3, 4}, nil}
+	v := &Variable{Addr: 0x1000, RealType: &godwarf.UintType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 4}}}, mem: mem}
+
+	if n := cv.Record(v); n != 1 {
+		t.Fatalf("wrong history index %d", n)
+	}
+	mem.cache[0] = 5
+	r, err := cv.Lookup("1")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	buf := make([]byte, 4)
+	r.mem.ReadMemory(buf, 0x1000)
+	if r.Name != "$1" || buf[0] != 1 {
+		t.Fatalf("value not captured: %s %v", r.Name, buf)
+	}
+	if _, err := r.mem.WriteMemory(0x1002, []byte{0}); err == nil {
+		t.Fatalf("captured value modified")
+	}
+
+	if err := cv.Set("1", v); err == nil {
+		t.Fatalf("could assign to the value history")
+	}
+	if err := cv.Set("x", v); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if vars := cv.List(); len(vars) != 2 || vars[0].Name != "$1" || vars[1].Name != "$x" {
+		t.Fatalf("wrong list %v", vars)
+	}
+	if err := cv.Clear("x"); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, err := cv.Lookup("x"); err == nil {
+		t.Fatalf("$x not cleared")
+	}
+	cv.Clear("")
+	if _, err := cv.Lookup("1"); err == nil {
+		t.Fatalf("history not cleared")
+	}
+}
+
+func TestConvVarOtherProcess(t *testing.T) {
+	mem := &memCache{0x100, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, nil}
+	bi := &BinaryInfo{}
+	v := &Variable{Name: "x", Addr: 0x100, RealType: &godwarf.UintType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{ByteSize: 8}}}, mem: mem, bi: bi}
+
+	var cv ConvenienceVariables
+	cv.Record(v)
+	node := &ast.Ident{Name: convVarPrefix + "1"}
+
+	r, err := (&EvalScope{BinInfo: bi, ConvVars: &cv}).evalConvVar(node)
+	if err != nil {
+		t.Fatalf("evalConvVar: %v", err)
+	}
+	buf := make([]byte, 8)
+	if _, err := r.mem.ReadMemory(buf, 0x108); err != nil || buf[0] != 9 {
+		t.Fatalf("could not read the memory of the same process: %v %v", buf, err)
+	}
+
+	r, err = (&EvalScope{BinInfo: &BinaryInfo{}, ConvVars: &cv}).evalConvVar(node)
+	if err != nil {
+		t.Fatalf("evalConvVar: %v", err)
+	}
+	if _, err := r.mem.ReadMemory(buf, 0x100); err != nil || buf[0] != 1 {
+		t.Fatalf("could not read the snapshot: %v %v", buf, err)
+	}
+	if _, err := r.mem.ReadMemory(buf, 0x108); err != errStaleConvVar {
+		t.Fatalf("memory of a different process read: %v", err)
+	}
+}
+
+func TestForkBreakpoints(t *testing.T) {
+	bp1 := &Breakpoint{Addr: 0x10, ID: 1, Kind: UserBreakpoint, HitCount: map[int]uint64{1: 2}, TotalHitCount: 2}
+	bp2 := &Breakpoint{Addr: 0x20, ID: 1, Kind: UserBreakpoint, HitCount: bp1.HitCount, TotalHitCount: 2}
+	bp1.logical = []*Breakpoint{bp1, bp2}
+	bp2.logical = bp1.logical
+	next := &Breakpoint{Addr: 0x30, ID: 1, Kind: NextBreakpoint}
+	wp := &Breakpoint{Addr: 0x40, ID: 2, Kind: UserBreakpoint, WatchType: WatchWrite}
+	bp2.watchpoints = []*Breakpoint{wp}
+
+	copies, stale := ForkBreakpoints(map[uint64]*Breakpoint{0x10: bp1, 0x20: bp2, 0x30: next, 0x40: wp})
+	if len(copies) != 2 || len(stale) != 1 || stale[0] != next {
+		t.Fatalf("wrong breakpoints %v, stale %v", copies, stale)
+	}
+	c1, c2 := copies[0x10], copies[0x20]
+	if c1 == bp1 || c2 == bp2 || c1.TotalHitCount != 0 || len(c1.HitCount) != 0 || c2.watchpoints != nil {
+		t.Fatalf("breakpoints not copied: %#v %#v", c1, c2)
+	}
+	for _, c := range []*Breakpoint{c1, c2} {
+		if len(c.logical) != 2 || c.logical[0] != c1 || c.logical[1] != c2 {
+			t.Fatalf("wrong logical breakpoint %v", c.logical)
+		}
+	}
+	c1.HitCount[1]++
+	if c2.HitCount[1] != 1 || bp1.HitCount[1] != 2 {
+		t.Fatalf("hit counts not shared by the copies only")
+	}
+}
@@ -2,6 +2,7 @@ package proc_test
 
 import (
 	"bytes"
+	"encoding/binary"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -16,6 +17,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -1412,6 +1414,72 @@ func TestBreakpointCounts(t *testing.T) {
 	})
 }
 
+func TestBreakpointHitCondition(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("bpcountstest", t, func(p proc.Process, fixture protest.Fixture) {
+		addr, _, err := p.BinInfo().LineToPC(fixture.Source, 12)
+		assertNoError(err, t, "LineToPC")
+		bp, err := p.SetBreakpoint(addr, proc.UserBreakpoint, nil)
+		assertNoError(err, t, "SetBreakpoint()")
+		bp.HitCond, err = proc.ParseHitCondition("% 50 == 0")
+		assertNoError(err, t, "ParseHitCondition()")
+
+		stops := 0
+		for {
+			if err := proc.Continue(p); err != nil {
+				if _, exited := err.(proc.ProcessExitedError); exited {
+					break
+				}
+				assertNoError(err, t, "Continue()")
+			}
+			stops++
+			if bp.TotalHitCount%50 != 0 {
+				t.Fatalf("Stopped with TotalHitCount %d", bp.TotalHitCount)
+			}
+		}
+
+		if bp.TotalHitCount != 200 || stops != 4 {
+			t.Fatalf("Wrong TotalHitCount %d or number of stops %d", bp.TotalHitCount, stops)
+		}
+	})
+}
+
+func TestParseHitCondition(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{"> 100", "> 100"},
+		{"==5", "== 5"},
+		{"!= 3", "!= 3"},
+		{"<= 0x10", "<= 16"},
+		{"% 10", "% 10 == 0"},
+		{"% 10 == 0", "% 10 == 0"},
+		{"", ""},
+		{"> ", ""},
+		{"5", ""},
+		{"+ 5", ""},
+		{"> 5 == 0", ""},
+		{"% 10 == 1", ""},
+		{"% 0", ""},
+		{"> -1", ""},
+	} {
+		hc, err := proc.ParseHitCondition(tc.in)
+		if tc.out == "" {
+			if err == nil {
+				t.Errorf("%q: expected error got %v", tc.in, hc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tc.in, err)
+			continue
+		}
+		if hc.String() != tc.out {
+			t.Errorf("%q: expected %q got %q", tc.in, tc.out, hc.String())
+		}
+	}
+}
+
 func BenchmarkArray(b *testing.B) {
 	// each bencharr struct is 128 bytes, bencharr is 64 elements long
 	protest.AllowRecording(b)
@@ -1871,6 +1939,202 @@ func TestPanicBreakpoint(t *testing.T) {
 	})
 }
 
+func TestCatchpointPanic(t *testing.T) {
+	// catch-panic must stop on recovered panics before the stack is unwound
+	protest.AllowRecording(t)
+	withTestProcess("issue594", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := proc.SetCatchpoint(p, "panic")
+		assertNoError(err, t, "SetCatchpoint()")
+		assertNoError(proc.Continue(p), t, "Continue()")
+		bp, _, _ := p.CurrentThread().Breakpoint()
+		if bp == nil || bp.Name != proc.CatchpointPrefix+"panic" || bp.ID >= 0 {
+			t.Fatalf("not on catch-panic breakpoint: %v", bp)
+		}
+		frames, err := proc.ThreadStacktrace(p.CurrentThread(), 20)
+		assertNoError(err, t, "ThreadStacktrace()")
+		found := false
+		for _, frame := range frames {
+			if frame.Call.Fn != nil && frame.Call.Fn.Name == "main.dontsegfault" {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("main.dontsegfault not in stack trace")
+		}
+		v, err := evalVariable(p, "e")
+		assertNoError(err, t, "EvalVariable(e)")
+		if v.Kind != reflect.Interface {
+			t.Fatalf("wrong kind for panic value %v", v.Kind)
+		}
+	})
+}
+
+func TestCatchpointThrow(t *testing.T) {
+	protest.AllowRecording(t)
+	for _, tc := range []struct {
+		fixture, expr, value string
+	}{
+		{"deadlock", "s", "all goroutines are asleep - deadlock!"},
+		{"panic", "msgs.arg", "BOOM!"},
+	} {
+		withTestProcess(tc.fixture, t, func(p proc.Process, fixture protest.Fixture) {
+			_, err := proc.SetCatchpoint(p, "throw")
+			assertNoError(err, t, "SetCatchpoint()")
+			assertNoError(proc.Continue(p), t, "Continue()")
+			bp, _, _ := p.CurrentThread().Breakpoint()
+			if bp == nil || bp.Name != proc.CatchpointPrefix+"throw" || bp.ID != proc.ThrowCatchpointID {
+				t.Fatalf("%s: not on catch-throw breakpoint: %v", tc.fixture, bp)
+			}
+			if expr, _ := proc.CatchpointExpr(bp); expr != tc.expr {
+				t.Fatalf("%s: wrong catchpoint expression %q", tc.fixture, expr)
+			}
+			v, err := evalVariable(p, tc.expr)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if v.Kind == reflect.Interface {
+				v = &v.Children[0]
+			}
+			if s := constant.StringVal(v.Value); s != tc.value {
+				t.Fatalf("%s: wrong value %q", tc.fixture, s)
+			}
+		})
+	}
+}
+
+func TestCatchpointExit(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("osexit", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := proc.SetCatchpoint(p, "exit")
+		assertNoError(err, t, "SetCatchpoint()")
+		assertNoError(proc.Continue(p), t, "Continue()")
+		bp, _, _ := p.CurrentThread().Breakpoint()
+		if bp == nil || bp.Name != proc.CatchpointPrefix+"exit" || bp.ID != proc.ExitCatchpointID {
+			t.Fatalf("not on catch-exit breakpoint: %v", bp)
+		}
+		v, err := evalVariable(p, "code")
+		assertNoError(err, t, "EvalVariable(code)")
+		if code, _ := constant.Int64Val(v.Value); code != 3 {
+			t.Fatalf("wrong exit code %d", code)
+		}
+	})
+}
+
+func TestSyscallCatchpoint(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("syscall catchpoints are only supported by the native backend on linux")
+	}
+	withTestProcess("testprog", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(p.CatchSyscalls([]string{"write"}, true), t, "CatchSyscalls()")
+		assertNoError(proc.Continue(p), t, "Continue()")
+		sc := p.CurrentThread().Syscall()
+		if sc == nil || sc.Name != "write" || sc.Exit {
+			t.Fatalf("not entering write: %#v", sc)
+		}
+		if len(sc.Args) != 3 || sc.Args[0] != "1" || sc.Args[1] != `"Hello, World!\n"` || sc.Args[2] != "14" {
+			t.Fatalf("wrong arguments: %q", sc.Args)
+		}
+		assertNoError(proc.Continue(p), t, "Continue()")
+		sc = p.CurrentThread().Syscall()
+		if sc == nil || sc.Name != "write" || !sc.Exit || sc.Ret != 14 {
+			t.Fatalf("not returning from write: %#v", sc)
+		}
+		assertNoError(p.CatchSyscalls(nil, false), t, "CatchSyscalls(false)")
+		err := proc.Continue(p)
+		if _, exited := err.(proc.ProcessExitedError); !exited {
+			t.Fatalf("program did not exit after disabling syscall catchpoints: %v", err)
+		}
+	})
+}
+
+func TestSyscallCatchpointENOSYS(t *testing.T) {
+	// system calls failing with ENOSYS must not be mistaken for
+	// syscall-enter-stops
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("syscall catchpoints are only supported by the native backend on linux")
+	}
+	withTestProcess("enosys", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(p.CatchSyscalls(nil, true), t, "CatchSyscalls()")
+		var stops []proc.Syscall
+		tid := 0
+		for len(stops) < 3 {
+			assertNoError(proc.Continue(p), t, "Continue()")
+			sc := p.CurrentThread().Syscall()
+			if sc == nil {
+				t.Fatalf("not stopped at a system call")
+			}
+			if tid == 0 && sc.Num == 1000 {
+				tid = p.CurrentThread().ThreadID()
+			}
+			if tid != 0 && tid == p.CurrentThread().ThreadID() {
+				stops = append(stops, *sc)
+			}
+		}
+		if stops[0].Exit {
+			t.Fatalf("not entering syscall_1000: %#v", stops[0])
+		}
+		if !stops[1].Exit || stops[1].Num != 1000 || stops[1].Err != syscall.ENOSYS {
+			t.Fatalf("not returning ENOSYS from syscall_1000: %#v", stops[1])
+		}
+		if stops[2].Exit {
+			t.Fatalf("system call after syscall_1000 reported as exit: %#v", stops[2])
+		}
+	})
+}
+
+func TestSignalHandling(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("signal handling is only supported by the native backend on linux")
+	}
+	withTestProcess("signalprog", t, func(p proc.Process, fixture protest.Fixture) {
+		if err := p.SetSignalHandling("SIGTRAP", proc.SignalHandling{}); err == nil {
+			t.Fatalf("changing the handling of SIGTRAP did not fail")
+		}
+		assertNoError(p.SetSignalHandling("USR1", proc.SignalHandling{Stop: true, Print: true, Pass: true}), t, "SetSignalHandling(USR1)")
+		assertNoError(p.SetSignalHandling("SIGUSR2", proc.SignalHandling{Print: true, Pass: true}), t, "SetSignalHandling(SIGUSR2)")
+		assertNoError(proc.Continue(p), t, "Continue()")
+		sig := p.CurrentThread().Signal()
+		if sig == nil || sig.Name != "SIGUSR1" {
+			t.Fatalf("not stopped by SIGUSR1: %#v", sig)
+		}
+		// the program waits for both signals, it can only exit if they are
+		// delivered
+		err := proc.Continue(p)
+		if _, exited := err.(proc.ProcessExitedError); !exited {
+			t.Fatalf("program did not exit: %v", err)
+		}
+		sigs := p.ReceivedSignals()
+		if len(sigs) != 1 || sigs[0].Name != "SIGUSR2" {
+			t.Fatalf("wrong received signals: %#v", sigs)
+		}
+	})
+}
+
+func TestSignalHandlingStep(t *testing.T) {
+	// a passed signal must be delivered when the thread it stopped is
+	// single stepped
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("signal handling is only supported by the native backend on linux")
+	}
+	withTestProcess("signalprog", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(p.SetSignalHandling("SIGUSR1", proc.SignalHandling{Stop: true, Print: true, Pass: true}), t, "SetSignalHandling(SIGUSR1)")
+		assertNoError(proc.Continue(p), t, "Continue()")
+		sig := p.CurrentThread().Signal()
+		if sig == nil || sig.Name != "SIGUSR1" {
+			t.Fatalf("not stopped by SIGUSR1: %#v", sig)
+		}
+		assertNoError(p.CurrentThread().StepInstruction(), t, "StepInstruction()")
+		loc, err := p.CurrentThread().Location()
+		assertNoError(err, t, "Location()")
+		if loc.Fn == nil || !strings.Contains(loc.Fn.Name, "sigtramp") {
+			t.Fatalf("not in the signal handler after stepping: %#v", loc)
+		}
+		assertNoError(p.SetSignalHandling("SIGUSR1", proc.SignalHandling{Pass: true}), t, "SetSignalHandling(SIGUSR1)")
+		err = proc.Continue(p)
+		if _, exited := err.(proc.ProcessExitedError); !exited {
+			t.Fatalf("program did not exit: %v", err)
+		}
+	})
+}
+
 func TestCmdLineArgs(t *testing.T) {
 	expectSuccess := func(p proc.Process, fixture protest.Fixture) {
 		err := proc.Continue(p)
@@ -2552,6 +2816,148 @@ func TestStepOutPanicAndDirectCall(t *testing.T) {
 	})
 }
 
+func TestStepOutReturnValues(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("stepoutret", t, func(p proc.Process, fixture protest.Fixture) {
+		bp, err := setFunctionBreakpoint(p, "main.stepout")
+		assertNoError(err, t, "SetBreakpoint()")
+		assertNoError(proc.Continue(p), t, "Continue()")
+		p.ClearBreakpoint(bp.Addr)
+
+		assertNoError(proc.StepOut(p), t, "StepOut()")
+
+		ret := p.CurrentThread().Common().ReturnValues(normalLoadConfig)
+		if len(ret) != 2 {
+			t.Fatalf("wrong number of return values %v", ret)
+		}
+		if ret[0].Name != "str" {
+			t.Fatalf("(str) wrong return value name %s", ret[0].Name)
+		}
+		if str := constant.StringVal(ret[0].Value); str != "return 47" {
+			t.Fatalf("(str) wrong return value %q", str)
+		}
+		if ret[1].Name != "num" {
+			t.Fatalf("(num) wrong return value name %s", ret[1].Name)
+		}
+		if num, _ := constant.Int64Val(ret[1].Value); num != 48 {
+			t.Fatalf("(num) wrong return value %d", num)
+		}
+	})
+}
+
+func TestStepTo(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("steptarget", t, func(p proc.Process, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture, 22)
+		assertNoError(proc.Continue(p), t, "Continue()")
+		p.ClearBreakpoint(bp.Addr)
+
+		targets, err := proc.StepTargets(p)
+		assertNoError(err, t, "StepTargets()")
+		var names []string
+		for _, fn := range targets {
+			names = append(names, fn.Name)
+		}
+		if tgt := []string{"main.read", "main.parse", "main.handle"}; !reflect.DeepEqual(names, tgt) {
+			t.Fatalf("wrong step targets %v, expected %v", names, tgt)
+		}
+
+		if err := proc.StepTo(p, "nonexistent"); err == nil {
+			t.Fatalf("StepTo(nonexistent) did not return an error")
+		}
+
+		assertNoError(proc.StepTo(p, "handle"), t, "StepTo(handle)")
+		loc, err := p.CurrentThread().Location()
+		assertNoError(err, t, "Location()")
+		if loc.Fn == nil || loc.Fn.Name != "main.handle" || loc.Line != 17 {
+			t.Fatalf("wrong location after StepTo %s:%d", loc.File, loc.Line)
+		}
+
+		// the index selects one of the calls to the same function
+		bp = setFileBreakpoint(p, t, fixture, 24)
+		assertNoError(proc.Continue(p), t, "Continue()")
+		p.ClearBreakpoint(bp.Addr)
+
+		assertNoError(proc.StepTo(p, "1"), t, "StepTo(1)")
+		loc, err = p.CurrentThread().Location()
+		assertNoError(err, t, "Location()")
+		if loc.Fn == nil || loc.Fn.Name != "main.read" {
+			t.Fatalf("wrong location after StepTo %s:%d", loc.File, loc.Line)
+		}
+		n, err := evalVariable(p, "n")
+		assertNoError(err, t, "EvalVariable(n)")
+		if n, _ := constant.Int64Val(n.Value); n != 2 {
+			t.Fatalf("StepTo(1) stopped in the wrong call to main.read, n = %d", n)
+		}
+	})
+}
+
+func TestStepFilter(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("stepfilter", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(proc.SetStepFilter(p, []string{"sort", "fmt"}, nil), t, "SetStepFilter()")
+
+		assertLine := func(fn string, line int) {
+			loc, err := p.CurrentThread().Location()
+			assertNoError(err, t, "Location()")
+			if loc.Fn == nil || loc.Fn.Name != fn || loc.Line != line {
+				t.Fatalf("wrong location %s:%d, expected %s:%d", loc.File, loc.Line, fn, line)
+			}
+		}
+
+		// stepping out of the closure returns into package sort, execution
+		// continues until main.main
+		bp := setFileBreakpoint(p, t, fixture, 9)
+		assertNoError(proc.Continue(p), t, "Continue()")
+		p.ClearBreakpoint(bp.Addr)
+		assertNoError(proc.StepOut(p), t, "StepOut()")
+		assertLine("main.main.func1", 14)
+		assertNoError(proc.StepOut(p), t, "StepOut()")
+		assertLine("main.main", 14)
+
+		// fmt.Println is stepped over
+		assertNoError(proc.Step(p), t, "Step()")
+		assertLine("main.main", 15)
+		assertNoError(proc.Step(p), t, "Step()")
+		assertLine("main.main", 16)
+	})
+}
+
+func TestJump(t *testing.T) {
+	if testBackend == "rr" {
+		t.Skip("can not jump in a recording")
+	}
+	withTestProcess("steptarget", t, func(p proc.Process, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture, 22)
+		assertNoError(proc.Continue(p), t, "Continue()")
+		p.ClearBreakpoint(bp.Addr)
+
+		addr, err := proc.FindFileLocation(p, fixture.Source, 21)
+		assertNoError(err, t, "FindFileLocation(21)")
+		assertNoError(proc.Jump(p, addr), t, "Jump(21)")
+		if _, ln := currentLineNumber(p, t); ln != 21 {
+			t.Fatalf("wrong line after jump %d", ln)
+		}
+		assertNoError(proc.Next(p), t, "Next()")
+		if _, ln := currentLineNumber(p, t); ln != 22 {
+			t.Fatalf("wrong line after next %d", ln)
+		}
+
+		addr, err = proc.FindFileLocation(p, fixture.Source, 7)
+		assertNoError(err, t, "FindFileLocation(7)")
+		if err := proc.Jump(p, addr); err == nil {
+			t.Fatalf("could jump to a different function")
+		}
+		fn := p.BinInfo().LookupFunc("main.main")
+		if err := proc.Jump(p, fn.Entry); err == nil {
+			t.Fatalf("could jump into the prologue")
+		}
+		if _, ln := currentLineNumber(p, t); ln != 22 {
+			t.Fatalf("wrong line after failed jumps %d", ln)
+		}
+	})
+}
+
 func TestWorkDir(t *testing.T) {
 	wd := os.TempDir()
 	// For Darwin `os.TempDir()` returns `/tmp` which is symlink to `/private/tmp`.
@@ -3137,3 +3543,253 @@ func TestAttachStripped(t *testing.T) {
 	}
 	os.Remove(fixture.Path)
 }
+
+func TestWatchpointsBasic(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("watchpoints only supported by the native backend on linux")
+	}
+	withTestProcess("databpeasy", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := setFunctionBreakpoint(p, "main.main")
+		assertNoError(err, t, "SetBreakpoint")
+		assertNoError(proc.Continue(p), t, "Continue 0")
+
+		wp, err := proc.SetWatchpoint(p, -1, 0, "globalvar1", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint")
+		if wp.WatchType.Size() != 8 {
+			t.Fatalf("wrong watchpoint size %d", wp.WatchType.Size())
+		}
+
+		assertNoError(proc.Continue(p), t, "Continue 1")
+		if bp, active, _ := p.CurrentThread().Breakpoint(); !active || bp != wp {
+			t.Fatalf("not stopped at watchpoint: %v", bp)
+		}
+		oldv, newv, err := wp.WatchValues(p.CurrentThread(), normalLoadConfig)
+		assertNoError(err, t, "WatchValues")
+		if oldv.Value.String() != "0" || newv.Value.String() != "2" {
+			t.Fatalf("wrong values of watched expression: %v %v", oldv.Value, newv.Value)
+		}
+
+		assertNoError(proc.Continue(p), t, "Continue 2")
+		if bp, active, _ := p.CurrentThread().Breakpoint(); !active || bp != wp {
+			t.Fatalf("not stopped at watchpoint: %v", bp)
+		}
+		oldv, newv, err = wp.WatchValues(p.CurrentThread(), normalLoadConfig)
+		assertNoError(err, t, "WatchValues")
+		if oldv.Value.String() != "2" || newv.Value.String() != "4" {
+			t.Fatalf("wrong values of watched expression: %v %v", oldv.Value, newv.Value)
+		}
+
+		_, err = proc.ClearWatchpoint(p, wp.Addr)
+		assertNoError(err, t, "ClearWatchpoint")
+		assertNoError(proc.Continue(p), t, "Continue 3")
+		if _, l := currentLineNumber(p, t); l != 17 {
+			t.Fatalf("watchpoint not cleared, stopped at line %d", l)
+		}
+	})
+}
+
+func TestWatchpointOutOfScope(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("watchpoints only supported by the native backend on linux")
+	}
+	withTestProcess("databpeasy", t, func(p proc.Process, fixture protest.Fixture) {
+		fbp, err := setFunctionBreakpoint(p, "main.f")
+		assertNoError(err, t, "SetBreakpoint")
+		assertNoError(proc.Continue(p), t, "Continue to runtime.Breakpoint")
+		assertNoError(proc.Continue(p), t, "Continue to main.f")
+		if bp, _, _ := p.CurrentThread().Breakpoint(); bp != fbp {
+			t.Fatalf("not stopped at main.f: %v", bp)
+		}
+
+		wp, err := proc.SetWatchpoint(p, -1, 0, "localvar", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint")
+
+		// localvar is written three times before f returns
+		for i := 0; i < 3; i++ {
+			assertNoError(proc.Continue(p), t, fmt.Sprintf("Continue %d", i))
+			if bp, _, _ := p.CurrentThread().Breakpoint(); bp != wp {
+				t.Fatalf("not stopped at watchpoint (%d): %v", i, bp)
+			}
+		}
+
+		assertNoError(proc.Continue(p), t, "Continue to return")
+		bp, active, _ := p.CurrentThread().Breakpoint()
+		if !active || bp.Kind != proc.WatchOutOfScopeBreakpoint {
+			t.Fatalf("not stopped when the watched frame returned: %v", bp)
+		}
+		if len(bp.WatchOutOfScope) != 1 || bp.WatchOutOfScope[0] != wp {
+			t.Fatalf("wrong watchpoints out of scope: %v", bp.WatchOutOfScope)
+		}
+		for _, bp := range p.Breakpoints() {
+			if bp.WatchType != 0 || bp.Kind == proc.WatchOutOfScopeBreakpoint {
+				t.Fatalf("breakpoint not cleared: %v", bp)
+			}
+		}
+		if _, l := currentLineNumber(p, t); l != 18 {
+			t.Fatalf("wrong line after watchpoint went out of scope: %d", l)
+		}
+	})
+}
+
+func TestWatchpointOutOfScopeUserBreakpoint(t *testing.T) {
+	// a user breakpoint on the return address of the frame of the watched
+	// variable is also used to detect when the watchpoint goes out of scope
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("watchpoints only supported by the native backend on linux")
+	}
+	withTestProcess("databpeasy", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := setFunctionBreakpoint(p, "main.f")
+		assertNoError(err, t, "SetBreakpoint")
+		assertNoError(proc.Continue(p), t, "Continue to runtime.Breakpoint")
+		assertNoError(proc.Continue(p), t, "Continue to main.f")
+
+		frames, err := proc.ThreadStacktrace(p.CurrentThread(), 1)
+		assertNoError(err, t, "ThreadStacktrace")
+		retbp, err := p.SetBreakpoint(frames[0].Ret, proc.UserBreakpoint, nil)
+		assertNoError(err, t, "SetBreakpoint on the return address")
+
+		wp, err := proc.SetWatchpoint(p, -1, 0, "localvar", proc.WatchWrite, nil)
+		assertNoError(err, t, "SetWatchpoint")
+
+		for i := 0; i < 3; i++ {
+			assertNoError(proc.Continue(p), t, fmt.Sprintf("Continue %d", i))
+			if bp, _, _ := p.CurrentThread().Breakpoint(); bp != wp {
+				t.Fatalf("not stopped at watchpoint (%d): %v", i, bp)
+			}
+		}
+
+		assertNoError(proc.Continue(p), t, "Continue to return")
+		bp, active, _ := p.CurrentThread().Breakpoint()
+		if !active || bp != retbp {
+			t.Fatalf("not stopped at the user breakpoint: %v", bp)
+		}
+		if len(bp.WatchOutOfScope) != 1 || bp.WatchOutOfScope[0] != wp {
+			t.Fatalf("wrong watchpoints out of scope: %v", bp.WatchOutOfScope)
+		}
+		if p.Breakpoints()[retbp.Addr] != retbp {
+			t.Fatalf("user breakpoint cleared along with the watchpoint")
+		}
+		for _, bp := range p.Breakpoints() {
+			if bp.WatchType != 0 || bp.Kind == proc.WatchOutOfScopeBreakpoint {
+				t.Fatalf("breakpoint not cleared: %v", bp)
+			}
+		}
+	})
+}
+
+func TestFollowForkExec(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("following forks is only supported by the native backend on linux")
+	}
+	withTestProcess("forkexec", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(p.SetFollowForkMode(proc.FollowBoth), t, "SetFollowForkMode()")
+
+		// the child stops after loading the new program
+		assertNoError(proc.Continue(p), t, "Continue()")
+		c := p.CurrentInferior()
+		if c.Pid() == p.Pid() {
+			t.Fatalf("stopped in the parent process")
+		}
+		sc := c.CurrentThread().Syscall()
+		if sc == nil || sc.Name != "execve" || !sc.Exit {
+			t.Fatalf("not returning from execve: %#v", sc)
+		}
+		if n := len(p.Inferiors()); n != 2 {
+			t.Fatalf("wrong number of inferiors %d", n)
+		}
+		if len(c.Breakpoints()) != 0 {
+			t.Fatalf("breakpoints not cleared by exec: %v", c.Breakpoints())
+		}
+
+		// breakpoints are set in the current inferior only
+		_, err := setFunctionBreakpoint(c, "main.child")
+		assertNoError(err, t, "SetBreakpoint()")
+		assertNoError(proc.Continue(c), t, "Continue()")
+		if p.CurrentInferior() != c {
+			t.Fatalf("stopped in the wrong process")
+		}
+		if loc, _ := c.CurrentThread().Location(); loc.Fn == nil || loc.Fn.Name != "main.child" {
+			t.Fatalf("not stopped in main.child: %v", loc)
+		}
+
+		// the program terminates when both processes have exited
+		err = proc.Continue(c)
+		if _, exited := err.(proc.ProcessExitedError); !exited {
+			t.Fatalf("program did not exit: %v", err)
+		}
+		if !c.Exited() || !p.Exited() {
+			t.Fatalf("processes still running")
+		}
+	})
+}
+
+func TestRegistersInExpressions(t *testing.T) {
+	withTestProcess("testnextprog", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := setFunctionBreakpoint(p, "main.helloworld")
+		assertNoError(err, t, "SetBreakpoint()")
+		assertNoError(proc.Continue(p), t, "Continue()")
+
+		regs, err := p.CurrentThread().Registers(false)
+		assertNoError(err, t, "Registers()")
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		for _, tc := range []struct {
+			expr string
+			val  uint64
+		}{
+			{"$rsp", regs.SP()},
+			{"$rip", regs.PC()},
+			{"$rsp + 8", regs.SP() + 8},
+		} {
+			v, err := scope.EvalVariable(tc.expr, normalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if n, _ := constant.Uint64Val(v.Value); n != tc.val {
+				t.Fatalf("%s: expected %#x got %#x", tc.expr, tc.val, n)
+			}
+		}
+
+		top, err := scope.EvalVariable("*(*uint64)($rsp)", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(*(*uint64)($rsp))")
+		buf := make([]byte, 8)
+		_, err = p.CurrentThread().ReadMemory(buf, uintptr(regs.SP()))
+		assertNoError(err, t, "ReadMemory()")
+		if n, _ := constant.Uint64Val(top.Value); n != binary.LittleEndian.Uint64(buf) {
+			t.Fatalf("wrong value at the top of the stack %#x, expected %#x", n, binary.LittleEndian.Uint64(buf))
+		}
+
+		frames, err := proc.ThreadStacktrace(p.CurrentThread(), 1)
+		assertNoError(err, t, "ThreadStacktrace()")
+
+		// outer frames only know the instruction and stack pointers
+		g, err := proc.GetG(p.CurrentThread())
+		assertNoError(err, t, "GetG()")
+		scope1, err := proc.ConvertEvalScope(p, g.ID, 1)
+		assertNoError(err, t, "ConvertEvalScope(frame 1)")
+		v, err := scope1.EvalVariable("$rip", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable($rip) in frame 1")
+		if n, _ := constant.Uint64Val(v.Value); n != frames[1].Current.PC {
+			t.Fatalf("wrong $rip in frame 1 %#x, expected %#x", n, frames[1].Current.PC)
+		}
+		if _, err := scope1.EvalVariable("$rax", normalLoadConfig); err == nil {
+			t.Fatalf("$rax available in frame 1")
+		}
+		if err := scope1.SetRegister("rax", "1"); err == nil {
+			t.Fatalf("could change $rax in frame 1")
+		}
+
+		if testBackend == "rr" {
+			return
+		}
+		err = scope.SetRegister("rax", "42")
+		if err == proc.ChangeRegisterUnsupportedErr {
+			return
+		}
+		assertNoError(err, t, "SetRegister(rax)")
+		v, err = scope.EvalVariable("$rax", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable($rax)")
+		if n, _ := constant.Uint64Val(v.Value); n != 42 {
+			t.Fatalf("wrong $rax after set %d", n)
+		}
+	})
+}
@@ -156,6 +156,10 @@ func AppendSSEReg(regs []Register, name string, xmm []byte) []Register {
 
 var UnknownRegisterError = errors.New("unknown register")
 
+// ChangeRegisterUnsupportedErr is returned by backends that can not change
+// the value of registers.
+var ChangeRegisterUnsupportedErr = errors.New("changing registers is not supported by this backend")
+
 type flagRegisterDescr []flagDescr
 type flagDescr struct {
 	name string
@@ -255,6 +259,7 @@ type LinuxX86Xstate struct {
 	PtraceFpRegs
 	AvxState bool // contains AVX state
 	YmmSpace [256]byte
+	Xsave    []byte // raw contents of the XSAVE area
 }
 
 // Decode decodes an XSAVE area to a list of name/value pairs of registers.
@@ -0,0 +1,125 @@
+package proc
+
+import (
+	"fmt"
+	"go/constant"
+	"reflect"
+	"strings"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// frameRegs contains the registers of the frame of an EvalScope, that
+// expressions can use as $rax, $rsp, $rip, etc.
+type frameRegs struct {
+	// thread is set for the topmost frame of a goroutine running on a
+	// thread, all registers are read from and written to the thread.
+	thread Thread
+	// pc and sp are used otherwise: for a parked goroutine they are the
+	// values saved by the scheduler, for outer frames they are the return
+	// address and the CFA of the frame called by it.
+	pc, sp uint64
+}
+
+// registerNames maps the names of the 64-bit general purpose registers and
+// of the instruction pointer to their x86asm.Reg.
+var registerNames = map[string]x86asm.Reg{}
+
+func init() {
+	for reg := x86asm.RAX; reg <= x86asm.R15; reg++ {
+		registerNames[strings.ToLower(reg.String())] = reg
+	}
+	registerNames["rip"] = x86asm.RIP
+}
+
+// IsRegister returns true if $name refers to a register.
+func IsRegister(name string) bool {
+	_, ok := registerNames[name]
+	return ok
+}
+
+func (scope *EvalScope) evalRegister(reg x86asm.Reg, name string) (*Variable, error) {
+	if scope.regs == nil {
+		return nil, fmt.Errorf("register $%s not available", name)
+	}
+	var val uint64
+	switch {
+	case scope.regs.thread != nil:
+		regs, err := scope.regs.thread.Registers(false)
+		if err != nil {
+			return nil, err
+		}
+		if reg == x86asm.RIP {
+			val = regs.PC()
+		} else if val, err = regs.Get(int(reg)); err != nil {
+			return nil, err
+		}
+	case reg == x86asm.RIP:
+		val = scope.regs.pc
+	case reg == x86asm.RSP:
+		val = scope.regs.sp
+	default:
+		return nil, fmt.Errorf("register $%s not available in this frame", name)
+	}
+
+	typ, err := scope.BinInfo.findType("uint64")
+	if err != nil {
+		v := newConstant(constant.MakeUint64(val), scope.Mem)
+		v.Name = "$" + name
+		return v, nil
+	}
+	v := scope.newVariable("$"+name, 0, typ)
+	v.Value = constant.MakeUint64(val)
+	v.loaded = true
+	return v, nil
+}
+
+// SetRegister changes the register name (e.g. "rax") to the value of the
+// expression value. Only the registers of the topmost frame of a goroutine
+// running on a thread can be changed.
+func (scope *EvalScope) SetRegister(name, value string) error {
+	reg, ok := registerNames[name]
+	if !ok {
+		return fmt.Errorf("unknown register $%s", name)
+	}
+	if scope.regs == nil || scope.regs.thread == nil {
+		return fmt.Errorf("can not change register $%s in this frame", name)
+	}
+
+	t, err := ParseExpr(value)
+	if err != nil {
+		return err
+	}
+	yv, err := scope.evalAST(t)
+	if err != nil {
+		return err
+	}
+	yv.loadValue(loadSingleValue)
+	if yv.Unreadable != nil {
+		return fmt.Errorf("Expression \"%s\" is unreadable: %v", value, yv.Unreadable)
+	}
+
+	var n uint64
+	switch yv.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, _ := constant.Int64Val(yv.Value)
+		n = uint64(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, _ = constant.Uint64Val(yv.Value)
+	case reflect.Ptr, reflect.UnsafePointer:
+		if len(yv.Children) > 0 {
+			n = uint64(yv.Children[0].Addr)
+		}
+	default:
+		return fmt.Errorf("can not assign %s to register $%s", yv.TypeString(), name)
+	}
+
+	thread := scope.regs.thread
+	if err := thread.SetReg(int(reg), n); err != nil {
+		return err
+	}
+	if reg == x86asm.RIP {
+		return thread.SetCurrentBreakpoint()
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/constant"
+	"math/big"
+	"net"
+	"reflect"
+	"time"
+)
+
+// stdlibDisplay returns the function returning the human readable
+// representation of the values of typename, if typename is one of the
+// standard library types whose fields are hard to read.
+func stdlibDisplay(typename string) func(v *Variable, cfg LoadConfig) (string, error) {
+	switch typename {
+	case "time.Time":
+		return displayTime
+	case "time.Duration":
+		return displayDuration
+	case "math/big.Int":
+		return displayBigInt
+	case "net.IP":
+		return displayIP
+	case "net.IPNet":
+		return displayIPNet
+	case "sync.Mutex":
+		return displayMutex
+	case "bytes.Buffer":
+		return displayBuffer
+	}
+	return nil
+}
+
+const (
+	// maxBigIntWords is the size of the largest big.Int that is displayed.
+	maxBigIntWords = 64
+	// maxIPBytes is the size of the largest net.IP and net.IPMask values
+	// that are displayed.
+	maxIPBytes = 64
+)
+
+// maxArrayValues returns the smaller of max and the number of array
+// elements cfg allows to load.
+func maxArrayValues(cfg LoadConfig, max int64) int64 {
+	if int64(cfg.MaxArrayValues) < max {
+		return int64(cfg.MaxArrayValues)
+	}
+	return max
+}
+
+// loadDisplay sets v.Display if v is a value of one of the standard
+// library types in stdlibDisplay. The fields of v are left as they are, so
+// that they can still be examined.
+func (v *Variable) loadDisplay(cfg LoadConfig) {
+	if v.Unreadable != nil || v.DwarfType == nil {
+		return
+	}
+	fn := stdlibDisplay(v.DwarfType.Common().Name)
+	if fn == nil {
+		return
+	}
+	if s, err := fn(v, cfg); err == nil {
+		v.Display = s
+	}
+}
+
+// intMember returns the value of the integer or boolean field name of v.
+func (v *Variable) intMember(name string) (int64, error) {
+	f, err := v.structMember(name)
+	if err != nil {
+		return 0, err
+	}
+	f.loadValue(loadSingleValue)
+	if f.Unreadable != nil {
+		return 0, f.Unreadable
+	}
+	switch f.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, _ := constant.Int64Val(f.Value)
+		return n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, _ := constant.Uint64Val(f.Value)
+		return int64(n), nil
+	case reflect.Bool:
+		if constant.BoolVal(f.Value) {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%s is not an integer", name)
+}
+
+// sliceBytes reads the memory of the elements of the slice v, if it has
+// no more than max elements.
+func (v *Variable) sliceBytes(max int64) ([]byte, error) {
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	if v.Kind != reflect.Slice {
+		return nil, errors.New("not a slice")
+	}
+	if v.Len > max {
+		return nil, errors.New("too large")
+	}
+	buf := make([]byte, v.Len*v.stride)
+	if len(buf) == 0 {
+		return buf, nil
+	}
+	_, err := v.mem.ReadMemory(buf, v.Base)
+	return buf, err
+}
+
+func displayDuration(v *Variable, cfg LoadConfig) (string, error) {
+	n, _ := constant.Int64Val(v.Value)
+	return time.Duration(n).String(), nil
+}
+
+// Constants of package time used to decode time.Time values.
+const (
+	secondsPerDay  = 24 * 60 * 60
+	unixToInternal = (1969*365 + 1969/4 - 1969/100 + 1969/400) * secondsPerDay
+	wallToInternal = (1884*365 + 1884/4 - 1884/100 + 1884/400) * secondsPerDay
+	hasMonotonic   = 1 << 63
+	nsecMask       = 1<<30 - 1
+	nsecShift      = 30
+)
+
+func displayTime(v *Variable, cfg LoadConfig) (string, error) {
+	var sec, nsec int64
+	if wall, err := v.intMember("wall"); err == nil {
+		ext, err := v.intMember("ext")
+		if err != nil {
+			return "", err
+		}
+		if uint64(wall)&hasMonotonic != 0 {
+			sec = wallToInternal + int64(uint64(wall)<<1>>(nsecShift+1))
+		} else {
+			sec = ext
+		}
+		nsec = int64(uint64(wall) & nsecMask)
+	} else {
+		// before Go 1.9
+		if sec, err = v.intMember("sec"); err != nil {
+			return "", err
+		}
+		if nsec, err = v.intMember("nsec"); err != nil {
+			return "", err
+		}
+	}
+	unix := sec - unixToInternal
+
+	loc, err := v.structMember("loc")
+	if err != nil {
+		return "", err
+	}
+	name, offset, err := timeZone(loc.maybeDereference(), unix)
+	if err != nil {
+		return "", err
+	}
+	return time.Unix(unix, nsec).In(time.FixedZone(name, offset)).Format(time.RFC3339Nano), nil
+}
+
+// timeZone returns the name and the offset of the zone of the
+// time.Location loc in effect at the time unix, like Location.lookup.
+func timeZone(loc *Variable, unix int64) (string, int, error) {
+	if loc.Unreadable != nil {
+		return "", 0, loc.Unreadable
+	}
+	if loc.Addr == 0 {
+		return "UTC", 0, nil
+	}
+	zones, err := loc.structMember("zone")
+	if err != nil {
+		return "", 0, err
+	}
+	if zones.Len == 0 {
+		return "UTC", 0, nil
+	}
+
+	zone := func(z *Variable) (string, int, error) {
+		name, err := z.structMember("name")
+		if err != nil {
+			return "", 0, err
+		}
+		name.loadValue(loadSingleValue)
+		if name.Unreadable != nil {
+			return "", 0, name.Unreadable
+		}
+		offset, err := z.intMember("offset")
+		return constant.StringVal(name.Value), int(offset), err
+	}
+
+	if cz, err := loc.structMember("cacheZone"); err == nil {
+		start, _ := loc.intMember("cacheStart")
+		end, _ := loc.intMember("cacheEnd")
+		if cz = cz.maybeDereference(); cz.Addr != 0 && cz.Unreadable == nil && start <= unix && unix < end {
+			return zone(cz)
+		}
+	}
+
+	idx := int64(0)
+	tx, err := loc.structMember("tx")
+	if err != nil {
+		return "", 0, err
+	}
+	when := func(i int) (int64, error) {
+		t, err := tx.sliceAccess(i)
+		if err != nil {
+			return 0, err
+		}
+		return t.intMember("when")
+	}
+	if first, err := when(0); err == nil && first <= unix {
+		lo, hi := 0, int(tx.Len)
+		for hi-lo > 1 {
+			m := lo + (hi-lo)/2
+			w, err := when(m)
+			if err != nil {
+				return "", 0, err
+			}
+			if w <= unix {
+				lo = m
+			} else {
+				hi = m
+			}
+		}
+		t, err := tx.sliceAccess(lo)
+		if err != nil {
+			return "", 0, err
+		}
+		if idx, err = t.intMember("index"); err != nil {
+			return "", 0, err
+		}
+	}
+	z, err := zones.sliceAccess(int(idx))
+	if err != nil {
+		return "", 0, err
+	}
+	return zone(z)
+}
+
+func displayBigInt(v *Variable, cfg LoadConfig) (string, error) {
+	neg, err := v.intMember("neg")
+	if err != nil {
+		return "", err
+	}
+	abs, err := v.structMember("abs")
+	if err != nil {
+		return "", err
+	}
+	buf, err := abs.sliceBytes(maxArrayValues(cfg, maxBigIntWords))
+	if err != nil {
+		return "", err
+	}
+	words := make([]big.Word, abs.Len)
+	for i := range words {
+		switch abs.stride {
+		case 8:
+			words[i] = big.Word(binary.LittleEndian.Uint64(buf[i*8:]))
+		case 4:
+			words[i] = big.Word(binary.LittleEndian.Uint32(buf[i*4:]))
+		default:
+			return "", fmt.Errorf("unsupported word size %d", abs.stride)
+		}
+	}
+	n := new(big.Int).SetBits(words)
+	if neg != 0 {
+		n.Neg(n)
+	}
+	r := n.String()
+	if len(r) > cfg.MaxStringLen {
+		return fmt.Sprintf("%s...+%d more", r[:cfg.MaxStringLen], len(r)-cfg.MaxStringLen), nil
+	}
+	return r, nil
+}
+
+func displayIP(v *Variable, cfg LoadConfig) (string, error) {
+	buf, err := v.sliceBytes(maxArrayValues(cfg, maxIPBytes))
+	if err != nil {
+		return "", err
+	}
+	return net.IP(buf).String(), nil
+}
+
+func displayIPNet(v *Variable, cfg LoadConfig) (string, error) {
+	ip, err := v.structMember("IP")
+	if err != nil {
+		return "", err
+	}
+	mask, err := v.structMember("Mask")
+	if err != nil {
+		return "", err
+	}
+	ipbuf, err := ip.sliceBytes(maxArrayValues(cfg, maxIPBytes))
+	if err != nil {
+		return "", err
+	}
+	maskbuf, err := mask.sliceBytes(maxArrayValues(cfg, maxIPBytes))
+	if err != nil {
+		return "", err
+	}
+	return (&net.IPNet{IP: net.IP(ipbuf), Mask: net.IPMask(maskbuf)}).String(), nil
+}
+
+// displayMutex reports whether the mutex is locked. The goroutine holding
+// it is not recorded by the runtime: Lock only sets a bit of the state, so
+// the owner can not be displayed.
+func displayMutex(v *Variable, cfg LoadConfig) (string, error) {
+	state, err := v.intMember("state")
+	if err != nil {
+		// newer versions of Go wrap internal/sync.Mutex
+		mu, err2 := v.structMember("mu")
+		if err2 != nil {
+			return "", err
+		}
+		if state, err = mu.intMember("state"); err != nil {
+			return "", err
+		}
+	}
+	if state&1 != 0 {
+		return "locked", nil
+	}
+	return "unlocked", nil
+}
+
+func displayBuffer(v *Variable, cfg LoadConfig) (string, error) {
+	buf, err := v.structMember("buf")
+	if err != nil {
+		return "", err
+	}
+	off, err := v.intMember("off")
+	if err != nil {
+		return "", err
+	}
+	if off < 0 || off > buf.Len {
+		return "", errors.New("invalid offset")
+	}
+	unread := buf.Len - off
+	count := unread
+	if count > int64(cfg.MaxStringLen) {
+		count = int64(cfg.MaxStringLen)
+	}
+	data := make([]byte, count)
+	if count > 0 {
+		if _, err := buf.mem.ReadMemory(data, buf.Base+uintptr(off)); err != nil {
+			return "", err
+		}
+	}
+	if count < unread {
+		return fmt.Sprintf("%q...+%d more", data, unread-count), nil
+	}
+	return fmt.Sprintf("%q", data), nil
+}
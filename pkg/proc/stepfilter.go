@@ -0,0 +1,125 @@
+package proc
+
+import (
+	"debug/gosym"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxSkipFrames is the maximum number of frames that step and stepout will
+// climb looking for a frame not excluded by the step filter.
+const maxSkipFrames = 50
+
+// stepFilter is the compiled form of the lists of package path patterns
+// and file globs passed to SetStepFilter.
+type stepFilter struct {
+	packages []*regexp.Regexp
+	files    []string
+}
+
+// SetStepFilter changes the functions that step and stepout treat as
+// opaque: calls to them are stepped over and when a function returns to one
+// of them execution continues until a frame that is not filtered is
+// reached.
+// A function is filtered if its package path matches one of the patterns
+// in packages, where "..." matches any string as it does for the go tool,
+// or if its file matches one of the globs in files. Globs that do not
+// contain a path separator are matched against the base name of the file.
+func SetStepFilter(p Process, packages, files []string) error {
+	var f stepFilter
+	for _, pattern := range packages {
+		rx, err := packagePatternRegexp(pattern)
+		if err != nil {
+			return err
+		}
+		f.packages = append(f.packages, rx)
+	}
+	for _, glob := range files {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid file glob %q: %v", glob, err)
+		}
+		f.files = append(f.files, glob)
+	}
+	p.Common().stepFilter = f
+	return nil
+}
+
+// packagePatternRegexp converts a package path pattern into a regular
+// expression, following the rules of the go tool: "..." matches any
+// string and a trailing "/..." also matches the package itself.
+func packagePatternRegexp(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty package pattern")
+	}
+	re := regexp.QuoteMeta(pattern)
+	re = strings.Replace(re, `\.\.\.`, `.*`, -1)
+	if strings.HasSuffix(re, `/.*`) {
+		re = re[:len(re)-len(`/.*`)] + `(/.*)?`
+	}
+	return regexp.Compile("^" + re + "$")
+}
+
+// skip returns true if fn, defined in file, should be treated as opaque.
+func (f *stepFilter) skip(fn *gosym.Func, file string) bool {
+	if fn == nil {
+		return false
+	}
+	if len(f.packages) > 0 {
+		pkg := fn.PackageName()
+		for _, rx := range f.packages {
+			if rx.MatchString(pkg) {
+				return true
+			}
+		}
+	}
+	file = filepath.ToSlash(file)
+	for _, glob := range f.files {
+		name := file
+		if !strings.Contains(glob, "/") {
+			name = filepath.Base(file)
+		}
+		if match, _ := filepath.Match(glob, name); match {
+			return true
+		}
+	}
+	return false
+}
+
+// skipStepInto returns true if step should not enter fn.
+func skipStepInto(p Process, fn *gosym.Func) bool {
+	file, _, _ := p.BinInfo().PCToLine(fn.Entry)
+	return p.Common().stepFilter.skip(fn, file)
+}
+
+// unfilteredRetframe returns the return address and the frame that step
+// and stepout should return to when leaving topframe: normally topframe.Ret
+// and retframe, but if the function of retframe is filtered the first
+// frame above it that is not filtered.
+func unfilteredRetframe(p Process, g *G, thread Thread, topframe, retframe Stackframe) (uint64, Stackframe) {
+	f := &p.Common().stepFilter
+	if !f.skip(retframe.Current.Fn, retframe.Current.File) {
+		return topframe.Ret, retframe
+	}
+	var frames []Stackframe
+	var err error
+	if g == nil {
+		frames, err = ThreadStacktrace(thread, maxSkipFrames)
+	} else {
+		frames, err = g.Stacktrace(maxSkipFrames)
+	}
+	if err != nil {
+		return topframe.Ret, retframe
+	}
+	for i := 1; i < len(frames); i++ {
+		fn := frames[i].Current.Fn
+		if fn == nil || fn.Name == "runtime.goexit" {
+			break
+		}
+		if !f.skip(fn, frames[i].Current.File) {
+			return frames[i].Current.PC, frames[i]
+		}
+	}
+	return topframe.Ret, retframe
+}
@@ -4,10 +4,12 @@ import (
 	"debug/gosym"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"go/ast"
 	"go/token"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/derekparker/delve/pkg/dwarf/godwarf"
@@ -33,6 +35,70 @@ type Thread interface {
 	Blocked() bool
 	// SetCurrentBreakpoint updates the current breakpoint of this thread
 	SetCurrentBreakpoint() error
+	// Syscall returns the system call this thread is stopped at or nil if
+	// the thread was not stopped by a syscall catchpoint.
+	Syscall() *Syscall
+	// Signal returns the signal that stopped this thread or nil if the
+	// thread was not stopped by a signal.
+	Signal() *Signal
+	// Common returns the CommonThread structure for this thread
+	Common() *CommonThread
+
+	// SetReg changes the value of the register regNum, regNum is a
+	// x86asm.Reg like the argument of Registers.Get.
+	SetReg(regNum int, value uint64) error
+	// RestoreRegisters restores the general purpose and floating point
+	// registers of the thread to the values in savedRegs, which must have
+	// been returned by a call to Registers(true) on this thread.
+	RestoreRegisters(savedRegs Registers) error
+}
+
+// CommonThread contains fields used by this package, common to all
+// implementations of the Thread interface.
+type CommonThread struct {
+	returnValues []*Variable
+	// condResult is the result of the condition of the breakpoint the
+	// thread is stopped at, when it was evaluated by
+	// evalBreakpointConditionCall
+	condResult *condResult
+}
+
+// ReturnValues returns the values returned by the function that the thread
+// just stepped out of, they are only available immediately after StepOut.
+func (t *CommonThread) ReturnValues(cfg LoadConfig) []*Variable {
+	for _, v := range t.returnValues {
+		v.loadValue(cfg)
+	}
+	return t.returnValues
+}
+
+// Syscall describes the entry or exit of a system call.
+type Syscall struct {
+	Num  uint64
+	Name string
+	// Args contains the arguments of the system call, decoded according to
+	// their type when it is known.
+	Args []string
+	// Exit is true if the thread is stopped after the system call returned,
+	// in which case Ret is its return value and Err the error it returned.
+	Exit bool
+	Ret  int64
+	Err  error
+}
+
+// Signal describes a signal received by a thread.
+type Signal struct {
+	ThreadID int
+	Num      int
+	Name     string
+}
+
+// SignalHandling describes what happens when the target receives a
+// signal. By default signals are passed to the target without stopping it.
+type SignalHandling struct {
+	Stop  bool // stop the target
+	Print bool // report the signal to the user
+	Pass  bool // deliver the signal to the target
 }
 
 // Location represents the location of a thread.
@@ -97,6 +163,9 @@ func topframe(g *G, thread Thread) (Stackframe, Stackframe, error) {
 //   checking that we move to the previous stack frame and stay on the same
 //   goroutine.
 func next(dbp Process, stepInto bool) error {
+	if dbp.GetDirection() == Backward {
+		return nextBackward(dbp, stepInto)
+	}
 	selg := dbp.SelectedGoroutine()
 	curthread := dbp.CurrentThread()
 	topframe, retframe, err := topframe(selg, curthread)
@@ -104,6 +173,11 @@ func next(dbp Process, stepInto bool) error {
 		return err
 	}
 
+	retaddr := topframe.Ret
+	if stepInto {
+		retaddr, retframe = unfilteredRetframe(dbp, selg, curthread, topframe, retframe)
+	}
+
 	success := false
 	defer func() {
 		if !success {
@@ -235,7 +309,7 @@ func next(dbp Process, stepInto bool) error {
 		}
 
 	}
-	if bp, err := dbp.SetBreakpoint(topframe.Ret, NextBreakpoint, retFrameCond); err != nil {
+	if bp, err := dbp.SetBreakpoint(retaddr, NextBreakpoint, retFrameCond); err != nil {
 		if _, isexists := err.(BreakpointExistsError); isexists {
 			if bp.Kind == NextBreakpoint {
 				// If the return address shares the same address with one of the lines
@@ -256,14 +330,188 @@ func next(dbp Process, stepInto bool) error {
 	return nil
 }
 
-func setStepIntoBreakpoint(dbp Process, text []AsmInstruction, cond ast.Expr) error {
-	if len(text) <= 0 {
-		return nil
+// nextBackward is the equivalent of next for targets executing backwards.
+// The following breakpoints will be set:
+// - a breakpoint on each line of the function, with a condition checking
+//   that we stay on the same stack frame and goroutine.
+// - a breakpoint on the CALL instruction that called the current function,
+//   with a condition checking that we move to the previous stack frame and
+//   stay on the same goroutine.
+// If stepInto is true a breakpoint of kind StepBreakpoint is also set on
+// the return address of each CALL instruction of the function, when
+// Continue stops at one of them it steps backwards into the called function.
+func nextBackward(dbp Process, stepInto bool) error {
+	selg := dbp.SelectedGoroutine()
+	curthread := dbp.CurrentThread()
+	topframe, retframe, err := topframe(selg, curthread)
+	if err != nil {
+		return err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			dbp.ClearInternalBreakpoints()
+		}
+	}()
+
+	sameGCond := SameGoroutineCondition(selg)
+	sameFrameCond := andFrameoffCondition(sameGCond, topframe.CFA-int64(topframe.StackHi))
+
+	pcs, err := dbp.BinInfo().lineInfo.AllPCsBetween(topframe.FDE.Begin(), topframe.FDE.End()-1, topframe.Current.File)
+	if err != nil {
+		return err
+	}
+	for _, pc := range pcs {
+		if _, err := dbp.SetBreakpoint(pc, NextBreakpoint, sameFrameCond); err != nil {
+			if _, ok := err.(BreakpointExistsError); !ok {
+				return err
+			}
+		}
+	}
+
+	if stepInto {
+		text, err := Disassemble(dbp, selg, topframe.FDE.Begin(), topframe.FDE.End())
+		if err != nil {
+			return err
+		}
+		for i, instr := range text {
+			if i+1 >= len(text) {
+				continue
+			}
+			if fn := stepIntoCall(instr); fn == nil || skipStepInto(dbp, fn) {
+				continue
+			}
+			// If the return address is also the start of a line the breakpoint
+			// set for the line takes precedence: reaching it means that we are
+			// back at the start of the line.
+			if _, err := dbp.SetBreakpoint(text[i+1].Loc.PC, StepBreakpoint, sameFrameCond); err != nil {
+				if _, ok := err.(BreakpointExistsError); !ok {
+					return err
+				}
+			}
+		}
+	}
+
+	if topframe.Ret != 0 {
+		// The CALL instruction could be wrong, if we can not find it or set a
+		// breakpoint there it's ok.
+		if callpc, err := callInstructionBefore(dbp, topframe.Ret); err == nil {
+			retFrameCond := andFrameoffCondition(sameGCond, retframe.CFA-int64(retframe.StackHi))
+			dbp.SetBreakpoint(callpc, NextBreakpoint, retFrameCond)
+		}
+	}
+
+	if bp, _, _ := curthread.Breakpoint(); bp == nil {
+		curthread.SetCurrentBreakpoint()
+	}
+	success = true
+	return nil
+}
+
+// callInstructionBefore returns the address of the CALL instruction whose
+// return address is ret.
+func callInstructionBefore(dbp Process, ret uint64) (uint64, error) {
+	fn := dbp.BinInfo().PCToFunc(ret)
+	if fn == nil {
+		return 0, fmt.Errorf("could not find function containing %#x", ret)
+	}
+	text, err := Disassemble(dbp, nil, fn.Entry, ret)
+	if err != nil {
+		return 0, err
+	}
+	if len(text) == 0 || !text[len(text)-1].IsCall() {
+		return 0, fmt.Errorf("no CALL instruction before %#x", ret)
+	}
+	return text[len(text)-1].Loc.PC, nil
+}
+
+// stepBackIntoCall steps the selected goroutine backwards into the function
+// called by the previous instruction, if the previous instruction is a CALL
+// that step should enter. Returns true if it did.
+func stepBackIntoCall(dbp Process) (bool, error) {
+	topframe, _, err := topframe(dbp.SelectedGoroutine(), dbp.CurrentThread())
+	if err != nil || topframe.FDE == nil || topframe.Current.PC <= topframe.FDE.Begin() {
+		return false, err
+	}
+	text, err := Disassemble(dbp, dbp.SelectedGoroutine(), topframe.FDE.Begin(), topframe.Current.PC)
+	if err != nil {
+		return false, err
+	}
+	if len(text) == 0 {
+		return false, nil
+	}
+	if fn := stepIntoCall(text[len(text)-1]); fn == nil || skipStepInto(dbp, fn) {
+		return false, nil
+	}
+	return true, dbp.StepInstruction()
+}
+
+// StepTargets returns the functions called by the instructions of the
+// current line that have not been executed yet, in the order in which they
+// appear. Calls whose destination can not be determined statically and
+// calls to unexported runtime functions are not included.
+func StepTargets(dbp Process) ([]*gosym.Func, error) {
+	targets, _, err := stepTargets(dbp)
+	if err != nil {
+		return nil, err
+	}
+	fns := make([]*gosym.Func, len(targets))
+	for i := range targets {
+		fns[i] = targets[i].fn
+	}
+	return fns, nil
+}
+
+// stepTarget is a CALL instruction of the current line and the function it
+// calls.
+type stepTarget struct {
+	fn   *gosym.Func
+	call AsmInstruction
+}
+
+// stepTargets returns the CALL instructions of the current line that have
+// not been executed yet, see StepTargets, and the frame they belong to.
+func stepTargets(dbp Process) ([]stepTarget, Stackframe, error) {
+	selg := dbp.SelectedGoroutine()
+	curthread := dbp.CurrentThread()
+	topframe, _, err := topframe(selg, curthread)
+	if err != nil {
+		return nil, Stackframe{}, err
+	}
+
+	var thread MemoryReadWriter = curthread
+	var regs Registers
+	if selg != nil && selg.Thread != nil {
+		thread = selg.Thread
+		regs, err = selg.Thread.Registers(false)
+		if err != nil {
+			return nil, Stackframe{}, err
+		}
 	}
 
-	instr := text[0]
+	text, err := disassemble(thread, regs, dbp.Breakpoints(), dbp.BinInfo(), topframe.Current.PC, topframe.FDE.End())
+	if err != nil {
+		return nil, Stackframe{}, err
+	}
 
-	if instr.DestLoc == nil || instr.DestLoc.Fn == nil {
+	var targets []stepTarget
+	for _, instr := range text {
+		if instr.Loc.File != topframe.Current.File || instr.Loc.Line != topframe.Current.Line {
+			// only the remaining instructions of the current line are considered
+			break
+		}
+		if fn := stepIntoCall(instr); fn != nil {
+			targets = append(targets, stepTarget{fn, instr})
+		}
+	}
+	return targets, topframe, nil
+}
+
+// stepIntoCall returns the function called by instr, if instr is a CALL
+// instruction to a function that step should enter, nil otherwise.
+func stepIntoCall(instr AsmInstruction) *gosym.Func {
+	if !instr.IsCall() || instr.DestLoc == nil || instr.DestLoc.Fn == nil {
 		return nil
 	}
 
@@ -281,9 +529,54 @@ func setStepIntoBreakpoint(dbp Process, text []AsmInstruction, cond ast.Expr) er
 		return nil
 	}
 
-	//TODO(aarzilli): if we want to let users hide functions
-	// or entire packages from being stepped into with 'step'
-	// those extra checks should be done here.
+	return fn
+}
+
+// findStepTarget returns the call in targets selected by target, which is
+// either an index into targets or the name of a function. Names can omit
+// the package path, as long as that doesn't make them ambiguous, and select
+// the first call to the function.
+func findStepTarget(targets []stepTarget, target string) (*stepTarget, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("no function calls on the current line")
+	}
+	if idx, err := strconv.Atoi(target); err == nil {
+		if idx < 0 || idx >= len(targets) {
+			return nil, fmt.Errorf("step target index %d out of range, the current line has %d function calls", idx, len(targets))
+		}
+		return &targets[idx], nil
+	}
+	var found *stepTarget
+	for i := range targets {
+		fn := targets[i].fn
+		if fn.Name != target && !strings.HasSuffix(fn.Name, "."+target) {
+			continue
+		}
+		if fn.Name == target {
+			return &targets[i], nil
+		}
+		if found != nil && found.fn != fn {
+			return nil, fmt.Errorf("ambiguous step target %q, matches %s and %s", target, found.fn.Name, fn.Name)
+		}
+		if found == nil {
+			found = &targets[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no call to %s on the current line", target)
+	}
+	return found, nil
+}
+
+func setStepIntoBreakpoint(dbp Process, text []AsmInstruction, cond ast.Expr) error {
+	if len(text) <= 0 {
+		return nil
+	}
+
+	fn := stepIntoCall(text[0])
+	if fn == nil || skipStepInto(dbp, fn) {
+		return nil
+	}
 
 	// Set a breakpoint after the function's prologue
 	pc, _ := FirstPCAfterPrologue(dbp, fn, false)
@@ -371,7 +664,7 @@ func ThreadScope(thread Thread) (*EvalScope, error) {
 	if len(locations) < 1 {
 		return nil, errors.New("could not decode first frame")
 	}
-	return &EvalScope{locations[0].Current.PC, locations[0].CFA, thread, nil, thread.BinInfo(), 0}, nil
+	return &EvalScope{locations[0].Current.PC, locations[0].CFA, thread, nil, thread.BinInfo(), 0, nil, nil, &frameRegs{thread: thread}}, nil
 }
 
 // GoroutineScope returns an EvalScope for the goroutine running on this thread.
@@ -387,7 +680,7 @@ func GoroutineScope(thread Thread) (*EvalScope, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &EvalScope{locations[0].Current.PC, locations[0].CFA, thread, g.variable, thread.BinInfo(), g.stackhi}, nil
+	return &EvalScope{locations[0].Current.PC, locations[0].CFA, thread, g.variable, thread.BinInfo(), g.stackhi, nil, nil, &frameRegs{thread: thread}}, nil
 }
 
 func onRuntimeBreakpoint(thread Thread) bool {
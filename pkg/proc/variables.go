@@ -54,6 +54,8 @@ const (
 	// VariableShadowed is set for local variables that are shadowed by a
 	// variable with the same name in another scope
 	VariableShadowed
+	// VariableReturnArgument is set for the return values of a function
+	VariableReturnArgument
 )
 
 // Variable represents a variable. It contains the address, name,
@@ -91,6 +93,15 @@ type Variable struct {
 
 	Children []Variable
 
+	// Display is the human readable representation of values of some
+	// standard library types (time.Time, net.IP...), Children still
+	// contains their fields.
+	Display string
+
+	// For channels, the IDs of the goroutines blocked receiving from and
+	// sending to the channel.
+	RecvWaiting, SendWaiting []int
+
 	loaded     bool
 	Unreadable error
 }
@@ -136,6 +147,7 @@ type G struct {
 	stkbarVar  *Variable // stkbar field of g struct
 	stkbarPos  int       // stkbarPos field of g struct
 	stackhi    uint64    // value of stack.hi
+	stacklo    uint64    // value of stack.lo
 
 	// Information on goroutine location
 	CurrentLoc Location
@@ -155,6 +167,16 @@ type EvalScope struct {
 	Gvar    *Variable
 	BinInfo *BinaryInfo
 	StackHi uint64
+
+	// callCtx is set when function calls are allowed during the evaluation
+	callCtx *callContext
+
+	// ConvVars contains the values of the convenience variables, $N and
+	// $name, if they can be used in the evaluation.
+	ConvVars *ConvenienceVariables
+
+	// regs contains the registers of the evaluation frame, if they are known
+	regs *frameRegs
 }
 
 // IsNilErr is returned when a variable is nil.
@@ -403,11 +425,14 @@ func (gvar *Variable) parseG() (*G, error) {
 	if wrvar := gvar.fieldVariable("waitreason"); wrvar.Value != nil {
 		waitReason = constant.StringVal(wrvar.Value)
 	}
-	var stackhi uint64
+	var stackhi, stacklo uint64
 	if stackVar := gvar.fieldVariable("stack"); stackVar != nil {
 		if stackhiVar := stackVar.fieldVariable("hi"); stackhiVar != nil {
 			stackhi, _ = constant.Uint64Val(stackhiVar.Value)
 		}
+		if stackloVar := stackVar.fieldVariable("lo"); stackloVar != nil {
+			stacklo, _ = constant.Uint64Val(stackloVar.Value)
+		}
 	}
 
 	stkbarVar, _ := gvar.structMember("stkbar")
@@ -431,6 +456,7 @@ func (gvar *Variable) parseG() (*G, error) {
 		stkbarVar:  stkbarVar,
 		stkbarPos:  int(stkbarPos),
 		stackhi:    stackhi,
+		stacklo:    stacklo,
 	}
 	return g, nil
 }
@@ -547,7 +573,7 @@ func (scope *EvalScope) EvalVariable(name string, cfg LoadConfig) (*Variable, er
 
 // SetVariable sets the value of the named variable
 func (scope *EvalScope) SetVariable(name, value string) error {
-	t, err := parser.ParseExpr(name)
+	t, err := ParseExpr(name)
 	if err != nil {
 		return err
 	}
@@ -565,7 +591,7 @@ func (scope *EvalScope) SetVariable(name, value string) error {
 		return fmt.Errorf("Expression \"%s\" is unreadable: %v", name, xv.Unreadable)
 	}
 
-	t, err = parser.ParseExpr(value)
+	t, err = ParseExpr(value)
 	if err != nil {
 		return err
 	}
@@ -748,7 +774,11 @@ func (scope *EvalScope) extractVarInfoFromEntry(entry *dwarf.Entry) (*Variable,
 		return nil, err
 	}
 
-	return scope.newVariable(n, uintptr(addr), t), nil
+	v := scope.newVariable(n, uintptr(addr), t)
+	if isret, _ := entry.Val(dwarf.AttrVarParam).(bool); isret {
+		v.Flags |= VariableReturnArgument
+	}
+	return v, nil
 }
 
 // If v is a pointer a new variable is returned containing the value pointed by v.
@@ -806,6 +836,9 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		v.Children = sv.Children
 		v.Len = sv.Len
 		v.Base = sv.Addr
+		if sv.Addr != 0 && sv.Unreadable == nil {
+			v.loadChanContents(sv, recurseLevel, cfg)
+		}
 
 	case reflect.Map:
 		if recurseLevel <= cfg.MaxVariableRecurse {
@@ -880,6 +913,8 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 	default:
 		v.Unreadable = fmt.Errorf("unknown or unsupported kind: \"%s\"", v.Kind.String())
 	}
+
+	v.loadDisplay(cfg)
 }
 
 func (v *Variable) setValue(y *Variable) error {
@@ -891,7 +926,7 @@ func (v *Variable) setValue(y *Variable) error {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		n, _ := constant.Int64Val(y.Value)
 		err = v.writeUint(uint64(n), v.RealType.Size())
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		n, _ := constant.Uint64Val(y.Value)
 		err = v.writeUint(n, v.RealType.Size())
 	case reflect.Bool:
@@ -1040,6 +1075,107 @@ func (v *Variable) loadArrayValues(recurseLevel int, cfg LoadConfig) {
 	}
 }
 
+// loadChanContents replaces the buf field of hchan, the runtime structure
+// of channel v already loaded in v.Children, with the elements queued in
+// the channel, in the order they will be received, and loads the IDs of the
+// goroutines blocked on the channel.
+func (v *Variable) loadChanContents(hchan *Variable, recurseLevel int, cfg LoadConfig) {
+	for i := range v.Children {
+		if v.Children[i].Name == "buf" {
+			v.Children[i] = *v.chanBuffer(hchan, recurseLevel, cfg)
+			break
+		}
+	}
+	v.RecvWaiting = hchan.waitingGoroutines("recvq", cfg.MaxArrayValues)
+	v.SendWaiting = hchan.waitingGoroutines("sendq", cfg.MaxArrayValues)
+}
+
+// chanBuffer returns the elements queued in the circular buffer of hchan
+// as an array, the first element is the next one to be received.
+func (v *Variable) chanBuffer(hchan *Variable, recurseLevel int, cfg LoadConfig) *Variable {
+	elemType := v.RealType.(*godwarf.ChanType).ElemType
+	buf, err := hchan.structMember("buf")
+	if err != nil {
+		r := v.newVariable("buf", 0, elemType)
+		r.Unreadable = err
+		return r
+	}
+	base := buf.maybeDereference().Addr
+
+	qcount, err := hchan.intMember("qcount")
+	if err != nil {
+		buf.Unreadable = err
+		return buf
+	}
+	dataqsiz, err := hchan.intMember("dataqsiz")
+	if err != nil {
+		buf.Unreadable = err
+		return buf
+	}
+	recvx, err := hchan.intMember("recvx")
+	if err != nil {
+		buf.Unreadable = err
+		return buf
+	}
+	if qcount < 0 || qcount > dataqsiz || recvx < 0 || (dataqsiz > 0 && recvx >= dataqsiz) {
+		buf.Unreadable = fmt.Errorf("inconsistent channel buffer (qcount %d, dataqsiz %d, recvx %d)", qcount, dataqsiz, recvx)
+		return buf
+	}
+
+	elemSize := elemType.Size()
+	typ := &godwarf.ArrayType{CommonType: godwarf.CommonType{ByteSize: qcount * elemSize}, Type: elemType, StrideBitSize: elemSize * 8, Count: qcount}
+	r := v.newVariable("buf", base, typ)
+	r.loaded = true
+
+	if recurseLevel > cfg.MaxVariableRecurse {
+		return r
+	}
+	count := qcount
+	if count > int64(cfg.MaxArrayValues) {
+		count = int64(cfg.MaxArrayValues)
+	}
+	for i := int64(0); i < count; i++ {
+		addr := uintptr(int64(base) + ((recvx+i)%dataqsiz)*elemSize)
+		elem := v.newVariable("", addr, elemType)
+		elem.loadValueInternal(recurseLevel+1, cfg)
+		r.Children = append(r.Children, *elem)
+	}
+	return r
+}
+
+// waitingGoroutines returns the IDs of the goroutines in the wait queue
+// name, recvq or sendq, of hchan, at most max of them.
+func (hchan *Variable) waitingGoroutines(name string, max int) []int {
+	q, err := hchan.structMember(name)
+	if err != nil {
+		return nil
+	}
+	sg, err := q.structMember("first")
+	if err != nil {
+		return nil
+	}
+	var r []int
+	for len(r) < max {
+		sg = sg.maybeDereference()
+		if sg.Addr == 0 || sg.Unreadable != nil {
+			break
+		}
+		g, err := sg.structMember("g")
+		if err != nil {
+			break
+		}
+		if g = g.maybeDereference(); g.Addr != 0 && g.Unreadable == nil {
+			if goid, err := g.intMember("goid"); err == nil {
+				r = append(r, int(goid))
+			}
+		}
+		if sg, err = sg.structMember("next"); err != nil {
+			break
+		}
+	}
+	return r
+}
+
 func (v *Variable) readComplex(size int64) {
 	var fs int64
 	switch size {
@@ -4,11 +4,13 @@ package terminal
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/parser"
 	"go/scanner"
 	"io"
+	"io/ioutil"
 	"math"
 	"os"
 	"reflect"
@@ -18,6 +20,7 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/derekparker/delve/pkg/config"
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
 	"github.com/derekparker/delve/service/debugger"
@@ -29,6 +32,7 @@ const (
 	noPrefix    = cmdPrefix(0)
 	scopePrefix = cmdPrefix(1 << iota)
 	onPrefix
+	revPrefix
 )
 
 type callContext struct {
@@ -89,26 +93,131 @@ Type "help" followed by the name of a command for more information about it.`},
 
 	break [name] <linespec>
 
-See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec.
+See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec. If linespec matches several locations a single breakpoint is set on all of them.
 
 See also: "help on", "help cond" and "help clear"`},
 		{aliases: []string{"trace", "t"}, cmdFn: tracepoint, helpMsg: `Set tracepoint.
 
 	trace [name] <linespec>
+	trace [name] <linespec> "<format>" [<expression> ...]
+	trace [name] <linespec> "<format>"[, <expression>, ...]
 	
 A tracepoint is a breakpoint that does not stop the execution of the program, instead when the tracepoint is hit a notification is displayed. See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec.
 
+If a format string is specified the tracepoint is a logpoint: when it is hit the only notification displayed is the format string, formatted like fmt.Printf would do using the values of the expressions that follow it, evaluated in the scope of the logpoint. Expressions are separated by spaces, an expression containing spaces must be enclosed in parenthesis:
+
+	trace main.go:20 "request %v took %d ms" req.ID (end - start)
+
+Alternatively expressions can be separated by commas, starting with one after the format string:
+
+	trace main.go:20 "request %v took %d ms", req.ID, end - start
+
+See also: "help on", "help cond" and "help clear"`},
+		{aliases: []string{"watch"}, allowedPrefixes: scopePrefix, cmdFn: watchpoint, helpMsg: `Set watchpoint.
+
+	[goroutine <n>] [frame <m>] watch [-r|-w|-rw] <expr>
+
+	-r	stops when the memory location is read
+	-w	stops when the memory location is written
+	-rw	stops when the memory location is read or written
+
+The memory location is the address of the value of <expr>, which is evaluated like the argument of print and must be 1, 2, 4 or 8 bytes long. If no flag is specified the default is -w.
+When the watchpoint is hit the old and new value of <expr> are displayed.
+
+Watchpoints set on stack variables are cleared automatically when the stack frame containing them returns.
+
+Watchpoints use hardware debug registers and are only supported by the native backend on linux/amd64, at most 4 watchpoints can be set at the same time. Because of a hardware limitation -r watchpoints will also stop on writes.
+
 See also: "help on", "help cond" and "help clear"`},
-		{aliases: []string{"restart", "r"}, cmdFn: restart, helpMsg: "Restart process."},
+		{aliases: []string{"catch"}, cmdFn: catchpoint, helpMsg: `Set catchpoint.
+
+	catch panic|throw|exit
+	catch syscall [<name>,...]
+	catch syscall off
+
+	panic	stops when a panic starts, even if it is later recovered
+	throw	stops when the runtime raises a fatal error or a panic is not recovered
+	exit	stops when the program calls os.Exit
+	syscall	stops when a thread enters or exits one of the listed system calls, or any system call if none is listed
+
+The execution stops before the stack is unwound, when the catchpoint is hit the panic value, the error message or the exit code is displayed.
+Catchpoints are named catch-panic, catch-throw and catch-exit, use "clear catch-panic" to remove the catchpoint on panics.
+Syscall catchpoints are only supported by the native backend on Linux, a new "catch syscall" replaces the previous list and "catch syscall off" removes them.`},
+		{aliases: []string{"handle"}, cmdFn: handleSignal, helpMsg: `Change what happens when the program receives a signal.
+
+	handle [<signal> [stop|nostop] [print|noprint] [pass|nopass]]
+
+	stop	stop the program when it receives the signal, implies print
+	print	report the signal at the next stop, without stopping the program
+	pass	deliver the signal to the program
+
+Signals that are not configured are passed to the program without stopping it. When the program is stopped by a signal the signal is displayed, it will be delivered when the program is resumed if the signal is passed.
+Without arguments lists the signals that have been configured, with only a signal displays how it is handled. The configuration is saved in config.yml and applied every time the debugger starts.
+Only supported by the native backend on Linux.`},
+		{aliases: []string{"restart", "r"}, cmdFn: restart, helpMsg: `Restart process.
+
+	restart [-rebuild]
+
+With -rebuild the program is built again, with the same build flags, before restarting it. Only available when the program was started with 'dlv debug' or 'dlv test', breakpoints are set again using the location they were created with.`},
 		{aliases: []string{"continue", "c"}, cmdFn: cont, helpMsg: "Run until breakpoint or program termination."},
-		{aliases: []string{"step", "s"}, allowedPrefixes: scopePrefix, cmdFn: step, helpMsg: "Single step through program."},
-		{aliases: []string{"step-instruction", "si"}, allowedPrefixes: scopePrefix, cmdFn: stepInstruction, helpMsg: "Single step a single cpu instruction."},
-		{aliases: []string{"next", "n"}, allowedPrefixes: scopePrefix, cmdFn: next, helpMsg: "Step over to next source line."},
-		{aliases: []string{"stepout"}, allowedPrefixes: scopePrefix, cmdFn: stepout, helpMsg: "Step out of the current function."},
+		{aliases: []string{"step", "s"}, allowedPrefixes: scopePrefix | revPrefix, cmdFn: step, helpMsg: `Single step through program.
+
+	step [-target [<funcname>|<index>]]
+
+With -target only the selected function call of the current line is stepped into, the other calls are stepped over. The function can be specified by name, the package path can be omitted, or by its index in the list of calls printed by 'step -target' without arguments. Functions excluded by the step filter, see 'help skip', can not be selected.`},
+		{aliases: []string{"step-instruction", "si"}, allowedPrefixes: scopePrefix | revPrefix, cmdFn: stepInstruction, helpMsg: "Single step a single cpu instruction."},
+		{aliases: []string{"next", "n"}, allowedPrefixes: scopePrefix | revPrefix, cmdFn: next, helpMsg: "Step over to next source line."},
+		{aliases: []string{"stepout"}, allowedPrefixes: scopePrefix | revPrefix, cmdFn: stepout, helpMsg: "Step out of the current function."},
+		{aliases: []string{"jump", "j"}, allowedPrefixes: scopePrefix, cmdFn: jump, helpMsg: `Changes the next statement executed by the current goroutine.
+
+	jump <linespec>
+
+The program counter is moved to the start of the specified line, which must be in the current function, the code between the current line and the specified line is not executed. Can be used to skip or repeat statements, jumping into the prologue of the function or to a different function is not allowed.
+See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec.
+Not supported on recordings and core files.`},
+		{aliases: []string{"skip"}, cmdFn: skip, helpMsg: `Changes the functions that step does not stop in.
+
+	skip
+	skip package <pattern>
+	skip file <glob>
+	skip clear [<pattern or glob>]
+
+Step treats the functions of the packages matching pattern, or defined in files matching glob, as opaque: calls to them are stepped over and when step or stepout return into one of them execution continues until a function that is not skipped is reached.
+In package patterns "..." matches any string, for example ".../vendor/..." matches all vendored packages. Globs that do not contain a '/' are matched against the base name of the file.
+Without arguments lists the packages and files being skipped, "skip clear" removes one pattern or glob, or all of them. The list is saved in config.yml.`},
+		{aliases: []string{"call"}, allowedPrefixes: scopePrefix, cmdFn: call, helpMsg: `Resumes process, injecting a function call.
+
+	call [-unsafe] <function call expression>
+
+The function is called on the current goroutine, which must be stopped at a safe point, and its return values are printed. If the function panics the value of the panic is printed instead. Arguments that point to the stack of the current goroutine are rejected, unless -unsafe is specified, since the stack could move during the call.
+Function calls can also be used by print, display and breakpoint conditions when the allow-function-calls option is set in config.yml.
+Requires Go 1.11 or later and the native backend on Linux.`},
 		{aliases: []string{"threads"}, cmdFn: threads, helpMsg: "Print out info for every traced thread."},
 		{aliases: []string{"thread", "tr"}, cmdFn: thread, helpMsg: `Switch to the specified thread.
 
 	thread <id>`},
+		{aliases: []string{"inferiors"}, cmdFn: inferiors, helpMsg: `Print out the processes being debugged.
+
+The current process is marked with '*'. Processes other than the one started or attached to are only debugged after they are forked, if the follow-fork-mode allows it.`},
+		{aliases: []string{"inferior"}, cmdFn: inferior, helpMsg: `Switch to the specified process.
+
+	inferior <pid>`},
+		{aliases: []string{"target"}, cmdFn: target, helpMsg: `Manages the programs being debugged.
+
+	target [list]
+	target switch <id>
+	target attach <pid> [executable]
+	target launch <executable> [arguments...]
+
+All other commands apply to the current target, marked with '*' by target list. Commands that resume execution, like continue, next and step, only resume the current target: the other targets stay stopped. Breakpoints belong to the target they were created in, their IDs are unique across targets.
+Attach and launch add a new target and make it current, the new target inherits the syscall catchpoints, signal handling, step filter and follow-fork-mode of the current target. The executable passed to attach is only needed by the lldb backend on macOS.`},
+		{aliases: []string{"follow-fork-mode"}, cmdFn: followForkMode, helpMsg: `Changes which processes are debugged when the program forks.
+
+	follow-fork-mode [parent|child|both]
+
+With parent, the default, forked children are not debugged. With child the debugger detaches from the parent and follows the child, with both the parent and the child are debugged: they are stopped and resumed together and the process that stopped becomes the current one, see the inferiors command. The program terminates when all the processes being debugged have exited.
+When a followed process calls exec the new program is loaded and the process stops at the exit of the execve system call, with all breakpoints cleared. If the new program can not be debugged the debugger detaches from it.
+Without arguments prints the current mode. Only supported by the native backend on Linux.`},
 		{aliases: []string{"clear"}, cmdFn: clear, helpMsg: `Deletes breakpoint.
 
 	clear <breakpoint name or id>`},
@@ -117,6 +226,29 @@ See also: "help on", "help cond" and "help clear"`},
 	clearall [<linespec>]
 	
 If called with the linespec argument it will delete all the breakpoints matching the linespec. If linespec is omitted all breakpoints are deleted.`},
+		{aliases: []string{"toggle"}, cmdFn: toggleBreakpoint, helpMsg: `Toggles on or off a breakpoint.
+
+	toggle <breakpoint name or id>
+	
+A disabled breakpoint is removed from the target process but keeps its name, conditions, hit counts and the commands set with 'on'.`},
+		{aliases: []string{"enable"}, cmdFn: enableBreakpoint, helpMsg: `Enables a breakpoint.
+
+	enable <breakpoint name or id>`},
+		{aliases: []string{"disable"}, cmdFn: disableBreakpoint, helpMsg: `Disables a breakpoint.
+
+	disable <breakpoint name or id>
+	
+A disabled breakpoint is removed from the target process but keeps its name, conditions, hit counts and the commands set with 'on'. Use 'enable' to set it again.`},
+		{aliases: []string{"bpsave"}, cmdFn: bpsave, helpMsg: `Saves breakpoints to a file.
+
+	bpsave <path>
+	
+Breakpoints are saved with their names, conditions and the commands set with 'on'. Each breakpoint is saved by the location it was created with, for example a function name, or by its file and line if that location was relative to the current position or an address, so that it can be set again with 'bpload' after the program is edited and rebuilt. Watchpoints and breakpoints on addresses without a source line are not saved.`},
+		{aliases: []string{"bpload"}, cmdFn: bpload, helpMsg: `Loads breakpoints from a file.
+
+	bpload <path>
+	
+Sets the breakpoints saved by 'bpsave'. Breakpoints whose location can no longer be found are discarded.`},
 		{aliases: []string{"goroutines"}, cmdFn: goroutines, helpMsg: `List program goroutines.
 
 	goroutines [-u (default: user location)|-r (runtime location)|-g (go statement location)]
@@ -140,17 +272,45 @@ Called with more arguments it will execute a command on the specified goroutine.
 		{aliases: []string{"breakpoints", "bp"}, cmdFn: breakpoints, helpMsg: "Print out info for active breakpoints."},
 		{aliases: []string{"print", "p"}, allowedPrefixes: onPrefix | scopePrefix, cmdFn: printVar, helpMsg: `Evaluate an expression.
 
-	[goroutine <n>] [frame <m>] print <expression>
+	[goroutine <n>] [frame <m>] print [-raw] [%<verb>] <expression>
+
+The value is printed as $N = <value> and appended to the value history, later expressions can refer to it as $N, see the values command.
+Values of the types that have a pretty printer, configured in config.yml, are displayed by it. Values of some standard library types (time.Time, time.Duration, *big.Int, net.IP, net.IPNet, sync.Mutex and bytes.Buffer) are displayed in a human readable form, a sync.Mutex only shows whether it is locked since the runtime does not record its owner.
+With -raw those values are printed as the fields they are made of.
+The verb changes how integers, strings and byte slices, including the ones nested in the value, are printed:
+
+	%x	integers in hexadecimal, strings as hexadecimal digits
+	%o	integers in octal
+	%b	integers in binary
+	%c	integers as the character they encode
+	%q	integers as quoted characters, byte slices and arrays as quoted strings
+	%h	strings, byte slices and arrays as a hexdump
 
 See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/expr.md for a description of supported expressions.`},
 		{aliases: []string{"whatis"}, allowedPrefixes: scopePrefix, cmdFn: whatisCommand, helpMsg: `Prints type of an expression.
 		
 		whatis <expression>.`},
+		{aliases: []string{"chan"}, allowedPrefixes: scopePrefix, cmdFn: chanCommand, helpMsg: `Shows the contents of a channel.
+
+	[goroutine <n>] [frame <m>] chan <expression>
+
+Prints the length and capacity of the channel, whether it is closed, the elements queued in its buffer, starting with the next one to be received, and the goroutines blocked receiving from it or sending to it.`},
 		{aliases: []string{"set"}, allowedPrefixes: scopePrefix, cmdFn: setVar, helpMsg: `Changes the value of a variable.
 
 	[goroutine <n>] [frame <m>] set <variable> = <value>
+	[goroutine <n>] [frame <m>] set $<name> = <expression>
+	[goroutine <n>] set $<register> = <value>
+
+See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/expr.md for a description of supported expressions. Only numerical variables and pointers can be changed.
+The second form saves the value of expression, of any type, in the convenience variable $name, that later expressions can refer to.
+The third form changes one of the 64-bit general purpose registers or the instruction pointer ($rip) of the topmost frame.`},
+		{aliases: []string{"values"}, cmdFn: values, helpMsg: `Lists or clears the value history and the convenience variables.
 
-See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/expr.md for a description of supported expressions. Only numerical variables and pointers can be changed.`},
+	values
+	values clear [$<name>]
+
+Without arguments prints the value history, $1, $2..., and the convenience variables set with "set $<name> = <expression>". Values are snapshots taken when they were printed or set: they do not change when the program modifies the variable they were read from, memory they point to is read when it is used, only while the process they were read from is the current one.
+"values clear" removes the convenience variable $name or, without a name, all convenience variables and the value history.`},
 		{aliases: []string{"sources"}, cmdFn: sources, helpMsg: `Print list of source files.
 
 	sources [<regex>]
@@ -213,6 +373,18 @@ If no argument is specified the function being executed in the selected stack fr
 	
 	-a <start> <end>	disassembles the specified address range
 	-l <locspec>		disassembles the specified function`},
+		{aliases: []string{"examinemem", "x"}, allowedPrefixes: scopePrefix, cmdFn: examineMemCommand, helpMsg: `Examine raw memory.
+
+	[goroutine <n>] [frame <m>] examinemem [-fmt <format>] [-len <count>] [-size <bytes>] <address>
+
+The address is an expression evaluating to an integer or a pointer, the memory it points to is printed as count units of the given size (1, 2, 4 or 8 bytes, 1 by default), 16 units by default, in little endian byte order.
+
+	-fmt <format>	hex (the default), oct, bin or dec
+
+For example:
+
+	x -fmt hex -len 4 -size 8 &buf
+	x -len 32 0xc420010000`},
 		{aliases: []string{"on"}, cmdFn: c.onCmd, helpMsg: `Executes a command when a breakpoint is hit.
 
 	on <breakpoint name or id> <command>.
@@ -221,8 +393,16 @@ Supported commands: print, stack and goroutine)`},
 		{aliases: []string{"condition", "cond"}, cmdFn: conditionCmd, helpMsg: `Set breakpoint condition.
 
 	condition <breakpoint name or id> <boolean expression>.
+	condition -hit <hit condition> <breakpoint name or id>
 	
-Specifies that the breakpoint or tracepoint should break only if the boolean expression is true.`},
+Specifies that the breakpoint or tracepoint should break only if the boolean expression is true.
+
+With -hit the breakpoint will break only if the number of times it has been hit satisfies the hit condition, hits for which the boolean expression is false are not counted. The hit condition is one of:
+
+	== <n>, != <n>, < <n>, <= <n>, > <n>, >= <n>
+	% <n> == 0	(break every <n> hits)
+
+If the hit condition is omitted the hit condition of the breakpoint is removed.`},
 		{aliases: []string{"config"}, cmdFn: configureCmd, helpMsg: `Changes configuration parameters.
 		
 	config -list
@@ -254,6 +434,15 @@ Defines <alias> as an alias to <command> or removes an alias.`},
 			cmdFn:   rewind,
 			helpMsg: "Run backwards until breakpoint or program termination.",
 		})
+		c.cmds = append(c.cmds, command{
+			aliases: []string{"rev"},
+			cmdFn:   c.revCmd,
+			helpMsg: `Reverses the execution of the target program for the command specified.
+
+	rev <command>
+
+The supported commands are next, step, stepout and step-instruction, for example 'rev next' runs backwards to the previous source line.`,
+		})
 		c.cmds = append(c.cmds, command{
 			aliases: []string{"check", "checkpoint"},
 			cmdFn:   checkpoint,
@@ -318,6 +507,16 @@ func (c *Commands) Find(cmdstr string, prefix cmdPrefix) cmdfunc {
 				continue
 			}
 			c.lastCmd = v.cmdFn
+			if prefix != noPrefix {
+				// replaying the command must not drop the prefix, for
+				// example 'rev next' followed by <enter> steps backward
+				// again
+				cmdFn := v.cmdFn
+				c.lastCmd = func(t *Term, ctx callContext, args string) error {
+					ctx.Prefix = prefix
+					return cmdFn(t, ctx, args)
+				}
+			}
 			return v.cmdFn
 		}
 	}
@@ -463,6 +662,124 @@ func thread(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func inferiors(t *Term, ctx callContext, args string) error {
+	inferiors, _, err := t.client.ListInferiors()
+	if err != nil {
+		return err
+	}
+	for _, p := range inferiors {
+		prefix := "  "
+		if p.Current {
+			prefix = "* "
+		}
+		fmt.Printf("%sProcess %d %s\n", prefix, p.Pid, p.Path)
+	}
+	return nil
+}
+
+func inferior(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("you must specify a process")
+	}
+	pid, err := strconv.Atoi(args)
+	if err != nil {
+		return err
+	}
+	oldState, err := t.client.GetState()
+	if err != nil {
+		return err
+	}
+	newState, err := t.client.SwitchInferior(pid)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Switched from %d to %d\n", oldState.Pid, newState.Pid)
+	return nil
+}
+
+func target(t *Term, ctx callContext, args string) error {
+	argv := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	switch argv[0] {
+	case "", "list":
+		targets, err := t.client.ListTargets()
+		if err != nil {
+			return err
+		}
+		for _, tgt := range targets {
+			prefix := "  "
+			if tgt.Current {
+				prefix = "* "
+			}
+			exited := ""
+			if tgt.Exited {
+				exited = " (exited)"
+			}
+			fmt.Printf("%sTarget %d Process %d %s%s\n", prefix, tgt.ID, tgt.Pid, tgt.Path, exited)
+		}
+		return nil
+	case "switch":
+		if len(argv) < 2 {
+			return fmt.Errorf("you must specify a target")
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(argv[1]))
+		if err != nil {
+			return err
+		}
+		state, err := t.client.SwitchTarget(id)
+		if err != nil {
+			return err
+		}
+		t.lastPid = state.Pid
+		fmt.Printf("Switched to target %d\n", state.TargetID)
+		return printcontext(t, state)
+	case "attach":
+		if len(argv) < 2 {
+			return fmt.Errorf("you must specify a pid")
+		}
+		attachArgs := strings.Fields(argv[1])
+		pid, err := strconv.Atoi(attachArgs[0])
+		if err != nil {
+			return err
+		}
+		path := ""
+		if len(attachArgs) > 1 {
+			path = attachArgs[1]
+		}
+		tgt, err := t.client.AttachTarget(pid, path)
+		if err != nil {
+			return err
+		}
+		t.lastPid = tgt.Pid
+		fmt.Printf("Target %d Process %d %s\n", tgt.ID, tgt.Pid, tgt.Path)
+		return nil
+	case "launch":
+		if len(argv) < 2 {
+			return fmt.Errorf("you must specify an executable")
+		}
+		tgt, err := t.client.LaunchTarget(config.SplitQuotedFields(argv[1], '"'), "")
+		if err != nil {
+			return err
+		}
+		t.lastPid = tgt.Pid
+		fmt.Printf("Target %d Process %d %s\n", tgt.ID, tgt.Pid, tgt.Path)
+		return nil
+	default:
+		return fmt.Errorf("unknown target command %q", argv[0])
+	}
+}
+
+func followForkMode(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		_, mode, err := t.client.ListInferiors()
+		if err != nil {
+			return err
+		}
+		fmt.Println(mode)
+		return nil
+	}
+	return t.client.SetFollowForkMode(args)
+}
+
 type byGoroutineID []*api.Goroutine
 
 func (a byGoroutineID) Len() int           { return len(a) }
@@ -581,6 +898,14 @@ func (c *Commands) frame(t *Term, ctx callContext, args string) error {
 	return c.CallWithContext(v[1], t, ctx)
 }
 
+func (c *Commands) revCmd(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return errors.New("not enough arguments")
+	}
+	ctx.Prefix = revPrefix
+	return c.CallWithContext(args, t, ctx)
+}
+
 func printscope(t *Term) error {
 	state, err := t.client.GetState()
 	if err != nil {
@@ -650,7 +975,13 @@ func writeGoroutineLong(w io.Writer, g *api.Goroutine, prefix string) {
 }
 
 func restart(t *Term, ctx callContext, args string) error {
-	discarded, err := t.client.RestartFrom(args)
+	var discarded []api.DiscardedBreakpoint
+	var err error
+	if args == "-rebuild" {
+		discarded, err = t.client.RestartRebuild()
+	} else {
+		discarded, err = t.client.RestartFrom(args)
+	}
 	if err != nil {
 		return err
 	}
@@ -691,13 +1022,21 @@ func cont(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
-func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string) error {
+func continueUntilCompleteNext(t *Term, ctx callContext, state *api.DebuggerState, op string) error {
 	if !state.NextInProgress {
 		printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
 		return nil
 	}
+	if ctx.Prefix == revPrefix {
+		op = "rev " + op
+	}
 	for {
-		stateChan := t.client.Continue()
+		var stateChan <-chan *api.DebuggerState
+		if ctx.Prefix == revPrefix {
+			stateChan = t.client.Rewind()
+		} else {
+			stateChan = t.client.Continue()
+		}
 		var state *api.DebuggerState
 		for state = range stateChan {
 			if state.Err != nil {
@@ -734,20 +1073,56 @@ func step(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := t.client.Step()
+	var state *api.DebuggerState
+	var err error
+	switch v := strings.Fields(args); {
+	case len(v) == 0 && ctx.Prefix == revPrefix:
+		state, err = t.client.ReverseStep()
+	case len(v) == 0:
+		state, err = t.client.Step()
+	case ctx.Prefix == revPrefix:
+		return errors.New("-target can not be used with rev")
+	case v[0] != "-target" || len(v) > 2:
+		return fmt.Errorf("wrong arguments")
+	case len(v) == 1:
+		return printStepTargets(t)
+	default:
+		state, err = t.client.StepTo(v[1])
+	}
 	if err != nil {
 		printfileNoState(t)
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "step")
+	return continueUntilCompleteNext(t, ctx, state, "step")
+}
+
+func printStepTargets(t *Term) error {
+	targets, err := t.client.StepTargets()
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		fmt.Println("No function calls on the current line.")
+		return nil
+	}
+	for i := range targets {
+		fmt.Printf("%d\t%s\n", i, targets[i].Name)
+	}
+	return nil
 }
 
 func stepInstruction(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := t.client.StepInstruction()
+	var state *api.DebuggerState
+	var err error
+	if ctx.Prefix == revPrefix {
+		state, err = t.client.ReverseStepInstruction()
+	} else {
+		state, err = t.client.StepInstruction()
+	}
 	if err != nil {
 		printfileNoState(t)
 		return err
@@ -761,26 +1136,77 @@ func next(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := t.client.Next()
+	var state *api.DebuggerState
+	var err error
+	if ctx.Prefix == revPrefix {
+		state, err = t.client.ReverseNext()
+	} else {
+		state, err = t.client.Next()
+	}
 	if err != nil {
 		printfileNoState(t)
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "next")
+	return continueUntilCompleteNext(t, ctx, state, "next")
 }
 
 func stepout(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := t.client.StepOut()
+	var state *api.DebuggerState
+	var err error
+	if ctx.Prefix == revPrefix {
+		state, err = t.client.ReverseStepOut()
+	} else {
+		state, err = t.client.StepOut()
+	}
+	if err != nil {
+		printfileNoState(t)
+		return err
+	}
+	printcontext(t, state)
+	return continueUntilCompleteNext(t, ctx, state, "stepout")
+}
+
+func call(t *Term, ctx callContext, args string) error {
+	if err := scopePrefixSwitch(t, ctx); err != nil {
+		return err
+	}
+	const unsafePrefix = "-unsafe "
+	unsafe := false
+	if strings.HasPrefix(args, unsafePrefix) {
+		unsafe = true
+		args = strings.TrimSpace(args[len(unsafePrefix):])
+	}
+	if args == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	state, err := t.client.Call(args, unsafe)
 	if err != nil {
 		printfileNoState(t)
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "stepout")
+	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+	return nil
+}
+
+func jump(t *Term, ctx callContext, args string) error {
+	if err := scopePrefixSwitch(t, ctx); err != nil {
+		return err
+	}
+	if args == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	state, err := t.client.Jump(args)
+	if err != nil {
+		return err
+	}
+	printcontext(t, state)
+	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+	return nil
 }
 
 func clear(t *Term, ctx callContext, args string) error {
@@ -797,7 +1223,145 @@ func clear(t *Term, ctx callContext, args string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s cleared at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	fmt.Printf("%s cleared at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	return nil
+}
+
+func toggleBreakpoint(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	bp, err := getBreakpointByIDOrName(t, args)
+	if err != nil {
+		return err
+	}
+	return setBreakpointDisabled(t, bp, !bp.Disabled)
+}
+
+func enableBreakpoint(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	bp, err := getBreakpointByIDOrName(t, args)
+	if err != nil {
+		return err
+	}
+	return setBreakpointDisabled(t, bp, false)
+}
+
+func disableBreakpoint(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	bp, err := getBreakpointByIDOrName(t, args)
+	if err != nil {
+		return err
+	}
+	return setBreakpointDisabled(t, bp, true)
+}
+
+func setBreakpointDisabled(t *Term, bp *api.Breakpoint, disabled bool) error {
+	bp.Disabled = disabled
+	if err := t.client.AmendBreakpoint(bp); err != nil {
+		return err
+	}
+	what := "enabled"
+	if disabled {
+		what = "disabled"
+	}
+	fmt.Printf("%s %s at %s\n", formatBreakpointName(bp, true), what, formatBreakpointLocation(bp))
+	return nil
+}
+
+// savedBreakpoint is the format used by bpsave and bpload.
+type savedBreakpoint struct {
+	// Location is the location spec used to set the breakpoint again.
+	Location string
+	api.Breakpoint
+}
+
+func bpsave(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	breakPoints, err := t.client.ListBreakpoints()
+	if err != nil {
+		return err
+	}
+	sort.Sort(ByID(breakPoints))
+
+	saved := []savedBreakpoint{}
+	for _, bp := range breakPoints {
+		if bp.ID < 0 {
+			continue
+		}
+		if bp.WatchType != 0 {
+			fmt.Printf("Discarded %s at %s: watchpoints can not be saved\n", formatBreakpointName(bp, false), formatBreakpointLocation(bp))
+			continue
+		}
+		// the location spec the breakpoint was created from follows the code
+		// it refers to when the program is edited and rebuilt, file:line is
+		// only used when there is no such spec
+		sbp := savedBreakpoint{Breakpoint: *bp}
+		switch {
+		case debugger.IsAbsoluteLocationSpec(bp.LocationSpec):
+			sbp.Location = bp.LocationSpec
+		case bp.File != "":
+			sbp.Location = fmt.Sprintf("%s:%d", bp.File, bp.Line)
+		default:
+			fmt.Printf("Discarded %s at %s: address breakpoints can not be saved\n", formatBreakpointName(bp, false), formatBreakpointLocation(bp))
+			continue
+		}
+		sbp.HitCount = nil
+		sbp.TotalHitCount = 0
+		saved = append(saved, sbp)
+	}
+
+	buf, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(args, buf, 0666); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %d breakpoints to %s\n", len(saved), args)
+	return nil
+}
+
+func bpload(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	buf, err := ioutil.ReadFile(args)
+	if err != nil {
+		return err
+	}
+	var saved []savedBreakpoint
+	if err := json.Unmarshal(buf, &saved); err != nil {
+		return fmt.Errorf("could not read breakpoints from %s: %v", args, err)
+	}
+
+	discarded := []api.DiscardedBreakpoint{}
+	for i := range saved {
+		requestedBp := &saved[i].Breakpoint
+		locs, err := t.client.FindLocation(api.EvalScope{GoroutineID: -1, Frame: 0}, saved[i].Location)
+		if err != nil {
+			discarded = append(discarded, api.DiscardedBreakpoint{requestedBp, err.Error()})
+			continue
+		}
+		requestedBp.Addrs = debugger.LocationAddrs(locs)
+		requestedBp.Addr = requestedBp.Addrs[0]
+		requestedBp.LocationSpec = saved[i].Location
+		bp, err := t.client.CreateBreakpoint(requestedBp)
+		if err != nil {
+			discarded = append(discarded, api.DiscardedBreakpoint{requestedBp, err.Error()})
+			continue
+		}
+		fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	}
+	for i := range discarded {
+		fmt.Printf("Discarded %s at %s: %v\n", formatBreakpointName(discarded[i].Breakpoint, false), formatBreakpointLocation(discarded[i].Breakpoint), discarded[i].Reason)
+	}
 	return nil
 }
 
@@ -816,12 +1380,22 @@ func clearAll(t *Term, ctx callContext, args string) error {
 		locPCs = make(map[uint64]struct{})
 		for _, loc := range locs {
 			locPCs[loc.PC] = struct{}{}
+			for _, pc := range loc.PCs {
+				locPCs[pc] = struct{}{}
+			}
 		}
 	}
 
 	for _, bp := range breakPoints {
 		if locPCs != nil {
-			if _, ok := locPCs[bp.Addr]; !ok {
+			found := false
+			for _, addr := range bp.Addrs {
+				if _, ok := locPCs[addr]; ok {
+					found = true
+					break
+				}
+			}
+			if !found {
 				continue
 			}
 		}
@@ -853,12 +1427,19 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 	}
 	sort.Sort(ByID(breakPoints))
 	for _, bp := range breakPoints {
-		fmt.Printf("%s at %v (%d)\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp), bp.TotalHitCount)
+		disabled := ""
+		if bp.Disabled {
+			disabled = " (disabled)"
+		}
+		fmt.Printf("%s%s at %v (%d)\n", formatBreakpointName(bp, true), disabled, formatBreakpointLocation(bp), bp.TotalHitCount)
 
 		var attrs []string
 		if bp.Cond != "" {
 			attrs = append(attrs, fmt.Sprintf("\tcond %s", bp.Cond))
 		}
+		if bp.HitCond != "" {
+			attrs = append(attrs, fmt.Sprintf("\tcond -hit %s", bp.HitCond))
+		}
 		if bp.Stacktrace > 0 {
 			attrs = append(attrs, fmt.Sprintf("\tstack %d", bp.Stacktrace))
 		}
@@ -879,8 +1460,12 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 				attrs = append(attrs, "\tlocals")
 			}
 		}
-		for i := range bp.Variables {
-			attrs = append(attrs, fmt.Sprintf("\tprint %s", bp.Variables[i]))
+		if bp.LogMessage != "" {
+			attrs = append(attrs, "\t"+strings.TrimSpace(fmt.Sprintf("log %q %s", bp.LogMessage, strings.Join(bp.Variables, " "))))
+		} else {
+			for i := range bp.Variables {
+				attrs = append(attrs, fmt.Sprintf("\tprint %s", bp.Variables[i]))
+			}
 		}
 		if len(attrs) > 0 {
 			fmt.Printf("%s\n", strings.Join(attrs, "\n"))
@@ -893,6 +1478,15 @@ func setBreakpoint(t *Term, tracepoint bool, argstr string) error {
 	args := strings.SplitN(argstr, " ", 2)
 
 	requestedBp := &api.Breakpoint{}
+	if i := strings.Index(argstr, "\""); tracepoint && i >= 0 {
+		var err error
+		requestedBp.LogMessage, requestedBp.Variables, err = parseLogMessage(argstr[i:])
+		if err != nil {
+			return err
+		}
+		argstr = strings.TrimSpace(argstr[:i])
+		args = strings.SplitN(argstr, " ", 2)
+	}
 	locspec := ""
 	switch len(args) {
 	case 1:
@@ -922,19 +1516,310 @@ func setBreakpoint(t *Term, tracepoint bool, argstr string) error {
 			return err
 		}
 	}
-	for _, loc := range locs {
-		requestedBp.Addr = loc.PC
+	// a single logical breakpoint is set on all the locations
+	requestedBp.LocationSpec = locspec
+	requestedBp.Addrs = debugger.LocationAddrs(locs)
+	requestedBp.Addr = requestedBp.Addrs[0]
+	bp, err := t.client.CreateBreakpoint(requestedBp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	return nil
+}
 
-		bp, err := t.client.CreateBreakpoint(requestedBp)
-		if err != nil {
-			return err
+// parseLogMessage parses the quoted format string of a logpoint and the
+// list of expressions that follows it. Expressions are separated by spaces
+// or, if the list starts with a comma, by commas.
+func parseLogMessage(s string) (string, []string, error) {
+	end := -1
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return "", nil, errors.New("unterminated format string")
+	}
+	msg, err := strconv.Unquote(s[:end+1])
+	if err != nil {
+		return "", nil, fmt.Errorf("malformed format string: %v", err)
+	}
+	rest := strings.TrimSpace(s[end+1:])
+	if rest == "" {
+		return msg, nil, nil
+	}
+	var exprs []string
+	if rest[0] == ',' {
+		exprs, err = splitExpressions(rest[1:], ',')
+	} else if end+1 < len(s) && s[end+1] == ' ' {
+		exprs, err = splitExpressions(rest, ' ')
+	} else {
+		err = errors.New("expressions must be separated from the format string by a space")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return msg, exprs, nil
+}
+
+// splitExpressions splits s on the occurrences of sep, a comma or a space,
+// that are not inside brackets or string and character literals. When sep
+// is a space consecutive spaces count as one.
+func splitExpressions(s string, sep byte) ([]string, error) {
+	var exprs []string
+	var stack []byte
+	var quote byte
+	start := 0
+	add := func(end int) error {
+		expr := strings.TrimSpace(s[start:end])
+		if expr == "" {
+			return errors.New("empty expression")
+		}
+		exprs = append(exprs, expr)
+		return nil
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch {
+			case c == '\\' && quote != '`':
+				i++
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '(', '[', '{':
+			stack = append(stack, c)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != "([{"[strings.IndexByte(")]}", c)] {
+				return nil, fmt.Errorf("unbalanced %q", c)
+			}
+			stack = stack[:len(stack)-1]
+		case sep:
+			if len(stack) == 0 {
+				if sep == ' ' && strings.TrimSpace(s[start:i]) == "" {
+					start = i + 1
+					continue
+				}
+				if err := add(i); err != nil {
+					return nil, err
+				}
+				start = i + 1
+			}
 		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated literal")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unbalanced %q", stack[len(stack)-1])
+	}
+	if err := add(len(s)); err != nil {
+		return nil, err
+	}
+	return exprs, nil
+}
 
-		fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+func watchpoint(t *Term, ctx callContext, args string) error {
+	v := strings.SplitN(args, " ", 2)
+	wtype := api.WatchWrite
+	switch v[0] {
+	case "-r":
+		wtype = api.WatchRead
+	case "-w":
+		wtype = api.WatchWrite
+	case "-rw":
+		wtype = api.WatchRead | api.WatchWrite
+	default:
+		v = []string{"-w", args}
+	}
+	if len(v) < 2 || strings.TrimSpace(v[1]) == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	bp, err := t.client.CreateWatchpoint(ctx.Scope, strings.TrimSpace(v[1]), wtype)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	return nil
+}
+
+func catchpoint(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	v := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	if v[0] == "syscall" {
+		return catchSyscalls(t, v[1:])
+	}
+	bp, err := t.client.CreateCatchpoint(strings.TrimSpace(args))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	return nil
+}
+
+func catchSyscalls(t *Term, args []string) error {
+	var names []string
+	if len(args) > 0 {
+		arg := strings.TrimSpace(args[0])
+		if arg == "off" {
+			return t.client.CatchSyscalls(nil, false)
+		}
+		for _, name := range strings.Split(arg, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if err := t.client.CatchSyscalls(names, true); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("Catching all system calls")
+	} else {
+		fmt.Printf("Catching system calls %s\n", strings.Join(names, ", "))
 	}
 	return nil
 }
 
+func handleSignal(t *Term, ctx callContext, args string) error {
+	if t.conf == nil {
+		t.conf = &config.Config{}
+	}
+	argv := strings.Fields(args)
+	if len(argv) == 0 {
+		names := make([]string, 0, len(t.conf.Handle))
+		for name := range t.conf.Handle {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w := new(tabwriter.Writer)
+		w.Init(os.Stdout, 0, 8, 1, ' ', 0)
+		fmt.Fprintln(w, "Signal\tStop\tPrint\tPass")
+		for _, name := range names {
+			h := t.conf.Handle[name]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, yesno(h.Stop), yesno(h.Print), yesno(h.Pass))
+		}
+		return w.Flush()
+	}
+
+	name := strings.ToUpper(argv[0])
+	if _, err := strconv.Atoi(name); err != nil && !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	h, ok := t.conf.Handle[name]
+	if !ok {
+		h = config.SignalHandling{Pass: true}
+	}
+	if len(argv) == 1 {
+		fmt.Printf("%s: stop %s, print %s, pass %s\n", name, yesno(h.Stop), yesno(h.Print), yesno(h.Pass))
+		return nil
+	}
+	for _, arg := range argv[1:] {
+		switch arg {
+		case "stop":
+			h.Stop, h.Print = true, true
+		case "nostop":
+			h.Stop = false
+		case "print":
+			h.Print = true
+		case "noprint":
+			h.Stop, h.Print = false, false
+		case "pass":
+			h.Pass = true
+		case "nopass":
+			h.Pass = false
+		default:
+			return fmt.Errorf("unknown argument %q", arg)
+		}
+	}
+	if err := t.client.SetSignalHandling(name, h.Stop, h.Print, h.Pass); err != nil {
+		return err
+	}
+	if t.conf.Handle == nil {
+		t.conf.Handle = make(map[string]config.SignalHandling)
+	}
+	t.conf.Handle[name] = h
+	fmt.Printf("%s: stop %s, print %s, pass %s\n", name, yesno(h.Stop), yesno(h.Print), yesno(h.Pass))
+	return t.saveConfig()
+}
+
+func skip(t *Term, ctx callContext, args string) error {
+	if t.conf == nil {
+		t.conf = &config.Config{}
+	}
+	argv := strings.Fields(args)
+	if len(argv) == 0 {
+		for _, pattern := range t.conf.SkipPackages {
+			fmt.Printf("package %s\n", pattern)
+		}
+		for _, glob := range t.conf.SkipFiles {
+			fmt.Printf("file %s\n", glob)
+		}
+		return nil
+	}
+
+	packages, files := t.conf.SkipPackages, t.conf.SkipFiles
+	switch {
+	case argv[0] == "package" && len(argv) == 2:
+		packages = append(packages[:len(packages):len(packages)], argv[1])
+	case argv[0] == "file" && len(argv) == 2:
+		files = append(files[:len(files):len(files)], argv[1])
+	case argv[0] == "clear" && len(argv) == 1:
+		packages, files = nil, nil
+	case argv[0] == "clear" && len(argv) == 2:
+		packages, files = removeString(packages, argv[1]), removeString(files, argv[1])
+		if len(packages) == len(t.conf.SkipPackages) && len(files) == len(t.conf.SkipFiles) {
+			return fmt.Errorf("%s is not being skipped", argv[1])
+		}
+	default:
+		return fmt.Errorf("wrong arguments")
+	}
+
+	if err := t.client.SetStepFilter(packages, files); err != nil {
+		return err
+	}
+	t.conf.SkipPackages, t.conf.SkipFiles = packages, files
+	return t.saveConfig()
+}
+
+// removeString returns a copy of v without the elements equal to s.
+func removeString(v []string, s string) []string {
+	var r []string
+	for _, x := range v {
+		if x != s {
+			r = append(r, x)
+		}
+	}
+	return r
+}
+
+func yesno(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// catchpointLabels is used to display the variable of a catchpoint.
+var catchpointLabels = map[string]string{
+	api.CatchpointPrefix + "panic": "panic",
+	api.CatchpointPrefix + "throw": "fatal error",
+	api.CatchpointPrefix + "exit":  "exit code",
+}
+
 func breakpoint(t *Term, ctx callContext, args string) error {
 	return setBreakpoint(t, false, args)
 }
@@ -944,22 +1829,73 @@ func tracepoint(t *Term, ctx callContext, args string) error {
 }
 
 func printVar(t *Term, ctx callContext, args string) error {
+	raw := false
+	var verb rune
+	for {
+		switch {
+		case strings.HasPrefix(args, "-raw "):
+			raw = true
+			args = strings.TrimSpace(args[len("-raw "):])
+			continue
+		case strings.HasPrefix(args, "%"):
+			v := strings.SplitN(args, " ", 2)
+			if len(v[0]) != 2 || !strings.ContainsRune(api.FormatVerbs, rune(v[0][1])) {
+				return fmt.Errorf("unknown format %s", v[0])
+			}
+			verb = rune(v[0][1])
+			args = ""
+			if len(v) > 1 {
+				args = strings.TrimSpace(v[1])
+			}
+			continue
+		}
+		break
+	}
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
 	}
 	if ctx.Prefix == onPrefix {
+		if raw || verb != 0 {
+			return fmt.Errorf("-raw and format verbs can not be used with on")
+		}
 		ctx.Breakpoint.Variables = append(ctx.Breakpoint.Variables, args)
 		return nil
 	}
-	val, err := t.client.EvalVariable(ctx.Scope, args, t.loadConfig())
+	val, n, err := t.client.EvalAndRecord(ctx.Scope, args, t.loadConfig())
 	if err != nil {
 		return err
 	}
+	if raw {
+		val.ClearDisplay()
+	}
 
-	fmt.Println(val.MultilineString(""))
+	fmt.Printf("$%d = %s\n", n, val.MultilineStringFormat("", verb))
 	return nil
 }
 
+func values(t *Term, ctx callContext, args string) error {
+	argv := strings.Fields(args)
+	switch {
+	case len(argv) == 0:
+		vars, err := t.client.ListConvenienceVariables()
+		if err != nil {
+			return err
+		}
+		for _, v := range vars {
+			fmt.Printf("%s = %s\n", v.Name, v.SinglelineString())
+		}
+		return nil
+	case argv[0] == "clear" && len(argv) <= 2:
+		name := ""
+		if len(argv) == 2 {
+			name = argv[1]
+		}
+		return t.client.ClearConvenienceVariables(name)
+	default:
+		return fmt.Errorf("wrong arguments")
+	}
+}
+
 func whatisCommand(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -980,9 +1916,69 @@ func whatisCommand(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func chanCommand(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	val, err := t.client.EvalVariable(ctx.Scope, args, t.loadConfig())
+	if err != nil {
+		return err
+	}
+	if val.Kind != reflect.Chan {
+		return fmt.Errorf("%s is not a channel", args)
+	}
+	if len(val.Children) == 0 {
+		fmt.Printf("%s nil\n", val.Type)
+		return nil
+	}
+	field := func(name string) *api.Variable {
+		for i := range val.Children {
+			if val.Children[i].Name == name {
+				return &val.Children[i]
+			}
+		}
+		return &api.Variable{}
+	}
+
+	fmt.Printf("%s len: %s, cap: %s", val.Type, field("qcount").Value, field("dataqsiz").Value)
+	if closed := field("closed").Value; closed != "" && closed != "0" {
+		fmt.Print(", closed")
+	}
+	fmt.Println()
+
+	buf := field("buf")
+	switch {
+	case buf.Unreadable != "":
+		fmt.Printf("Buffer: (unreadable %s)\n", buf.Unreadable)
+	case buf.Len > 0:
+		fmt.Println("Buffer:")
+		for i := range buf.Children {
+			fmt.Printf("\t%d: %s\n", i, buf.Children[i].MultilineString("\t"))
+		}
+		if int64(len(buf.Children)) < buf.Len {
+			fmt.Printf("\t...+%d more\n", buf.Len-int64(len(buf.Children)))
+		}
+	}
+
+	printWaiting := func(what string, ids []int) {
+		if len(ids) == 0 {
+			return
+		}
+		strs := make([]string, len(ids))
+		for i := range ids {
+			strs[i] = strconv.Itoa(ids[i])
+		}
+		fmt.Printf("Goroutines blocked %s: %s\n", what, strings.Join(strs, " "))
+	}
+	printWaiting("receiving", val.RecvWaiting)
+	printWaiting("sending", val.SendWaiting)
+	return nil
+}
+
 func setVar(t *Term, ctx callContext, args string) error {
 	// HACK: in go '=' is not an operator, we detect the error and try to recover from it by splitting the input string
-	_, err := parser.ParseExpr(args)
+	// '$' is replaced with a character of the same length that can appear in identifiers, so that convenience variables parse
+	_, err := parser.ParseExpr(strings.Replace(args, "$", "_", -1))
 	if err == nil {
 		return fmt.Errorf("syntax error '=' not found")
 	}
@@ -1268,6 +2264,99 @@ func disassCommand(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+var examineMemUsageError = errors.New("wrong arguments: examinemem [-fmt <format>] [-len <count>] [-size <bytes>] <address>")
+
+// examineMemArgs are the arguments of the examinemem command.
+type examineMemArgs struct {
+	format string
+	count  int
+	size   int
+	expr   string
+}
+
+func parseExamineMemArgs(args string) (examineMemArgs, error) {
+	r := examineMemArgs{format: "hex", count: 16, size: 1}
+	argv := strings.Fields(args)
+	for len(argv) >= 2 && strings.HasPrefix(argv[0], "-") {
+		switch argv[0] {
+		case "-fmt":
+			switch argv[1] {
+			case "hex", "oct", "bin", "dec":
+				r.format = argv[1]
+			default:
+				return r, fmt.Errorf("unknown format %q", argv[1])
+			}
+		case "-len", "-size":
+			n, err := strconv.Atoi(argv[1])
+			if err != nil || n <= 0 {
+				return r, fmt.Errorf("wrong argument: %s is not a positive number", argv[1])
+			}
+			if argv[0] == "-len" {
+				r.count = n
+			} else {
+				r.size = n
+			}
+		default:
+			return r, examineMemUsageError
+		}
+		argv = argv[2:]
+	}
+	switch r.size {
+	case 1, 2, 4, 8:
+	default:
+		return r, fmt.Errorf("wrong size %d: must be 1, 2, 4 or 8", r.size)
+	}
+	if len(argv) == 0 {
+		return r, examineMemUsageError
+	}
+	r.expr = strings.Join(argv, " ")
+	return r, nil
+}
+
+func examineMemCommand(t *Term, ctx callContext, args string) error {
+	xargs, err := parseExamineMemArgs(args)
+	if err != nil {
+		return err
+	}
+	addr, mem, err := t.client.ExamineMemory(ctx.Scope, xargs.expr, xargs.count*xargs.size)
+	if err != nil {
+		return err
+	}
+	printMemory(os.Stdout, addr, mem, xargs.format, xargs.size)
+	return nil
+}
+
+// printMemory prints mem, read at addr, as little endian units of size
+// bytes in the given format, 16 bytes per line.
+func printMemory(w io.Writer, addr uint64, mem []byte, format string, size int) {
+	formatUnit := func(n uint64) string {
+		switch format {
+		case "oct":
+			return fmt.Sprintf("%#o", n)
+		case "bin":
+			return fmt.Sprintf("0b%0*b", size*8, n)
+		case "dec":
+			return strconv.FormatUint(n, 10)
+		default:
+			return fmt.Sprintf("0x%0*x", size*2, n)
+		}
+	}
+	width := len(formatUnit(^uint64(0) >> uint(64-size*8)))
+
+	perLine := 16 / size
+	for i := 0; i+size <= len(mem); i += perLine * size {
+		fmt.Fprintf(w, "%#x:", addr+uint64(i))
+		for j := i; j < i+perLine*size && j+size <= len(mem); j += size {
+			var n uint64
+			for k := size - 1; k >= 0; k-- {
+				n = n<<8 | uint64(mem[j+k])
+			}
+			fmt.Fprintf(w, "   %*s", width, formatUnit(n))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
 func digits(n int) int {
 	if n <= 0 {
 		return 1
@@ -1314,10 +2403,25 @@ func printcontext(t *Term, state *api.DebuggerState) error {
 		}
 	}
 
+	if t.lastPid != 0 && state.Pid != 0 && state.Pid != t.lastPid {
+		fmt.Printf("Switched to process %d\n", state.Pid)
+	}
+	t.lastPid = state.Pid
+
 	if state.CurrentThread == nil {
 		fmt.Println("No current thread available")
 		return nil
 	}
+	for _, sig := range state.SignalsReceived {
+		fmt.Printf("Thread %d received signal %s\n", sig.ThreadID, sig.Name)
+	}
+	if state.Signal != nil {
+		fmt.Printf("Thread %d stopped by signal %s\n", state.Signal.ThreadID, state.Signal.Name)
+	}
+	if state.Syscall != nil {
+		fmt.Println(formatSyscall(state.CurrentThread.ID, state.Syscall))
+	}
+
 	if len(state.CurrentThread.File) == 0 {
 		fmt.Printf("Stopped at: 0x%x\n", state.CurrentThread.PC)
 		t.Println("=>", "no source available")
@@ -1326,6 +2430,10 @@ func printcontext(t *Term, state *api.DebuggerState) error {
 
 	printcontextThread(t, state.CurrentThread)
 
+	for _, wp := range state.WatchOutOfScope {
+		fmt.Printf("%s went out of scope and was cleared\n", formatBreakpointName(wp, true))
+	}
+
 	if state.When != "" {
 		fmt.Println(state.When)
 	}
@@ -1333,11 +2441,42 @@ func printcontext(t *Term, state *api.DebuggerState) error {
 	return nil
 }
 
+func formatSyscall(tid int, sc *api.Syscall) string {
+	call := fmt.Sprintf("%s(%s)", sc.Name, strings.Join(sc.Args, ", "))
+	if !sc.Exit {
+		return fmt.Sprintf("Thread %d entering syscall %s", tid, call)
+	}
+	if sc.Err != "" {
+		return fmt.Sprintf("Thread %d returned from syscall %s = %d (%s)", tid, call, sc.Ret, sc.Err)
+	}
+	return fmt.Sprintf("Thread %d returned from syscall %s = %d", tid, call, sc.Ret)
+}
+
 func printcontextThread(t *Term, th *api.Thread) {
 	fn := th.Function
 
 	if th.Breakpoint == nil {
 		fmt.Printf("> %s() %s:%d (PC: %#v)\n", fn.Name, ShortenFilePath(th.File), th.Line, th.PC)
+		printReturnValues(th)
+		return
+	}
+
+	if th.Breakpoint.LogMessage != "" && th.BreakpointInfo != nil {
+		fmt.Println(th.BreakpointInfo.LogMessage)
+		return
+	}
+
+	bpname := ""
+	if th.Breakpoint.Name != "" {
+		bpname = fmt.Sprintf("[%s] ", th.Breakpoint.Name)
+	}
+
+	if th.Breakpoint.TraceReturn {
+		retVals := make([]string, 0, len(th.ReturnValues))
+		for _, v := range th.ReturnValues {
+			retVals = append(retVals, v.SinglelineString())
+		}
+		fmt.Printf("> %s%s() %s:%d => (%s)\n", bpname, fn.Name, ShortenFilePath(th.File), th.Line, strings.Join(retVals, ", "))
 		return
 	}
 
@@ -1350,11 +2489,6 @@ func printcontextThread(t *Term, th *api.Thread) {
 		args = strings.Join(arg, ", ")
 	}
 
-	bpname := ""
-	if th.Breakpoint.Name != "" {
-		bpname = fmt.Sprintf("[%s] ", th.Breakpoint.Name)
-	}
-
 	if hitCount, ok := th.Breakpoint.HitCount[strconv.Itoa(th.GoroutineID)]; ok {
 		fmt.Printf("> %s%s(%s) %s:%d (hits goroutine(%d):%d total:%d) (PC: %#v)\n",
 			bpname,
@@ -1385,8 +2519,18 @@ func printcontextThread(t *Term, th *api.Thread) {
 			writeGoroutineLong(os.Stdout, bpi.Goroutine, "\t")
 		}
 
+		if bpi.WatchOldValue != nil && bpi.WatchNewValue != nil {
+			fmt.Printf("\t%s accessed by goroutine %d\n", bp.WatchExpr, th.GoroutineID)
+			fmt.Printf("\told value: %s\n", bpi.WatchOldValue.SinglelineString())
+			fmt.Printf("\tnew value: %s\n", bpi.WatchNewValue.SinglelineString())
+		}
+
 		for _, v := range bpi.Variables {
-			fmt.Printf("\t%s: %s\n", v.Name, v.MultilineString("\t"))
+			name := v.Name
+			if label, ok := catchpointLabels[bp.Name]; ok && bp.ID < 0 {
+				name = label
+			}
+			fmt.Printf("\t%s: %s\n", name, v.MultilineString("\t"))
 		}
 
 		for _, v := range bpi.Locals {
@@ -1408,6 +2552,19 @@ func printcontextThread(t *Term, th *api.Thread) {
 			printStack(bpi.Stacktrace, "\t\t")
 		}
 	}
+
+	printReturnValues(th)
+}
+
+func printReturnValues(th *api.Thread) {
+	if len(th.ReturnValues) == 0 {
+		return
+	}
+	fmt.Println("Values returned:")
+	for _, v := range th.ReturnValues {
+		fmt.Printf("\t%s: %s\n", v.Name, v.MultilineString("\t"))
+	}
+	fmt.Println()
 }
 
 func printfile(t *Term, filename string, line int, showArrow bool) error {
@@ -1502,6 +2659,10 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 		return fmt.Errorf("not enough arguments")
 	}
 
+	if args[0] == "-hit" {
+		return hitConditionCmd(t, args[1])
+	}
+
 	bp, err := getBreakpointByIDOrName(t, args[0])
 	if err != nil {
 		return err
@@ -1511,6 +2672,20 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 	return t.client.AmendBreakpoint(bp)
 }
 
+func hitConditionCmd(t *Term, argstr string) error {
+	args := strings.Fields(argstr)
+	if len(args) < 1 {
+		return fmt.Errorf("not enough arguments")
+	}
+	bp, err := getBreakpointByIDOrName(t, args[len(args)-1])
+	if err != nil {
+		return err
+	}
+	bp.HitCond = strings.Join(args[:len(args)-1], " ")
+
+	return t.client.AmendBreakpoint(bp)
+}
+
 // ShortenFilePath take a full file path and attempts to shorten
 // it by replacing the current directory to './'.
 func ShortenFilePath(fullPath string) string {
@@ -1616,6 +2791,12 @@ func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 	if bp.Tracepoint {
 		thing = "tracepoint"
 	}
+	if bp.WatchType != 0 {
+		thing = "watchpoint"
+	}
+	if bp.ID < 0 && strings.HasPrefix(bp.Name, api.CatchpointPrefix) {
+		thing = "catchpoint"
+	}
 	if upcase {
 		thing = strings.Title(thing)
 	}
@@ -1627,9 +2808,20 @@ func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 }
 
 func formatBreakpointLocation(bp *api.Breakpoint) string {
+	if bp.WatchType != 0 {
+		return fmt.Sprintf("%#v for %s", bp.Addr, bp.WatchExpr)
+	}
+	addrs := fmt.Sprintf("%#v", bp.Addr)
+	if len(bp.Addrs) > 1 {
+		v := make([]string, len(bp.Addrs))
+		for i := range bp.Addrs {
+			v[i] = fmt.Sprintf("%#v", bp.Addrs[i])
+		}
+		addrs = strings.Join(v, ",")
+	}
 	p := ShortenFilePath(bp.File)
 	if bp.FunctionName != "" {
-		return fmt.Sprintf("%#v for %s() %s:%d", bp.Addr, bp.FunctionName, p, bp.Line)
+		return fmt.Sprintf("%s for %s() %s:%d", addrs, bp.FunctionName, p, bp.Line)
 	}
-	return fmt.Sprintf("%#v for %s:%d", bp.Addr, p, bp.Line)
+	return fmt.Sprintf("%s for %s:%d", addrs, p, bp.Line)
 }
@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -160,6 +162,26 @@ func TestCommandReplay(t *testing.T) {
 	}
 }
 
+func TestCommandReplayWithPrefix(t *testing.T) {
+	cmds := DebugCommands(nil)
+	cmds.cmds = append(cmds.cmds, command{
+		aliases:         []string{"foo"},
+		allowedPrefixes: revPrefix,
+		cmdFn: func(t *Term, ctx callContext, args string) error {
+			if ctx.Prefix != revPrefix {
+				return fmt.Errorf("wrong prefix %d", ctx.Prefix)
+			}
+			return nil
+		},
+	})
+	if err := cmds.Call("rev foo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmds.Call("", nil); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+}
+
 func TestCommandReplayWithoutPreviousCommand(t *testing.T) {
 	var (
 		cmds = DebugCommands(nil)
@@ -677,3 +699,151 @@ func TestConfig(t *testing.T) {
 		t.Fatalf("new alias found after delete")
 	}
 }
+
+func TestBpsaveBpload(t *testing.T) {
+	withTestTerminal("goroutinestackprog", t, func(term *FakeTerminal) {
+		fh, err := ioutil.TempFile("", "bpsavetest")
+		if err != nil {
+			t.Fatalf("could not create temporary file: %v", err)
+		}
+		fh.Close()
+		defer os.Remove(fh.Name())
+
+		term.MustExec("b agobp main.agoroutine")
+		term.MustExec("on agobp print i")
+		term.MustExec("cond agobp i == 5")
+		term.MustExec("bpsave " + fh.Name())
+		buf, err := ioutil.ReadFile(fh.Name())
+		if err != nil {
+			t.Fatalf("could not read saved breakpoints: %v", err)
+		}
+		if !strings.Contains(string(buf), `"Location": "main.agoroutine"`) {
+			t.Fatalf("location spec not saved: %s", buf)
+		}
+		term.MustExec("clearall")
+		out := term.MustExec("bpload " + fh.Name())
+		if !strings.Contains(out, "Breakpoint agobp set at") {
+			t.Fatalf("breakpoint not restored: %q", out)
+		}
+		out = term.MustExec("breakpoints")
+		if !strings.Contains(out, "\tcond i == 5") || !strings.Contains(out, "\tprint i") {
+			t.Fatalf("breakpoint attributes not restored: %q", out)
+		}
+
+		// loading the same breakpoints again fails because of the name
+		out = term.MustExec("bpload " + fh.Name())
+		if !strings.Contains(out, "Discarded breakpoint agobp") {
+			t.Fatalf("breakpoint not discarded: %q", out)
+		}
+	})
+}
+
+func TestParseLogMessage(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		msg   string
+		exprs []string
+	}{
+		{`"no arguments"`, "no arguments", nil},
+		{`"request %v took \"%d\" ms", req.ID, elapsed`, `request %v took "%d" ms`, []string{"req.ID", "elapsed"}},
+		{`"%d %s %v %c" ,a + b, s[i : j], f(x, y), ','`, "%d %s %v %c", []string{"a + b", "s[i : j]", "f(x, y)", "','"}},
+		{`"%v %v", m["a,b"], []int{1, 2}[0]`, "%v %v", []string{`m["a,b"]`, "[]int{1, 2}[0]"}},
+		{`"request %v took %d ms" req.ID  elapsed`, "request %v took %d ms", []string{"req.ID", "elapsed"}},
+		{`"%d %s %v" (a + b) s[i : j] f(x, y)`, "%d %s %v", []string{"(a + b)", "s[i : j]", "f(x, y)"}},
+		{`"%v %v" m["a b"] ' '`, "%v %v", []string{`m["a b"]`, "' '"}},
+	} {
+		msg, exprs, err := parseLogMessage(tc.in)
+		if err != nil {
+			t.Fatalf("parseLogMessage(%s): %v", tc.in, err)
+		}
+		if msg != tc.msg {
+			t.Fatalf("parseLogMessage(%s): wrong message %q", tc.in, msg)
+		}
+		if !reflect.DeepEqual(exprs, tc.exprs) {
+			t.Fatalf("parseLogMessage(%s): wrong expressions %q", tc.in, exprs)
+		}
+	}
+	for _, in := range []string{`"unterminated %d x`, `"%d"x`, `"%d %d", a,, b`, `"%d" f(x`, `"%d", f(x`, `"%d", a]`, `"%s", "x`} {
+		if _, _, err := parseLogMessage(in); err == nil {
+			t.Fatalf("parseLogMessage(%s) did not fail", in)
+		}
+	}
+}
+
+func TestPrintFormat(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		for _, tc := range []struct{ cmd, tail string }{
+			{"print %x i1", " = 0x1\n"},
+			{"print %o i1 + 8", " = 011\n"},
+			{"print %b i1 + 4", " = 0b101\n"},
+			{"print %q 97", " = 'a'\n"},
+			{"print %x str1", " = 3031323334353637383930\n"},
+		} {
+			if out := term.MustExec(tc.cmd); !strings.HasSuffix(out, tc.tail) {
+				t.Errorf("%s: wrong output %q", tc.cmd, out)
+			}
+		}
+		if out := term.MustExec("print %h str1"); !strings.Contains(out, "00000000  30 31 32 33 34 35 36 37  38 39 30") {
+			t.Errorf("print %%h str1: wrong output %q", out)
+		}
+		if _, err := term.Exec("print %z i1"); err == nil {
+			t.Errorf("print %%z did not fail")
+		}
+	})
+}
+
+func TestParseExamineMemArgs(t *testing.T) {
+	xargs, err := parseExamineMemArgs("-fmt bin -len 64 -size 8 &buf[2]")
+	if err != nil {
+		t.Fatalf("parseExamineMemArgs: %v", err)
+	}
+	if xargs != (examineMemArgs{"bin", 64, 8, "&buf[2]"}) {
+		t.Fatalf("wrong arguments %#v", xargs)
+	}
+	xargs, err = parseExamineMemArgs("uintptr(p) + 8")
+	if err != nil {
+		t.Fatalf("parseExamineMemArgs: %v", err)
+	}
+	if xargs != (examineMemArgs{"hex", 16, 1, "uintptr(p) + 8"}) {
+		t.Fatalf("wrong arguments %#v", xargs)
+	}
+	for _, args := range []string{"", "-len 4", "-size 3 p", "-fmt foo p", "-len -1 p", "-x 1 p"} {
+		if _, err := parseExamineMemArgs(args); err == nil {
+			t.Errorf("parseExamineMemArgs(%q) did not fail", args)
+		}
+	}
+}
+
+func TestPrintMemory(t *testing.T) {
+	mem := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0xff, 0xfe}
+	var buf bytes.Buffer
+	printMemory(&buf, 0x1000, mem, "hex", 4)
+	if tgt := "0x1000:   0x04030201   0x08070605\n"; buf.String() != tgt {
+		t.Errorf("wrong output %q, expected %q", buf.String(), tgt)
+	}
+	buf.Reset()
+	printMemory(&buf, 0x1000, mem[8:], "dec", 1)
+	if tgt := "0x1000:   255   254\n"; buf.String() != tgt {
+		t.Errorf("wrong output %q, expected %q", buf.String(), tgt)
+	}
+}
+
+func TestChanCommand(t *testing.T) {
+	withTestTerminal("chanstate", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		out := term.MustExec("chan ch1")
+		if !strings.HasPrefix(out, "chan int len: 3, cap: 4\nBuffer:\n\t0: 2\n\t1: 3\n\t2: 4\n") {
+			t.Errorf("wrong output for ch1: %q", out)
+		}
+		if out := term.MustExec("chan ch2"); !strings.Contains(out, "Goroutines blocked receiving: ") {
+			t.Errorf("wrong output for ch2: %q", out)
+		}
+		if out := term.MustExec("chan ch4"); !strings.HasPrefix(out, "chan struct {} len: 0, cap: 1, closed\n") {
+			t.Errorf("wrong output for ch4: %q", out)
+		}
+		if _, err := term.Exec("chan 1"); err == nil {
+			t.Errorf("chan of an integer did not fail")
+		}
+	})
+}
@@ -16,7 +16,7 @@ func configureCmd(t *Term, ctx callContext, args string) error {
 	case "-list":
 		return configureList(t)
 	case "-save":
-		return config.SaveConfig(t.conf)
+		return t.saveConfig()
 	case "":
 		return fmt.Errorf("wrong number of arguments to \"config\"")
 	default:
@@ -24,6 +24,17 @@ func configureCmd(t *Term, ctx callContext, args string) error {
 	}
 }
 
+// saveConfig writes the configuration to config.yml. If config.yml could
+// not be loaded the configuration is not saved, since that would discard
+// the rest of the file.
+func (t *Term) saveConfig() error {
+	if !t.confLoaded {
+		fmt.Println("Configuration not saved: config.yml could not be loaded, the change only applies to this session")
+		return nil
+	}
+	return config.SaveConfig(t.conf)
+}
+
 type configureIterator struct {
 	cfgValue reflect.Value
 	cfgType  reflect.Type
@@ -33,6 +33,14 @@ type Term struct {
 	dumb     bool
 	stdout   io.Writer
 	InitFile string
+
+	// lastPid is the pid of the process that stopped last, used to tell
+	// the user when a different inferior stops.
+	lastPid int
+
+	// confLoaded is false if config.yml could not be loaded, conf then
+	// only contains the changes made in this session.
+	confLoaded bool
 }
 
 // New returns a new Term.
@@ -59,6 +67,8 @@ func New(client service.Client, conf *config.Config) *Term {
 		cmds:   cmds,
 		dumb:   dumb,
 		stdout: w,
+
+		confLoaded: conf != nil,
 	}
 }
 
@@ -112,6 +122,29 @@ func (t *Term) Run() (int, error) {
 	f.Close()
 	fmt.Println("Type 'help' for list of commands.")
 
+	if t.conf != nil {
+		for name, h := range t.conf.Handle {
+			if err := t.client.SetSignalHandling(name, h.Stop, h.Print, h.Pass); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not change handling of %s: %v\n", name, err)
+			}
+		}
+		if len(t.conf.SkipPackages) > 0 || len(t.conf.SkipFiles) > 0 {
+			if err := t.client.SetStepFilter(t.conf.SkipPackages, t.conf.SkipFiles); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not set step filter: %v\n", err)
+			}
+		}
+		if t.conf.FollowForkMode != "" {
+			if err := t.client.SetFollowForkMode(t.conf.FollowForkMode); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not set follow-fork-mode: %v\n", err)
+			}
+		}
+		if t.conf.AllowFunctionCalls {
+			if err := t.client.AllowFunctionCalls(true); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not allow function calls: %v\n", err)
+			}
+		}
+	}
+
 	if t.InitFile != "" {
 		err := t.cmds.executeFile(t, t.InitFile)
 		if err != nil {
@@ -0,0 +1,78 @@
+// +build ignore
+
+// Generates the table of linux system call names used by the native
+// backend from the SYS_* constants of golang.org/x/sys/unix.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	root := os.ExpandEnv("$GOPATH/src/github.com/derekparker/delve")
+	in := filepath.Join(root, "vendor/golang.org/x/sys/unix/zsysnum_linux_amd64.go")
+	out := filepath.Join(root, "pkg/proc/native/zsyscalls_linux_amd64.go")
+
+	f, err := parser.ParseFile(token.NewFileSet(), in, nil, 0)
+	if err != nil {
+		log.Fatalf("could not parse %s: %v", in, err)
+	}
+
+	names := map[int]string{}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vspec := spec.(*ast.ValueSpec)
+			if len(vspec.Names) != 1 || len(vspec.Values) != 1 || !strings.HasPrefix(vspec.Names[0].Name, "SYS_") {
+				continue
+			}
+			lit, ok := vspec.Values[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.INT {
+				continue
+			}
+			num, err := strconv.Atoi(lit.Value)
+			if err != nil {
+				log.Fatalf("bad system call number for %s: %v", vspec.Names[0].Name, err)
+			}
+			names[num] = strings.ToLower(vspec.Names[0].Name[len("SYS_"):])
+		}
+	}
+	nums := make([]int, 0, len(names))
+	for num := range names {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by scripts/gen-syscall-names.go from golang.org/x/sys/unix/%s; DO NOT EDIT.\n\n", filepath.Base(in))
+	fmt.Fprintf(&buf, "package native\n\n")
+	fmt.Fprintf(&buf, "// syscallNames maps linux/amd64 system call numbers to their names.\n")
+	fmt.Fprintf(&buf, "var syscallNames = [...]string{\n")
+	for _, num := range nums {
+		fmt.Fprintf(&buf, "%d: %q,\n", num, names[num])
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("could not format output: %v", err)
+	}
+	if err := ioutil.WriteFile(out, src, 0644); err != nil {
+		log.Fatalf("could not write %s: %v", out, err)
+	}
+}
@@ -1,11 +1,8 @@
 package api
 
 import (
-	"bytes"
 	"debug/gosym"
 	"go/constant"
-	"go/printer"
-	"go/token"
 	"reflect"
 	"strconv"
 
@@ -22,14 +19,21 @@ func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 		FunctionName:  bp.FunctionName,
 		File:          bp.File,
 		Line:          bp.Line,
+		LocationSpec:  bp.LocationSpec,
 		Addr:          bp.Addr,
+		Addrs:         bp.Addrs(),
+		Disabled:      bp.Disabled,
 		Tracepoint:    bp.Tracepoint,
 		Stacktrace:    bp.Stacktrace,
 		Goroutine:     bp.Goroutine,
 		Variables:     bp.Variables,
+		LogMessage:    bp.LogMessage,
+		TraceReturn:   bp.TraceReturn,
 		LoadArgs:      LoadConfigFromProc(bp.LoadArgs),
 		LoadLocals:    LoadConfigFromProc(bp.LoadLocals),
 		TotalHitCount: bp.TotalHitCount,
+		WatchExpr:     bp.WatchExpr,
+		WatchType:     WatchType(bp.WatchType) & (WatchRead | WatchWrite),
 	}
 
 	b.HitCount = map[string]uint64{}
@@ -37,9 +41,13 @@ func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 		b.HitCount[strconv.Itoa(idx)] = bp.HitCount[idx]
 	}
 
-	var buf bytes.Buffer
-	printer.Fprint(&buf, token.NewFileSet(), bp.Cond)
-	b.Cond = buf.String()
+	if bp.Cond != nil {
+		b.Cond = proc.ExprString(bp.Cond)
+	}
+
+	if bp.HitCond != nil {
+		b.HitCond = bp.HitCond.String()
+	}
 
 	return b
 }
@@ -65,7 +73,7 @@ func ConvertThread(th proc.Thread) *Thread {
 
 	var bp *Breakpoint
 
-	if b, active, _ := th.Breakpoint(); active {
+	if b, active, _ := th.Breakpoint(); active && b.Kind != proc.WatchOutOfScopeBreakpoint {
 		bp = ConvertBreakpoint(b)
 	}
 
@@ -84,6 +92,31 @@ func ConvertThread(th proc.Thread) *Thread {
 	}
 }
 
+// ConvertSyscall converts a proc.Syscall into an api.Syscall.
+func ConvertSyscall(sc *proc.Syscall) *Syscall {
+	r := &Syscall{
+		Num:  sc.Num,
+		Name: sc.Name,
+		Args: sc.Args,
+		Exit: sc.Exit,
+		Ret:  sc.Ret,
+	}
+	if sc.Err != nil {
+		r.Err = sc.Err.Error()
+	}
+	return r
+}
+
+// ConvertInferior converts an inferior process into an api.Inferior.
+func ConvertInferior(p proc.Process, current bool) Inferior {
+	return Inferior{Pid: p.Pid(), Path: p.BinInfo().Path(), Current: current}
+}
+
+// ConvertSignal converts a proc.Signal into an api.Signal.
+func ConvertSignal(sig proc.Signal) *Signal {
+	return &Signal{ThreadID: sig.ThreadID, Num: sig.Num, Name: sig.Name}
+}
+
 func prettyTypeName(typ godwarf.Type) string {
 	if typ == nil {
 		return ""
@@ -122,6 +155,10 @@ func ConvertVar(v *proc.Variable) *Variable {
 		Cap:      v.Cap,
 		Flags:    VariableFlags(v.Flags),
 		Base:     v.Base,
+		Display:  v.Display,
+
+		RecvWaiting: v.RecvWaiting,
+		SendWaiting: v.SendWaiting,
 	}
 
 	r.Type = prettyTypeName(v.DwarfType)
@@ -2,9 +2,16 @@ package api
 
 import (
 	"bytes"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/derekparker/delve/pkg/config"
+	"github.com/derekparker/delve/pkg/proc"
 )
 
 const (
@@ -17,23 +24,54 @@ const (
 // SinglelineString returns a representation of v on a single line.
 func (v *Variable) SinglelineString() string {
 	var buf bytes.Buffer
-	v.writeTo(&buf, true, false, true, "")
+	v.writeTo(&buf, true, false, true, "", 0)
 	return buf.String()
 }
 
 // MultilineString returns a representation of v on multiple lines.
 func (v *Variable) MultilineString(indent string) string {
 	var buf bytes.Buffer
-	v.writeTo(&buf, true, true, true, indent)
+	v.writeTo(&buf, true, true, true, indent, 0)
+	return buf.String()
+}
+
+// FormatVerbs lists the format verbs accepted by MultilineStringFormat.
+// Integers are printed in hexadecimal with x, in octal with o, in binary
+// with b, as the character they encode with c and as a quoted character
+// literal with q. Strings are printed as hexadecimal digits with x, slices
+// and arrays of bytes as a quoted string with q, both are printed as a
+// hexdump with h. Values that a verb does not apply to are printed as usual.
+const FormatVerbs = "xobcqh"
+
+// MultilineStringFormat returns a representation of v on multiple lines,
+// where integers, strings and byte slices contained in v are formatted as
+// specified by verb, one of FormatVerbs.
+func (v *Variable) MultilineStringFormat(indent string, verb rune) string {
+	var buf bytes.Buffer
+	v.writeTo(&buf, true, true, true, indent, verb)
 	return buf.String()
 }
 
-func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, indent string) {
+// ClearDisplay removes the human readable representation of v and of its
+// children, so that they are printed as the values they are made of.
+func (v *Variable) ClearDisplay() {
+	v.Display = ""
+	for i := range v.Children {
+		v.Children[i].ClearDisplay()
+	}
+}
+
+func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, indent string, verb rune) {
 	if v.Unreadable != "" {
 		fmt.Fprintf(buf, "(unreadable %s)", v.Unreadable)
 		return
 	}
 
+	if v.Display != "" {
+		fmt.Fprint(buf, v.Display)
+		return
+	}
+
 	if !top && v.Addr == 0 {
 		if includeType && v.Type != "void" {
 			fmt.Fprintf(buf, "%s nil", v.Type)
@@ -45,9 +83,9 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 
 	switch v.Kind {
 	case reflect.Slice:
-		v.writeSliceTo(buf, newlines, includeType, indent)
+		v.writeSliceTo(buf, newlines, includeType, indent, verb)
 	case reflect.Array:
-		v.writeArrayTo(buf, newlines, includeType, indent)
+		v.writeArrayTo(buf, newlines, includeType, indent, verb)
 	case reflect.Ptr:
 		if v.Type == "" {
 			fmt.Fprint(buf, "nil")
@@ -55,15 +93,15 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 			fmt.Fprintf(buf, "(%s)(0x%x)", v.Type, v.Children[0].Addr)
 		} else {
 			fmt.Fprint(buf, "*")
-			v.Children[0].writeTo(buf, false, newlines, includeType, indent)
+			v.Children[0].writeTo(buf, false, newlines, includeType, indent, verb)
 		}
 	case reflect.UnsafePointer:
 		fmt.Fprintf(buf, "unsafe.Pointer(0x%x)", v.Children[0].Addr)
 	case reflect.String:
-		v.writeStringTo(buf)
+		v.writeStringTo(buf, newlines, indent, verb)
 	case reflect.Chan:
 		if newlines {
-			v.writeStructTo(buf, newlines, includeType, indent)
+			v.writeStructTo(buf, newlines, includeType, indent, verb)
 		} else {
 			if len(v.Children) == 0 {
 				fmt.Fprintf(buf, "%s nil", v.Type)
@@ -72,7 +110,7 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 			}
 		}
 	case reflect.Struct:
-		v.writeStructTo(buf, newlines, includeType, indent)
+		v.writeStructTo(buf, newlines, includeType, indent, verb)
 	case reflect.Interface:
 		if includeType {
 			if v.Children[0].Kind == reflect.Invalid {
@@ -94,15 +132,15 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 			} else if data.Children[0].OnlyAddr {
 				fmt.Fprintf(buf, "0x%x", v.Children[0].Addr)
 			} else {
-				v.Children[0].writeTo(buf, false, newlines, !includeType, indent)
+				v.Children[0].writeTo(buf, false, newlines, !includeType, indent, verb)
 			}
 		} else if data.OnlyAddr {
 			fmt.Fprintf(buf, "*(*%q)(0x%x)", v.Type, v.Addr)
 		} else {
-			v.Children[0].writeTo(buf, false, newlines, !includeType, indent)
+			v.Children[0].writeTo(buf, false, newlines, !includeType, indent, verb)
 		}
 	case reflect.Map:
-		v.writeMapTo(buf, newlines, includeType, indent)
+		v.writeMapTo(buf, newlines, includeType, indent, verb)
 	case reflect.Func:
 		if v.Value == "" {
 			fmt.Fprint(buf, "nil")
@@ -113,14 +151,24 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 		fmt.Fprintf(buf, "(%s + %si)", v.Children[0].Value, v.Children[1].Value)
 	default:
 		if v.Value != "" {
-			buf.Write([]byte(v.Value))
+			buf.Write([]byte(v.formatValue(verb)))
 		} else {
 			fmt.Fprintf(buf, "(unknown %s)", v.Kind)
 		}
 	}
 }
 
-func (v *Variable) writeStringTo(buf io.Writer) {
+func (v *Variable) writeStringTo(buf io.Writer, newlines bool, indent string, verb rune) {
+	switch verb {
+	case 'x':
+		fmt.Fprintf(buf, "%x", v.Value)
+		writeMore(buf, int(v.Len)-len(v.Value))
+		return
+	case 'h':
+		writeHexdump(buf, []byte(v.Value), newlines, indent)
+		writeMore(buf, int(v.Len)-len(v.Value))
+		return
+	}
 	s := v.Value
 	if len(s) != int(v.Len) {
 		s = fmt.Sprintf("%s...+%d more", s, int(v.Len)-len(s))
@@ -128,7 +176,90 @@ func (v *Variable) writeStringTo(buf io.Writer) {
 	fmt.Fprintf(buf, "%q", s)
 }
 
-func (v *Variable) writeSliceTo(buf io.Writer, newlines, includeType bool, indent string) {
+// formatValue returns v.Value formatted as specified by verb, if v is an
+// integer.
+func (v *Variable) formatValue(verb rune) string {
+	var n int64
+	switch v.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return v.Value
+		}
+		n = x
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, err := strconv.ParseUint(v.Value, 10, 64)
+		if err != nil {
+			return v.Value
+		}
+		if verb == 'x' || verb == 'o' || verb == 'b' {
+			return formatUint(x, verb)
+		}
+		n = int64(x)
+	default:
+		return v.Value
+	}
+	switch verb {
+	case 'x', 'o', 'b':
+		if n < 0 {
+			return "-" + formatUint(uint64(-n), verb)
+		}
+		return formatUint(uint64(n), verb)
+	case 'c':
+		return string(rune(n))
+	case 'q':
+		return strconv.QuoteRune(rune(n))
+	}
+	return v.Value
+}
+
+func formatUint(n uint64, verb rune) string {
+	switch verb {
+	case 'x':
+		return "0x" + strconv.FormatUint(n, 16)
+	case 'o':
+		return "0" + strconv.FormatUint(n, 8)
+	default:
+		return "0b" + strconv.FormatUint(n, 2)
+	}
+}
+
+// byteValues returns the contents of v if v is a slice or array of bytes whose
+// elements have been loaded.
+func (v *Variable) byteValues() ([]byte, bool) {
+	if len(v.Children) == 0 || v.Children[0].Kind != reflect.Uint8 {
+		return nil, false
+	}
+	r := make([]byte, len(v.Children))
+	for i := range v.Children {
+		n, err := strconv.ParseUint(v.Children[i].Value, 10, 8)
+		if err != nil {
+			return nil, false
+		}
+		r[i] = byte(n)
+	}
+	return r, true
+}
+
+// writeHexdump writes data as hexdump does on multiple lines, or as a
+// sequence of hexadecimal digits on a single line.
+func writeHexdump(buf io.Writer, data []byte, newlines bool, indent string) {
+	if !newlines || len(data) == 0 {
+		fmt.Fprintf(buf, "%x", data)
+		return
+	}
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(hex.Dump(data), "\n"), "\n") {
+		fmt.Fprintf(buf, "\n%s%s%s", indent, indentString, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func writeMore(buf io.Writer, n int) {
+	if n > 0 {
+		fmt.Fprintf(buf, "...+%d more", n)
+	}
+}
+
+func (v *Variable) writeSliceTo(buf io.Writer, newlines, includeType bool, indent string, verb rune) {
 	if includeType {
 		fmt.Fprintf(buf, "%s len: %d, cap: %d, ", v.Type, v.Len, v.Cap)
 	}
@@ -136,17 +267,17 @@ func (v *Variable) writeSliceTo(buf io.Writer, newlines, includeType bool, inden
 		fmt.Fprintf(buf, "nil")
 		return
 	}
-	v.writeSliceOrArrayTo(buf, newlines, indent)
+	v.writeSliceOrArrayTo(buf, newlines, indent, verb)
 }
 
-func (v *Variable) writeArrayTo(buf io.Writer, newlines, includeType bool, indent string) {
+func (v *Variable) writeArrayTo(buf io.Writer, newlines, includeType bool, indent string, verb rune) {
 	if includeType {
 		fmt.Fprintf(buf, "%s ", v.Type)
 	}
-	v.writeSliceOrArrayTo(buf, newlines, indent)
+	v.writeSliceOrArrayTo(buf, newlines, indent, verb)
 }
 
-func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, indent string) {
+func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, indent string, verb rune) {
 	if int(v.Len) != len(v.Children) && len(v.Children) == 0 {
 		fmt.Fprintf(buf, "(*%s)(0x%x)", v.Type, v.Addr)
 		return
@@ -165,7 +296,7 @@ func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, inde
 			fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 		}
 		fmt.Fprintf(buf, "%s: ", v.Children[i].Name)
-		v.Children[i].writeTo(buf, false, nl, true, indent+indentString)
+		v.Children[i].writeTo(buf, false, nl, true, indent+indentString, verb)
 		if i != len(v.Children)-1 || nl {
 			fmt.Fprint(buf, ",")
 			if !nl {
@@ -186,7 +317,7 @@ func (v *Variable) writeStructTo(buf io.Writer, newlines, includeType bool, inde
 	fmt.Fprint(buf, "}")
 }
 
-func (v *Variable) writeMapTo(buf io.Writer, newlines, includeType bool, indent string) {
+func (v *Variable) writeMapTo(buf io.Writer, newlines, includeType bool, indent string, verb rune) {
 	if includeType {
 		fmt.Fprintf(buf, "%s ", v.Type)
 	}
@@ -207,9 +338,9 @@ func (v *Variable) writeMapTo(buf io.Writer, newlines, includeType bool, indent
 			fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 		}
 
-		key.writeTo(buf, false, false, false, indent+indentString)
+		key.writeTo(buf, false, false, false, indent+indentString, verb)
 		fmt.Fprint(buf, ": ")
-		value.writeTo(buf, false, nl, false, indent+indentString)
+		value.writeTo(buf, false, nl, false, indent+indentString, verb)
 		if i != len(v.Children)-1 || nl {
 			fmt.Fprint(buf, ", ")
 		}
@@ -298,7 +429,18 @@ func (v *Variable) shouldNewlineStruct(newlines bool) bool {
 	return false
 }
 
-func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent string) {
+func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent string, verb rune) {
+	if verb == 'h' || verb == 'q' {
+		if data, ok := v.byteValues(); ok {
+			if verb == 'h' {
+				writeHexdump(buf, data, newlines, indent)
+			} else {
+				fmt.Fprintf(buf, "%q", data)
+			}
+			writeMore(buf, int(v.Len)-len(v.Children))
+			return
+		}
+	}
 	nl := v.shouldNewlineArray(newlines)
 	fmt.Fprint(buf, "[")
 
@@ -306,7 +448,7 @@ func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent stri
 		if nl {
 			fmt.Fprintf(buf, "\n%s%s", indent, indentString)
 		}
-		v.Children[i].writeTo(buf, false, nl, false, indent+indentString)
+		v.Children[i].writeTo(buf, false, nl, false, indent+indentString, verb)
 		if i != len(v.Children)-1 || nl {
 			fmt.Fprint(buf, ",")
 		}
@@ -331,3 +473,149 @@ func (v *Variable) writeSliceOrArrayTo(buf io.Writer, newlines bool, indent stri
 
 	fmt.Fprint(buf, "]")
 }
+
+// maxPrettyPrintDepth is how many values produced by pretty printers can be
+// nested, it stops pretty printers of recursive types.
+const maxPrettyPrintDepth = 3
+
+// prettyPrintLoadConfig is used to load the values of the expressions of
+// pretty printers.
+var prettyPrintLoadConfig = proc.LoadConfig{true, 1, 64, 64, -1}
+
+// PrettyPrinters converts variables applying the pretty printers
+// configured by the user.
+type PrettyPrinters struct {
+	byType map[string]*prettyPrinter
+}
+
+type prettyPrinter struct {
+	// display is Display split at braces: odd items are expressions.
+	display  []string
+	children []config.PrettyPrinterChild
+}
+
+// NewPrettyPrinters checks pps and returns the PrettyPrinters applying them.
+func NewPrettyPrinters(pps []config.PrettyPrinter) (*PrettyPrinters, error) {
+	r := &PrettyPrinters{byType: make(map[string]*prettyPrinter)}
+	for _, pp := range pps {
+		if pp.Type == "" {
+			return nil, errors.New("pretty printer without a type")
+		}
+		if pp.Display == "" && len(pp.Children) == 0 {
+			return nil, fmt.Errorf("pretty printer for %s has neither a display nor children", pp.Type)
+		}
+		p := &prettyPrinter{children: pp.Children}
+		if pp.Display != "" {
+			var err error
+			p.display, err = splitDisplay(pp.Display)
+			if err != nil {
+				return nil, fmt.Errorf("pretty printer for %s: %v", pp.Type, err)
+			}
+		}
+		for i := 1; i < len(p.display); i += 2 {
+			if _, err := proc.ParseExpr(p.display[i]); err != nil {
+				return nil, fmt.Errorf("pretty printer for %s: could not parse %q: %v", pp.Type, p.display[i], err)
+			}
+		}
+		for _, child := range pp.Children {
+			if child.Name == "" {
+				return nil, fmt.Errorf("pretty printer for %s: child without a name", pp.Type)
+			}
+			if _, err := proc.ParseExpr(child.Expr); err != nil {
+				return nil, fmt.Errorf("pretty printer for %s: could not parse %q: %v", pp.Type, child.Expr, err)
+			}
+		}
+		r.byType[pp.Type] = p
+	}
+	return r, nil
+}
+
+// splitDisplay splits display into text and expressions, the expressions
+// are at odd indexes.
+func splitDisplay(display string) ([]string, error) {
+	var r []string
+	for {
+		start := strings.Index(display, "{")
+		if start < 0 {
+			if strings.Contains(display, "}") {
+				return nil, errors.New("unbalanced '}' in display")
+			}
+			return append(r, display), nil
+		}
+		end := strings.Index(display[start:], "}")
+		if end < 0 {
+			return nil, errors.New("unbalanced '{' in display")
+		}
+		end += start
+		if strings.Contains(display[:start], "}") || strings.Contains(display[start+1:end], "{") {
+			return nil, errors.New("unbalanced braces in display")
+		}
+		r = append(r, display[:start], display[start+1:end])
+		display = display[end+1:]
+	}
+}
+
+// ConvertVar converts v like ConvertVar and applies the pretty printers to
+// the result and to its children.
+func (pps *PrettyPrinters) ConvertVar(v *proc.Variable) *Variable {
+	r := ConvertVar(v)
+	if pps != nil && len(pps.byType) > 0 {
+		pps.apply(r, v, 0)
+	}
+	return r
+}
+
+func (pps *PrettyPrinters) apply(r *Variable, v *proc.Variable, depth int) {
+	if pp := pps.byType[r.Type]; pp != nil && v.Unreadable == nil && depth < maxPrettyPrintDepth {
+		pp.format(pps, r, v, depth)
+		return
+	}
+	if len(r.Children) != len(v.Children) {
+		return
+	}
+	for i := range r.Children {
+		pps.apply(&r.Children[i], &v.Children[i], depth)
+	}
+}
+
+func (pp *prettyPrinter) format(pps *PrettyPrinters, r *Variable, v *proc.Variable, depth int) {
+	r.Display = ""
+	if len(pp.children) > 0 {
+		r.Kind = reflect.Struct
+		r.Len = int64(len(pp.children))
+		r.Children = make([]Variable, len(pp.children))
+		for i, child := range pp.children {
+			cv, err := proc.EvalExpressionOnValue(v, child.Expr, prettyPrintLoadConfig)
+			if err != nil {
+				r.Children[i] = Variable{Name: child.Name, Unreadable: err.Error()}
+				continue
+			}
+			r.Children[i] = *ConvertVar(cv)
+			r.Children[i].Name = child.Name
+			pps.apply(&r.Children[i], cv, depth+1)
+		}
+	}
+
+	if len(pp.display) > 0 {
+		var buf bytes.Buffer
+		for i := range pp.display {
+			if i%2 == 0 {
+				buf.WriteString(pp.display[i])
+				continue
+			}
+			ev, err := proc.EvalExpressionOnValue(v, pp.display[i], prettyPrintLoadConfig)
+			if err != nil {
+				fmt.Fprintf(&buf, "(unreadable %v)", err)
+				continue
+			}
+			ar := ConvertVar(ev)
+			pps.apply(ar, ev, depth+1)
+			if ar.Kind == reflect.String && ar.Unreadable == "" && ar.Display == "" {
+				buf.WriteString(ar.Value)
+			} else {
+				ar.writeTo(&buf, true, false, false, "", 0)
+			}
+		}
+		r.Display = buf.String()
+	}
+}
@@ -26,15 +26,73 @@ type DebuggerState struct {
 	// While NextInProgress is set further requests for next or step may be rejected.
 	// Either execute continue until NextInProgress is false or call CancelNext
 	NextInProgress bool
+	// TargetID is the ID of the current target, see Target.
+	TargetID int `json:"targetID"`
+	// Pid is the pid of the current inferior, see Inferior.
+	Pid int `json:"pid"`
 	// Exited indicates whether the debugged process has exited.
 	Exited     bool `json:"exited"`
 	ExitStatus int  `json:"exitStatus"`
 	// When contains a description of the current position in a recording
 	When string
+	// WatchOutOfScope contains the watchpoints that were cleared because
+	// their stack frame returned.
+	WatchOutOfScope []*Breakpoint `json:"watchOutOfScope,omitempty"`
+	// Syscall is the system call the current thread is stopped at, if it
+	// was stopped by a syscall catchpoint.
+	Syscall *Syscall `json:"syscall,omitempty"`
+	// Signal is the signal that stopped the current thread, if any.
+	Signal *Signal `json:"signal,omitempty"`
+	// SignalsReceived contains the signals that were received and
+	// reported, but did not stop the target, since the last command.
+	SignalsReceived []*Signal `json:"signalsReceived,omitempty"`
 	// Filled by RPCClient.Continue, indicates an error
 	Err error `json:"-"`
 }
 
+// Target is one of the programs being debugged.
+type Target struct {
+	ID  int `json:"id"`
+	Pid int `json:"pid"`
+	// Path is the executable of the target.
+	Path string `json:"path"`
+	// Current is true for the target commands apply to.
+	Current bool `json:"current"`
+	Exited  bool `json:"exited"`
+}
+
+// Inferior is a process being debugged: the target or one of the
+// children it forked that are being followed.
+type Inferior struct {
+	Pid int `json:"pid"`
+	// Path is the executable the process is running.
+	Path string `json:"path"`
+	// Current is true for the inferior that stopped last or was selected
+	// with SwitchInferior.
+	Current bool `json:"current"`
+}
+
+// Syscall describes the entry or exit of a system call.
+type Syscall struct {
+	// Num is the system call number.
+	Num  uint64 `json:"num"`
+	Name string `json:"name"`
+	// Args contains the decoded arguments of the system call.
+	Args []string `json:"args"`
+	// Exit is true if the system call has returned, Ret is its return
+	// value and Err the description of the error it returned, if any.
+	Exit bool   `json:"exit"`
+	Ret  int64  `json:"ret"`
+	Err  string `json:"err,omitempty"`
+}
+
+// Signal describes a signal received by a thread of the target.
+type Signal struct {
+	ThreadID int    `json:"threadID"`
+	Num      int    `json:"num"`
+	Name     string `json:"name"`
+}
+
 // Breakpoint addresses a location at which process execution may be
 // suspended.
 type Breakpoint struct {
@@ -44,6 +102,10 @@ type Breakpoint struct {
 	Name string `json:"name"`
 	// Addr is the address of the breakpoint.
 	Addr uint64 `json:"addr"`
+	// Addrs is the list of addresses for this breakpoint, a breakpoint set
+	// on a line compiled into multiple ranges of instructions will have
+	// more than one address.
+	Addrs []uint64 `json:"addrs"`
 	// File is the source file for the breakpoint.
 	File string `json:"file"`
 	// Line is a line in File for the breakpoint.
@@ -51,9 +113,20 @@ type Breakpoint struct {
 	// FunctionName is the name of the function at the current breakpoint, and
 	// may not always be available.
 	FunctionName string `json:"functionName,omitempty"`
+	// LocationSpec is the location spec the breakpoint was created from,
+	// if any. It is used to set the breakpoint again when the program is
+	// rebuilt by a restart.
+	LocationSpec string `json:"locationSpec,omitempty"`
+
+	// Disabled is true if the breakpoint is disabled, disabled
+	// breakpoints keep their attributes and hit counts but are not set in
+	// the target process.
+	Disabled bool `json:"disabled,omitempty"`
 
 	// Breakpoint condition
 	Cond string
+	// Breakpoint hit count condition, for example "> 100" or "% 10 == 0"
+	HitCond string `json:"hitCond,omitempty"`
 
 	// tracepoint flag
 	Tracepoint bool `json:"continue"`
@@ -63,6 +136,14 @@ type Breakpoint struct {
 	Stacktrace int `json:"stacktrace"`
 	// expressions to evaluate
 	Variables []string `json:"variables,omitempty"`
+	// LogMessage turns a tracepoint into a logpoint: when it is hit
+	// LogMessage is formatted, like fmt.Sprintf does, using the values of
+	// Variables as arguments and returned in BreakpointInfo.LogMessage.
+	LogMessage string `json:"logMessage,omitempty"`
+	// TraceReturn is set on the breakpoints created on the return
+	// instructions of a traced function, when they are hit the values
+	// returned by the function are reported in Thread.ReturnValues.
+	TraceReturn bool `json:"traceReturn,omitempty"`
 	// LoadArgs requests loading function arguments when the breakpoint is hit
 	LoadArgs *LoadConfig
 	// LoadLocals requests loading function locals when the breakpoint is hit
@@ -71,8 +152,25 @@ type Breakpoint struct {
 	HitCount map[string]uint64 `json:"hitCount"`
 	// number of times a breakpoint has been reached
 	TotalHitCount uint64 `json:"totalHitCount"`
+
+	// WatchExpr is the expression used to create this watchpoint
+	WatchExpr string `json:"watchExpr,omitempty"`
+	// WatchType is the type of memory access that triggers this
+	// watchpoint, zero for breakpoints
+	WatchType WatchType `json:"watchType,omitempty"`
 }
 
+// CatchpointPrefix is the prefix of the name of catchpoints.
+const CatchpointPrefix = proc.CatchpointPrefix
+
+// WatchType is the type of memory access that triggers a watchpoint.
+type WatchType uint8
+
+const (
+	WatchRead  = WatchType(proc.WatchRead)
+	WatchWrite = WatchType(proc.WatchWrite)
+)
+
 func ValidBreakpointName(name string) error {
 	if _, err := strconv.Atoi(name); err == nil {
 		return errors.New("breakpoint name can not be a number")
@@ -91,6 +189,8 @@ func ValidBreakpointName(name string) error {
 type Thread struct {
 	// ID is a unique identifier for the thread.
 	ID int `json:"id"`
+	// TargetID is the ID of the target the thread belongs to.
+	TargetID int `json:"targetID"`
 	// PC is the current program counter for the thread.
 	PC uint64 `json:"pc"`
 	// File is the file for the program counter.
@@ -107,6 +207,9 @@ type Thread struct {
 	Breakpoint *Breakpoint `json:"breakPoint,omitempty"`
 	// Informations requested by the current breakpoint
 	BreakpointInfo *BreakpointInfo `json:"breakPointInfo,omitrempty"`
+
+	// ReturnValues contains the return values of the function we just stepped out of
+	ReturnValues []Variable `json:"returnValues,omitempty"`
 }
 
 type Location struct {
@@ -114,6 +217,9 @@ type Location struct {
 	File     string    `json:"file"`
 	Line     int       `json:"line"`
 	Function *Function `json:"function,omitempty"`
+	// PCs is the list of addresses for this location, it is only set by
+	// FindLocation when the location resolves to more than one address.
+	PCs []uint64 `json:"pcs,omitempty"`
 }
 
 type Stackframe struct {
@@ -205,6 +311,21 @@ type Variable struct {
 
 	// Unreadable addresses will have this field set
 	Unreadable string `json:"unreadable"`
+
+	// Display is the human readable representation of values of some
+	// standard library types, like time.Time, or of the types with a pretty
+	// printer configured by the user. It replaces the value when printing
+	// it, Children still contains the fields of the value.
+	// Pretty printers can also replace the children of a variable, in that
+	// case Kind is reflect.Struct and each child is a field.
+	Display string `json:"display,omitempty"`
+
+	// For channels, the IDs of the goroutines blocked receiving from and
+	// sending to the channel. Children contains the fields of the runtime
+	// structure of the channel, where buf is an array of the queued
+	// elements, starting with the next one to be received.
+	RecvWaiting []int `json:"recvWaiting,omitempty"`
+	SendWaiting []int `json:"sendWaiting,omitempty"`
 }
 
 // LoadConfig describes how to load values from target's memory
@@ -226,6 +347,8 @@ type LoadConfig struct {
 type Goroutine struct {
 	// ID is a unique identifier for the goroutine.
 	ID int `json:"id"`
+	// TargetID is the ID of the target the goroutine belongs to.
+	TargetID int `json:"targetID"`
 	// Current location of the goroutine
 	CurrentLoc Location `json:"currentLoc"`
 	// Current location of the goroutine, excluding calls inside runtime
@@ -246,6 +369,23 @@ type DebuggerCommand struct {
 	// GoroutineID is used to specify which thread to use with the SwitchGoroutine
 	// command.
 	GoroutineID int `json:"goroutineID,omitempty"`
+	// Pid is used to specify which inferior to use with the SwitchInferior
+	// command.
+	Pid int `json:"pid,omitempty"`
+	// TargetID is used to specify which target to use with the
+	// SwitchTarget command.
+	TargetID int `json:"targetID,omitempty"`
+	// StepTarget selects the function the Step command steps into, either by
+	// name or by its index in the list returned by StepTargets. All other
+	// calls on the current line are stepped over.
+	StepTarget string `json:"stepTarget,omitempty"`
+	// Expr is the expression evaluated by the Call command.
+	Expr string `json:"expr,omitempty"`
+	// UnsafeCall disables parameter escape checking for the Call command.
+	UnsafeCall bool `json:"unsafeCall,omitempty"`
+	// ReturnInfoLoadConfig is the load configuration used for the values
+	// returned by the Call command.
+	ReturnInfoLoadConfig *LoadConfig `json:"returnInfoLoadConfig,omitempty"`
 }
 
 // Informations about the current breakpoint
@@ -255,6 +395,12 @@ type BreakpointInfo struct {
 	Variables  []Variable   `json:"variables,omitempty"`
 	Arguments  []Variable   `json:"arguments,omitempty"`
 	Locals     []Variable   `json:"locals,omitempty"`
+	// WatchOldValue and WatchNewValue are the values of the watched
+	// expression before and after the access that triggered the watchpoint
+	WatchOldValue *Variable `json:"watchOldValue,omitempty"`
+	WatchNewValue *Variable `json:"watchNewValue,omitempty"`
+	// LogMessage is the message of a logpoint
+	LogMessage string `json:"logMessage,omitempty"`
 }
 
 type EvalScope struct {
@@ -275,12 +421,28 @@ const (
 	StepInstruction = "stepInstruction"
 	// Next continues to the next source line, not entering function calls.
 	Next = "next"
+	// ReverseNext is like Next but executes backwards (target must be a recording).
+	ReverseNext = "reverseNext"
+	// ReverseStep is like Step but executes backwards (target must be a recording).
+	ReverseStep = "reverseStep"
+	// ReverseStepOut continues backwards to the CALL instruction that called
+	// the current function (target must be a recording).
+	ReverseStepOut = "reverseStepOut"
+	// ReverseStepInstruction executes exactly 1 cpu instruction backwards
+	// (target must be a recording).
+	ReverseStepInstruction = "reverseStepInstruction"
 	// SwitchThread switches the debugger's current thread context.
 	SwitchThread = "switchThread"
 	// SwitchGoroutine switches the debugger's current thread context to the thread running the specified goroutine
 	SwitchGoroutine = "switchGoroutine"
+	// SwitchInferior switches the debugger's current process to the specified inferior
+	SwitchInferior = "switchInferior"
+	// SwitchTarget switches the debugger's current target to the specified target
+	SwitchTarget = "switchTarget"
 	// Halt suspends the process.
 	Halt = "halt"
+	// Call resumes process execution injecting a function call.
+	Call = "call"
 )
 
 type AssemblyFlavour int
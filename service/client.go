@@ -22,6 +22,8 @@ type Client interface {
 	Restart() ([]api.DiscardedBreakpoint, error)
 	// Restarts program from the specified position.
 	RestartFrom(pos string) ([]api.DiscardedBreakpoint, error)
+	// Rebuilds the program and restarts it.
+	RestartRebuild() ([]api.DiscardedBreakpoint, error)
 
 	// GetState returns the current debugger state.
 	GetState() (*api.DebuggerState, error)
@@ -34,15 +36,38 @@ type Client interface {
 	Next() (*api.DebuggerState, error)
 	// Step continues to the next source line, entering function calls.
 	Step() (*api.DebuggerState, error)
+	// StepTo continues to the next source line, entering only the function
+	// selected by target and stepping over other function calls.
+	StepTo(target string) (*api.DebuggerState, error)
+	// StepTargets returns the functions called by the current line.
+	StepTargets() ([]api.Function, error)
+	// Jump changes the next statement executed by the selected goroutine to
+	// loc, a line of the current function.
+	Jump(loc string) (*api.DebuggerState, error)
 	// StepOut continues to the return address of the current function
 	StepOut() (*api.DebuggerState, error)
+	// Call resumes process execution while making a function call.
+	Call(expr string, unsafe bool) (*api.DebuggerState, error)
 
 	// SingleStep will step a single cpu instruction.
 	StepInstruction() (*api.DebuggerState, error)
+
+	// ReverseNext continues backwards to the previous source line, not entering function calls.
+	ReverseNext() (*api.DebuggerState, error)
+	// ReverseStep continues backwards to the previous source line, entering function calls.
+	ReverseStep() (*api.DebuggerState, error)
+	// ReverseStepOut continues backwards to the call of the current function.
+	ReverseStepOut() (*api.DebuggerState, error)
+	// ReverseStepInstruction will step a single cpu instruction backwards.
+	ReverseStepInstruction() (*api.DebuggerState, error)
 	// SwitchThread switches the current thread context.
 	SwitchThread(threadID int) (*api.DebuggerState, error)
 	// SwitchGoroutine switches the current goroutine (and the current thread as well)
 	SwitchGoroutine(goroutineID int) (*api.DebuggerState, error)
+	// SwitchInferior switches the current inferior process.
+	SwitchInferior(pid int) (*api.DebuggerState, error)
+	// SwitchTarget switches the current target.
+	SwitchTarget(id int) (*api.DebuggerState, error)
 	// Halt suspends the process.
 	Halt() (*api.DebuggerState, error)
 
@@ -52,6 +77,35 @@ type Client interface {
 	GetBreakpointByName(name string) (*api.Breakpoint, error)
 	// CreateBreakpoint creates a new breakpoint.
 	CreateBreakpoint(*api.Breakpoint) (*api.Breakpoint, error)
+	// CreateWatchpoint creates a new watchpoint.
+	CreateWatchpoint(api.EvalScope, string, api.WatchType) (*api.Breakpoint, error)
+	// CreateCatchpoint creates a new catchpoint, kind is one of "panic",
+	// "throw" or "exit".
+	CreateCatchpoint(kind string) (*api.Breakpoint, error)
+	// CatchSyscalls enables or disables stopping on the specified system
+	// calls, or on all system calls if syscalls is empty.
+	CatchSyscalls(syscalls []string, enabled bool) error
+	// SetSignalHandling changes what happens when the target receives sig.
+	SetSignalHandling(sig string, stop, print, pass bool) error
+	// SetStepFilter replaces the package path patterns and file globs of
+	// the functions that step and stepout do not stop in.
+	SetStepFilter(packages, files []string) error
+	// AllowFunctionCalls changes whether expressions evaluated on the
+	// selected goroutine, and breakpoint conditions, can call functions.
+	AllowFunctionCalls(allow bool) error
+	// SetFollowForkMode changes which processes are debugged after the
+	// target forks, mode is "parent", "child" or "both".
+	SetFollowForkMode(mode string) error
+	// ListInferiors returns the processes being debugged and the current
+	// follow-fork-mode.
+	ListInferiors() ([]api.Inferior, string, error)
+	// ListTargets returns the programs being debugged.
+	ListTargets() ([]api.Target, error)
+	// AttachTarget attaches to the process pid and makes it the current
+	// target.
+	AttachTarget(pid int, path string) (api.Target, error)
+	// LaunchTarget starts a new process and makes it the current target.
+	LaunchTarget(processArgs []string, wd string) (api.Target, error)
 	// ListBreakpoints gets all breakpoints.
 	ListBreakpoints() ([]*api.Breakpoint, error)
 	// ClearBreakpoint deletes a breakpoint by ID.
@@ -74,13 +128,31 @@ type Client interface {
 	// EvalVariable returns a variable in the context of the current thread.
 	EvalVariable(scope api.EvalScope, symbol string, cfg api.LoadConfig) (*api.Variable, error)
 
+	// EvalAndRecord is like EvalVariable but also appends the value to the
+	// value history and returns its index.
+	EvalAndRecord(scope api.EvalScope, symbol string, cfg api.LoadConfig) (*api.Variable, int, error)
+
 	// SetVariable sets the value of a variable
 	SetVariable(scope api.EvalScope, symbol, value string) error
 
+	// ListConvenienceVariables returns the value history followed by the
+	// convenience variables.
+	ListConvenienceVariables() ([]api.Variable, error)
+	// ClearConvenienceVariables removes the convenience variable name or,
+	// if name is empty, all convenience variables and the value history.
+	ClearConvenienceVariables(name string) error
+
+	// ExamineMemory reads length bytes of memory starting at the address
+	// expr evaluates to, it returns the address and the data read.
+	ExamineMemory(scope api.EvalScope, expr string, length int) (uint64, []byte, error)
+
 	// ListSources lists all source files in the process matching filter.
 	ListSources(filter string) ([]string, error)
 	// ListFunctions lists all functions in the process matching filter.
 	ListFunctions(filter string) ([]string, error)
+	// FunctionReturnLocations returns the addresses of the return
+	// instructions of the function called fnName.
+	FunctionReturnLocations(fnName string) ([]uint64, error)
 	// ListTypes lists all types in the process matching filter.
 	ListTypes(filter string) ([]string, error)
 	// ListLocals lists all local variables in scope.
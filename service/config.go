@@ -1,6 +1,11 @@
 package service
 
-import "net"
+import (
+	"net"
+
+	"github.com/derekparker/delve/pkg/config"
+	"github.com/derekparker/delve/service/debugger"
+)
 
 // Config provides the configuration to start a Debugger and expose it with a
 // service.
@@ -32,6 +37,19 @@ type Config struct {
 	// Selects server backend.
 	Backend string
 
+	// ExecuteKind describes how the executable in ProcessArgs was obtained.
+	ExecuteKind debugger.ExecuteKind
+	// Package is the package that was built to obtain the executable, when
+	// ExecuteKind is ExecutingGeneratedFile or ExecutingGeneratedTest.
+	Package string
+	// BuildFlags are the flags that were passed to the compiler to build
+	// Package.
+	BuildFlags string
+
+	// PrettyPrinters change how the values of some types are returned to
+	// clients.
+	PrettyPrinters []config.PrettyPrinter
+
 	// DisconnectChan will be closed by the server when the client disconnects
 	DisconnectChan chan<- struct{}
 }
@@ -4,15 +4,19 @@ import (
 	"debug/gosym"
 	"errors"
 	"fmt"
-	"go/parser"
 	"log"
+	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/derekparker/delve/pkg/config"
+	"github.com/derekparker/delve/pkg/gobuild"
 	"github.com/derekparker/delve/pkg/proc"
 	"github.com/derekparker/delve/pkg/proc/core"
 	"github.com/derekparker/delve/pkg/proc/gdbserial"
@@ -32,7 +36,48 @@ type Debugger struct {
 	config *Config
 	// TODO(DO NOT MERGE WITHOUT) rename to targetMutex
 	processMutex sync.Mutex
-	target       proc.Process
+	// targets contains the programs being debugged, in the order they were
+	// started or attached to. The current one is embedded, commands only
+	// act on it and only the current target is resumed.
+	targets []*debugTarget
+	*debugTarget
+	// convVars contains the value history and the convenience variables,
+	// shared by all targets and kept across restarts.
+	convVars proc.ConvenienceVariables
+	// prettyPrinters are applied to every variable returned to clients.
+	prettyPrinters *api.PrettyPrinters
+}
+
+// debugTarget is one of the programs being debugged, together with the
+// state that is restored when it is restarted.
+type debugTarget struct {
+	id int
+	// targetConfig describes how the target was started.
+	targetConfig Config
+	// target is the process that was launched or attached to, it also
+	// controls the children it forked, see proc.Process.Inferiors.
+	target proc.Process
+	// disabledBreakpoints contains the user breakpoints that have been
+	// disabled, indexed by the pid of the inferior they belong to and by
+	// ID.
+	disabledBreakpoints map[int]map[int]*proc.Breakpoint
+	// catchSyscalls and syscalls remember the last call to CatchSyscalls,
+	// to restore syscall catchpoints after a restart.
+	catchSyscalls bool
+	syscalls      []string
+	// signalHandling contains the signal handling set with
+	// SetSignalHandling, restored after a restart.
+	signalHandling map[string]proc.SignalHandling
+	// skipPackages and skipFiles are the step filter set with
+	// SetStepFilter, restored after a restart.
+	skipPackages []string
+	skipFiles    []string
+	// followForkMode is set with SetFollowForkMode, restored after a
+	// restart.
+	followForkMode proc.FollowForkMode
+	// allowFunctionCalls is set with AllowFunctionCalls, restored after a
+	// restart.
+	allowFunctionCalls bool
 }
 
 // Config provides the configuration to start a Debugger.
@@ -55,14 +100,46 @@ type Config struct {
 	CoreFile string
 	// Backend specifies the debugger backend.
 	Backend string
+
+	// ExecuteKind describes how the executable in ProcessArgs was obtained.
+	ExecuteKind ExecuteKind
+	// Package is the package built to obtain the executable, used to
+	// rebuild it on restart.
+	Package string
+	// BuildFlags are the flags passed to the compiler when building
+	// Package.
+	BuildFlags string
+
+	// PrettyPrinters change how the values of some types are returned.
+	PrettyPrinters []config.PrettyPrinter
 }
 
+// ExecuteKind describes how the executable being debugged was obtained.
+type ExecuteKind int
+
+const (
+	// ExecutingExistingFile is an executable that was already on disk.
+	ExecutingExistingFile = ExecuteKind(iota)
+	// ExecutingGeneratedFile is an executable built by 'dlv debug'.
+	ExecutingGeneratedFile
+	// ExecutingGeneratedTest is a test executable built by 'dlv test'.
+	ExecutingGeneratedTest
+	// ExecutingOther is used when attaching to a process or opening a
+	// core file or a recording.
+	ExecutingOther
+)
+
 // New creates a new Debugger.
 func New(config *Config) (*Debugger, error) {
 	d := &Debugger{
 		config: config,
 	}
 
+	var err error
+	if d.prettyPrinters, err = api.NewPrettyPrinters(config.PrettyPrinters); err != nil {
+		return nil, err
+	}
+
 	// Create the process by either attaching or launching.
 	switch {
 	case d.config.AttachPid > 0:
@@ -75,7 +152,7 @@ func New(config *Config) (*Debugger, error) {
 		if err != nil {
 			return nil, attachErrorMessage(d.config.AttachPid, err)
 		}
-		d.target = p
+		d.addTarget(p, *d.config)
 
 	case d.config.CoreFile != "":
 		var p proc.Process
@@ -91,7 +168,7 @@ func New(config *Config) (*Debugger, error) {
 		if err != nil {
 			return nil, err
 		}
-		d.target = p
+		d.addTarget(p, *d.config)
 
 	default:
 		log.Printf("launching process with args: %v", d.config.ProcessArgs)
@@ -102,11 +179,41 @@ func New(config *Config) (*Debugger, error) {
 			}
 			return nil, err
 		}
-		d.target = p
+		d.addTarget(p, *d.config)
 	}
 	return d, nil
 }
 
+// addTarget adds p, started as described by cfg, to the programs being
+// debugged and makes it the current target.
+func (d *Debugger) addTarget(p proc.Process, cfg Config) *debugTarget {
+	t := d.newTarget(p, cfg)
+	d.targets = append(d.targets, t)
+	d.debugTarget = t
+	return t
+}
+
+// newTarget returns the target for p, without adding it to the programs
+// being debugged.
+func (d *Debugger) newTarget(p proc.Process, cfg Config) *debugTarget {
+	return &debugTarget{
+		id:                  len(d.targets) + 1,
+		targetConfig:        cfg,
+		target:              p,
+		disabledBreakpoints: make(map[int]map[int]*proc.Breakpoint),
+	}
+}
+
+// findTarget returns the target with the specified ID.
+func (d *Debugger) findTarget(id int) *debugTarget {
+	for _, t := range d.targets {
+		if t.id == id {
+			return t
+		}
+	}
+	return nil
+}
+
 func (d *Debugger) Launch(processArgs []string, wd string) (proc.Process, error) {
 	switch d.config.Backend {
 	case "native":
@@ -147,6 +254,34 @@ func (d *Debugger) Attach(pid int, path string) (proc.Process, error) {
 	}
 }
 
+func (t *debugTarget) convertThread(th proc.Thread) *api.Thread {
+	r := api.ConvertThread(th)
+	r.TargetID = t.id
+	return r
+}
+
+// inferior returns the process of the target that stopped last or was
+// selected with SwitchInferior, commands that act on a single process are
+// sent to it.
+func (t *debugTarget) inferior() proc.Process {
+	return t.target.CurrentInferior()
+}
+
+// disabled returns the disabled breakpoints of the current inferior.
+func (t *debugTarget) disabled() map[int]*proc.Breakpoint {
+	pid := t.inferior().Pid()
+	if t.disabledBreakpoints[pid] == nil {
+		t.disabledBreakpoints[pid] = make(map[int]*proc.Breakpoint)
+	}
+	return t.disabledBreakpoints[pid]
+}
+
+func (t *debugTarget) convertGoroutine(g *proc.G) *api.Goroutine {
+	r := api.ConvertGoroutine(g)
+	r.TargetID = t.id
+	return r
+}
+
 // ProcessPid returns the PID of the process
 // the debugger is debugging.
 func (d *Debugger) ProcessPid() int {
@@ -156,36 +291,68 @@ func (d *Debugger) ProcessPid() int {
 // LastModified returns the time that the process' executable was last
 // modified.
 func (d *Debugger) LastModified() time.Time {
-	return d.target.BinInfo().LastModified()
+	return d.inferior().BinInfo().LastModified()
 }
 
-// Detach detaches from the target process.
-// If `kill` is true we will kill the process after
+// Detach detaches from all the target processes.
+// If `kill` is true we will kill the processes after
 // detaching.
 func (d *Debugger) Detach(kill bool) error {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	return d.detach(kill)
+	var err error
+	for _, t := range d.targets {
+		if err1 := t.detach(kill); err1 != nil && err == nil {
+			err = err1
+		}
+	}
+	return err
+}
+
+// AttachedToExistingProcess returns true if one of the targets is a
+// process the debugger attached to, rather than one it launched.
+func (d *Debugger) AttachedToExistingProcess() bool {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+	for _, t := range d.targets {
+		if t.targetConfig.AttachPid != 0 {
+			return true
+		}
+	}
+	return false
 }
 
-func (d *Debugger) detach(kill bool) error {
-	if d.config.AttachPid == 0 {
+// detach detaches from the target, processes that were launched by the
+// debugger are always killed, processes it attached to only if kill is
+// true.
+func (t *debugTarget) detach(kill bool) error {
+	if t.targetConfig.AttachPid == 0 {
 		kill = true
 	}
-	return d.target.Detach(kill)
+	return t.target.Detach(kill)
 }
 
-// Restart will restart the target process, first killing
+// Restart will restart the current target process, first killing
 // and then exec'ing it again.
 // If the target process is a recording it will restart it from the given
 // position. If pos starts with 'c' it's a checkpoint ID, otherwise it's an
 // event number.
-func (d *Debugger) Restart(pos string) ([]api.DiscardedBreakpoint, error) {
+// If rebuild is true the executable is built again, with the same build
+// flags, before launching it and breakpoints are set again using the
+// location spec they were created from.
+func (d *Debugger) Restart(pos string, rebuild bool) ([]api.DiscardedBreakpoint, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
+	if d.targetConfig.AttachPid != 0 {
+		return nil, errors.New("cannot restart process Delve did not create")
+	}
+
 	if recorded, _ := d.target.Recorded(); recorded {
+		if rebuild {
+			return nil, errors.New("can not rebuild a recording")
+		}
 		return nil, d.target.Restart(pos)
 	}
 
@@ -193,6 +360,25 @@ func (d *Debugger) Restart(pos string) ([]api.DiscardedBreakpoint, error) {
 		return nil, proc.NotRecordedErr
 	}
 
+	// The executable is rebuilt before the old process is killed, so that a
+	// build error leaves it running. It is built under a different name
+	// because the old process is still using it.
+	var rebuilt string
+	if rebuild {
+		switch d.targetConfig.ExecuteKind {
+		case ExecutingGeneratedFile, ExecutingGeneratedTest:
+			// ok
+		default:
+			return nil, errors.New("can not rebuild an executable that was not built by 'dlv debug' or 'dlv test'")
+		}
+		rebuilt = d.targetConfig.ProcessArgs[0] + ".rebuild"
+		if err := d.rebuild(rebuilt); err != nil {
+			os.Remove(rebuilt)
+			return nil, fmt.Errorf("could not rebuild process: %s", err)
+		}
+		defer os.Remove(rebuilt)
+	}
+
 	if !d.target.Exited() {
 		// Ensure the process is in a PTRACE_STOP.
 		if err := stopProcess(d.ProcessPid()); err != nil {
@@ -202,35 +388,147 @@ func (d *Debugger) Restart(pos string) ([]api.DiscardedBreakpoint, error) {
 	if err := d.detach(true); err != nil {
 		return nil, err
 	}
-	p, err := d.Launch(d.config.ProcessArgs, d.config.WorkingDir)
+	if rebuild {
+		if err := os.Rename(rebuilt, d.targetConfig.ProcessArgs[0]); err != nil {
+			return nil, fmt.Errorf("could not rebuild process: %s", err)
+		}
+	}
+	p, err := d.Launch(d.targetConfig.ProcessArgs, d.targetConfig.WorkingDir)
 	if err != nil {
 		return nil, fmt.Errorf("could not launch process: %s", err)
 	}
 	discarded := []api.DiscardedBreakpoint{}
-	for _, oldBp := range d.breakpoints() {
+	oldBps := d.breakpoints()
+	d.disabledBreakpoints = make(map[int]map[int]*proc.Breakpoint)
+	d.target = p
+	for _, oldBp := range oldBps {
 		if oldBp.ID < 0 {
+			if strings.HasPrefix(oldBp.Name, proc.CatchpointPrefix) {
+				newBp, err := proc.SetCatchpoint(p, oldBp.Name[len(proc.CatchpointPrefix):])
+				if err != nil {
+					discarded = append(discarded, api.DiscardedBreakpoint{oldBp, err.Error()})
+					continue
+				}
+				if err := copyLogicalBreakpointInfo(newBp, oldBp); err != nil {
+					return nil, err
+				}
+				if oldBp.Disabled {
+					if err := proc.DisableBreakpoint(p, newBp); err != nil {
+						return nil, err
+					}
+					d.disabled()[newBp.ID] = newBp
+				}
+			}
+			continue
+		}
+		if oldBp.WatchType != 0 {
+			discarded = append(discarded, api.DiscardedBreakpoint{oldBp, "watchpoints can not be restored after a restart"})
 			continue
 		}
-		if len(oldBp.File) > 0 {
+		addrs := oldBp.Addrs
+		switch {
+		case rebuild:
+			var err error
+			addrs, err = d.relocateBreakpoint(oldBp)
+			if err != nil {
+				discarded = append(discarded, api.DiscardedBreakpoint{oldBp, err.Error()})
+				continue
+			}
+		case len(oldBp.File) > 0:
 			var err error
-			oldBp.Addr, err = proc.FindFileLocation(p, oldBp.File, oldBp.Line)
+			addrs, err = proc.FindFileLocations(p, oldBp.File, oldBp.Line)
 			if err != nil {
 				discarded = append(discarded, api.DiscardedBreakpoint{oldBp, err.Error()})
 				continue
 			}
 		}
-		newBp, err := p.SetBreakpoint(oldBp.Addr, proc.UserBreakpoint, nil)
+		newBp, err := proc.SetLogicalBreakpoint(p, addrs)
 		if err != nil {
 			return nil, err
 		}
-		if err := copyBreakpointInfo(newBp, oldBp); err != nil {
+		setLogicalBreakpointID(newBp, oldBp.ID)
+		if err := copyLogicalBreakpointInfo(newBp, oldBp); err != nil {
 			return nil, err
 		}
+		if oldBp.Disabled {
+			if err := proc.DisableBreakpoint(p, newBp); err != nil {
+				return nil, err
+			}
+			d.disabled()[newBp.ID] = newBp
+		}
+	}
+	if err := d.restoreSettings(p); err != nil {
+		return nil, err
 	}
-	d.target = p
 	return discarded, nil
 }
 
+// restoreSettings applies the syscall catchpoints, signal handling, step
+// filter, follow-fork-mode and function call setting of t to p.
+func (t *debugTarget) restoreSettings(p proc.Process) error {
+	if t.catchSyscalls {
+		if err := p.CatchSyscalls(t.syscalls, true); err != nil {
+			return err
+		}
+	}
+	for sig, h := range t.signalHandling {
+		if err := p.SetSignalHandling(sig, h); err != nil {
+			return err
+		}
+	}
+	if err := proc.SetStepFilter(p, t.skipPackages, t.skipFiles); err != nil {
+		return err
+	}
+	if t.followForkMode != proc.FollowParent {
+		if err := p.SetFollowForkMode(t.followForkMode); err != nil {
+			return err
+		}
+	}
+	proc.AllowFunctionCalls(p, t.allowFunctionCalls)
+	return nil
+}
+
+// rebuild builds the executable again into debugname, the same way 'dlv
+// debug' or 'dlv test' originally built it.
+func (d *Debugger) rebuild(debugname string) error {
+	switch d.targetConfig.ExecuteKind {
+	case ExecutingGeneratedTest:
+		return gobuild.GoTestBuild(debugname, d.targetConfig.Package, d.targetConfig.BuildFlags)
+	default:
+		return gobuild.GoBuild(debugname, d.targetConfig.Package, d.targetConfig.BuildFlags)
+	}
+}
+
+// relocateBreakpoint returns the addresses of bp in the rebuilt process,
+// resolving again the location spec bp was created from. Breakpoints
+// created without a location spec, or from a spec that is relative to the
+// stopped position, fall back to their file and line.
+func (d *Debugger) relocateBreakpoint(bp *api.Breakpoint) ([]uint64, error) {
+	if bp.LocationSpec != "" {
+		loc, err := parseLocationSpec(bp.LocationSpec)
+		if err != nil {
+			return nil, err
+		}
+		switch loc.(type) {
+		case *OffsetLocationSpec, *LineLocationSpec:
+			// relative to the position of the old process
+		case *AddrLocationSpec:
+			return nil, errors.New("address breakpoints can not be restored after a rebuild")
+		default:
+			scope, _ := proc.ConvertEvalScope(d.inferior(), -1, 0)
+			locs, err := loc.Find(d, scope, bp.LocationSpec)
+			if err != nil {
+				return nil, err
+			}
+			return LocationAddrs(locs), nil
+		}
+	}
+	if bp.File == "" {
+		return nil, errors.New("address breakpoints can not be restored after a rebuild")
+	}
+	return proc.FindFileLocations(d.inferior(), bp.File, bp.Line)
+}
+
 // State returns the current state of the debugger.
 func (d *Debugger) State() (*api.DebuggerState, error) {
 	d.processMutex.Lock()
@@ -239,7 +537,7 @@ func (d *Debugger) State() (*api.DebuggerState, error) {
 }
 
 func (d *Debugger) state() (*api.DebuggerState, error) {
-	if d.target.Exited() {
+	if d.inferior().Exited() {
 		return nil, proc.ProcessExitedError{Pid: d.ProcessPid()}
 	}
 
@@ -248,32 +546,50 @@ func (d *Debugger) state() (*api.DebuggerState, error) {
 		goroutine *api.Goroutine
 	)
 
-	if d.target.SelectedGoroutine() != nil {
-		goroutine = api.ConvertGoroutine(d.target.SelectedGoroutine())
+	if d.inferior().SelectedGoroutine() != nil {
+		goroutine = d.convertGoroutine(d.inferior().SelectedGoroutine())
 	}
 
 	state = &api.DebuggerState{
 		SelectedGoroutine: goroutine,
-		Exited:            d.target.Exited(),
+		TargetID:          d.id,
+		Pid:               d.inferior().Pid(),
+		Exited:            d.inferior().Exited(),
 	}
 
-	for _, thread := range d.target.ThreadList() {
-		th := api.ConvertThread(thread)
+	for _, thread := range d.inferior().ThreadList() {
+		th := d.convertThread(thread)
+		th.ReturnValues = d.convertVars(thread.Common().ReturnValues(proc.LoadConfig{true, 1, 64, 64, -1}))
 		state.Threads = append(state.Threads, th)
-		if thread.ThreadID() == d.target.CurrentThread().ThreadID() {
+		if thread.ThreadID() == d.inferior().CurrentThread().ThreadID() {
 			state.CurrentThread = th
 		}
 	}
 
-	for _, bp := range d.target.Breakpoints() {
-		if bp.Internal() {
+	for _, thread := range d.inferior().ThreadList() {
+		if bp, active, _ := thread.Breakpoint(); active {
+			for _, wp := range bp.WatchOutOfScope {
+				state.WatchOutOfScope = append(state.WatchOutOfScope, api.ConvertBreakpoint(wp))
+			}
+		}
+	}
+
+	for _, bp := range d.inferior().Breakpoints() {
+		if bp.Internal() && bp.Kind != proc.WatchOutOfScopeBreakpoint {
 			state.NextInProgress = true
 			break
 		}
 	}
 
-	if recorded, _ := d.target.Recorded(); recorded {
-		state.When, _ = d.target.When()
+	if sc := d.inferior().CurrentThread().Syscall(); sc != nil {
+		state.Syscall = api.ConvertSyscall(sc)
+	}
+	if sig := d.inferior().CurrentThread().Signal(); sig != nil {
+		state.Signal = api.ConvertSignal(*sig)
+	}
+
+	if recorded, _ := d.inferior().Recorded(); recorded {
+		state.When, _ = d.inferior().When()
 	}
 
 	return state, nil
@@ -286,7 +602,7 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 
 	var (
 		createdBp *api.Breakpoint
-		addr      uint64
+		addrs     []uint64
 		err       error
 	)
 
@@ -305,43 +621,271 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 		if runtime.GOOS == "windows" {
 			// Accept fileName which is case-insensitive and slash-insensitive match
 			fileNameNormalized := strings.ToLower(filepath.ToSlash(fileName))
-			for symFile := range d.target.BinInfo().Sources() {
+			for symFile := range d.inferior().BinInfo().Sources() {
 				if fileNameNormalized == strings.ToLower(filepath.ToSlash(symFile)) {
 					fileName = symFile
 					break
 				}
 			}
 		}
-		addr, err = proc.FindFileLocation(d.target, fileName, requestedBp.Line)
+		addrs, err = proc.FindFileLocations(d.inferior(), fileName, requestedBp.Line)
 	case len(requestedBp.FunctionName) > 0:
+		var addr uint64
 		if requestedBp.Line >= 0 {
-			addr, err = proc.FindFunctionLocation(d.target, requestedBp.FunctionName, false, requestedBp.Line)
+			addr, err = proc.FindFunctionLocation(d.inferior(), requestedBp.FunctionName, false, requestedBp.Line)
 		} else {
-			addr, err = proc.FindFunctionLocation(d.target, requestedBp.FunctionName, true, 0)
+			addr, err = proc.FindFunctionLocation(d.inferior(), requestedBp.FunctionName, true, 0)
 		}
+		addrs = []uint64{addr}
+	case len(requestedBp.Addrs) > 0:
+		addrs = requestedBp.Addrs
 	default:
-		addr = requestedBp.Addr
+		addrs = []uint64{requestedBp.Addr}
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	bp, err := d.target.SetBreakpoint(addr, proc.UserBreakpoint, nil)
+	bp, err := proc.SetLogicalBreakpoint(d.inferior(), addrs)
 	if err != nil {
 		return nil, err
 	}
-	if err := copyBreakpointInfo(bp, requestedBp); err != nil {
-		if _, err1 := d.target.ClearBreakpoint(bp.Addr); err1 != nil {
+	d.uniqueBreakpointID(bp)
+	if err := copyLogicalBreakpointInfo(bp, requestedBp); err != nil {
+		if _, err1 := proc.ClearLogicalBreakpoint(d.inferior(), bp.Addr); err1 != nil {
 			err = fmt.Errorf("error while creating breakpoint: %v, additionally the breakpoint could not be properly rolled back: %v", err, err1)
 		}
 		return nil, err
 	}
+	if err := d.setBreakpointDisabled(bp, requestedBp.Disabled); err != nil {
+		return nil, err
+	}
 	createdBp = api.ConvertBreakpoint(bp)
 	log.Printf("created breakpoint: %#v", createdBp)
 	return createdBp, nil
 }
 
+// CreateWatchpoint creates a watchpoint on the specified expression.
+func (d *Debugger) CreateWatchpoint(goid, frame int, expr string, wtype api.WatchType) (*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if wtype&(api.WatchRead|api.WatchWrite) == 0 {
+		return nil, errors.New("watchpoint must be triggered by reads, writes or both")
+	}
+	bp, err := proc.SetWatchpoint(d.inferior(), goid, frame, expr, proc.WatchType(wtype), nil)
+	if err != nil {
+		return nil, err
+	}
+	d.uniqueBreakpointID(bp)
+	createdBp := api.ConvertBreakpoint(bp)
+	log.Printf("created watchpoint: %#v", createdBp)
+	return createdBp, nil
+}
+
+// CreateCatchpoint sets a catchpoint of the specified kind, see
+// proc.SetCatchpoint.
+func (d *Debugger) CreateCatchpoint(kind string) (*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	bp, err := proc.SetCatchpoint(d.inferior(), kind)
+	if err != nil {
+		return nil, err
+	}
+	createdBp := api.ConvertBreakpoint(bp)
+	log.Printf("created catchpoint: %#v", createdBp)
+	return createdBp, nil
+}
+
+// CatchSyscalls enables or disables stopping the target when it enters or
+// exits one of the named system calls, or any system call if names is
+// empty.
+func (d *Debugger) CatchSyscalls(names []string, enabled bool) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if err := d.inferior().CatchSyscalls(names, enabled); err != nil {
+		return err
+	}
+	d.catchSyscalls = enabled
+	d.syscalls = names
+	return nil
+}
+
+// SetSignalHandling changes what happens when the target receives sig.
+func (d *Debugger) SetSignalHandling(sig string, stop, print, pass bool) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	h := proc.SignalHandling{Stop: stop, Print: print, Pass: pass}
+	if err := d.inferior().SetSignalHandling(sig, h); err != nil {
+		return err
+	}
+	if d.signalHandling == nil {
+		d.signalHandling = make(map[string]proc.SignalHandling)
+	}
+	d.signalHandling[sig] = h
+	return nil
+}
+
+// SetStepFilter changes the packages and files that step and stepout treat
+// as opaque, see proc.SetStepFilter.
+func (d *Debugger) SetStepFilter(packages, files []string) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if err := proc.SetStepFilter(d.inferior(), packages, files); err != nil {
+		return err
+	}
+	d.skipPackages = packages
+	d.skipFiles = files
+	return nil
+}
+
+// AllowFunctionCalls changes whether the expressions evaluated on the
+// selected goroutine, and breakpoint conditions, can call functions, see
+// proc.AllowFunctionCalls.
+func (d *Debugger) AllowFunctionCalls(allow bool) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	proc.AllowFunctionCalls(d.inferior(), allow)
+	d.allowFunctionCalls = allow
+	return nil
+}
+
+// SetFollowForkMode changes which processes are debugged after the target
+// forks, mode is "parent", "child" or "both".
+func (d *Debugger) SetFollowForkMode(mode string) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	m, err := proc.ParseFollowForkMode(mode)
+	if err != nil {
+		return err
+	}
+	if err := d.target.SetFollowForkMode(m); err != nil {
+		return err
+	}
+	d.followForkMode = m
+	return nil
+}
+
+// Inferiors returns the processes being debugged and the current
+// follow-fork-mode.
+func (d *Debugger) Inferiors() ([]api.Inferior, string) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	cur := d.inferior()
+	inferiors := []api.Inferior{}
+	for _, p := range d.target.Inferiors() {
+		inferiors = append(inferiors, api.ConvertInferior(p, p == cur))
+	}
+	return inferiors, d.followForkMode.String()
+}
+
+// Targets returns the programs being debugged.
+func (d *Debugger) Targets() []api.Target {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	targets := make([]api.Target, 0, len(d.targets))
+	for _, t := range d.targets {
+		targets = append(targets, api.Target{
+			ID:      t.id,
+			Pid:     t.target.Pid(),
+			Path:    t.target.BinInfo().Path(),
+			Current: t == d.debugTarget,
+			Exited:  t.target.Exited(),
+		})
+	}
+	return targets
+}
+
+// AttachTarget attaches to the process pid and adds it to the programs
+// being debugged, as the current target. Path is the executable of the
+// process, it is only needed by the lldb backend on macOS.
+// The new target inherits the syscall catchpoints, signal handling, step
+// filter and follow-fork-mode of the current target.
+func (d *Debugger) AttachTarget(pid int, path string) (api.Target, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	log.Printf("attaching to pid %d", pid)
+	p, err := d.Attach(pid, path)
+	if err != nil {
+		return api.Target{}, attachErrorMessage(pid, err)
+	}
+	cfg := Config{AttachPid: pid, Backend: d.config.Backend, ExecuteKind: ExecutingOther}
+	if path != "" {
+		cfg.ProcessArgs = []string{path}
+	}
+	return d.addTargetLike(p, cfg)
+}
+
+// LaunchTarget starts a new process with processArgs and adds it to the
+// programs being debugged, as the current target. The new target inherits
+// the syscall catchpoints, signal handling, step filter and
+// follow-fork-mode of the current target.
+func (d *Debugger) LaunchTarget(processArgs []string, wd string) (api.Target, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	log.Printf("launching process with args: %v", processArgs)
+	p, err := d.Launch(processArgs, wd)
+	if err != nil {
+		if err != proc.NotExecutableErr && err != proc.UnsupportedLinuxArchErr && err != proc.UnsupportedWindowsArchErr && err != proc.UnsupportedDarwinArchErr {
+			err = fmt.Errorf("could not launch process: %s", err)
+		}
+		return api.Target{}, err
+	}
+	cfg := Config{ProcessArgs: processArgs, WorkingDir: wd, Backend: d.config.Backend, ExecuteKind: ExecutingExistingFile}
+	return d.addTargetLike(p, cfg)
+}
+
+// addTargetLike adds p as a new target, with the settings of the current
+// target. If the settings can not be applied to p it is detached and the
+// current target does not change.
+func (d *Debugger) addTargetLike(p proc.Process, cfg Config) (api.Target, error) {
+	old := d.debugTarget
+	t := d.newTarget(p, cfg)
+	t.catchSyscalls, t.syscalls = old.catchSyscalls, old.syscalls
+	for sig, h := range old.signalHandling {
+		if t.signalHandling == nil {
+			t.signalHandling = make(map[string]proc.SignalHandling)
+		}
+		t.signalHandling[sig] = h
+	}
+	t.skipPackages, t.skipFiles = old.skipPackages, old.skipFiles
+	t.followForkMode = old.followForkMode
+	t.allowFunctionCalls = old.allowFunctionCalls
+	if err := t.restoreSettings(p); err != nil {
+		if err1 := t.detach(false); err1 != nil {
+			err = fmt.Errorf("%v, additionally the process could not be detached: %v", err, err1)
+		}
+		return api.Target{}, err
+	}
+	d.targets = append(d.targets, t)
+	d.debugTarget = t
+	return api.Target{ID: t.id, Pid: p.Pid(), Path: p.BinInfo().Path(), Current: true}, nil
+}
+
+// switchTarget makes the target with the specified ID current.
+func (d *Debugger) switchTarget(id int) error {
+	t := d.findTarget(id)
+	if t == nil {
+		return fmt.Errorf("no target with ID %d", id)
+	}
+	if t.target.Exited() {
+		return proc.ProcessExitedError{Pid: t.target.Pid()}
+	}
+	d.debugTarget = t
+	return nil
+}
+
 func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
@@ -353,24 +897,74 @@ func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	if err := api.ValidBreakpointName(amend.Name); err != nil {
 		return err
 	}
-	return copyBreakpointInfo(original, amend)
+	if err := copyLogicalBreakpointInfo(original, amend); err != nil {
+		return err
+	}
+	return d.setBreakpointDisabled(original, amend.Disabled)
+}
+
+// setBreakpointDisabled disables or enables the logical breakpoint bp
+// belongs to.
+func (d *Debugger) setBreakpointDisabled(bp *proc.Breakpoint, disabled bool) error {
+	switch {
+	case disabled && !bp.Disabled:
+		if err := proc.DisableBreakpoint(d.inferior(), bp); err != nil {
+			return err
+		}
+		d.disabled()[bp.ID] = bp
+	case !disabled && bp.Disabled:
+		if _, err := proc.EnableBreakpoint(d.inferior(), bp); err != nil {
+			return err
+		}
+		delete(d.disabled(), bp.ID)
+	}
+	return nil
 }
 
 func (d *Debugger) CancelNext() error {
-	return d.target.ClearInternalBreakpoints()
+	return d.inferior().ClearInternalBreakpoints()
+}
+
+// copyLogicalBreakpointInfo calls copyBreakpointInfo on every breakpoint
+// that shares the ID of bp.
+func copyLogicalBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) error {
+	for _, lbp := range bp.Logical() {
+		if err := copyBreakpointInfo(lbp, requested); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err error) {
 	bp.Name = requested.Name
+	bp.LocationSpec = requested.LocationSpec
 	bp.Tracepoint = requested.Tracepoint
 	bp.Goroutine = requested.Goroutine
 	bp.Stacktrace = requested.Stacktrace
 	bp.Variables = requested.Variables
+	if expr, ok := proc.CatchpointExpr(bp); ok {
+		// the first variable of a catchpoint is the value it reports, which
+		// depends on the function each of its breakpoints is set on
+		bp.Variables = []string{expr}
+		if len(requested.Variables) > 1 {
+			bp.Variables = append(bp.Variables, requested.Variables[1:]...)
+		}
+	}
+	bp.LogMessage = requested.LogMessage
+	bp.TraceReturn = requested.TraceReturn
 	bp.LoadArgs = api.LoadConfigToProc(requested.LoadArgs)
 	bp.LoadLocals = api.LoadConfigToProc(requested.LoadLocals)
 	bp.Cond = nil
 	if requested.Cond != "" {
-		bp.Cond, err = parser.ParseExpr(requested.Cond)
+		bp.Cond, err = proc.ParseExpr(requested.Cond)
+		if err != nil {
+			return err
+		}
+	}
+	bp.HitCond = nil
+	if requested.HitCond != "" {
+		bp.HitCond, err = proc.ParseHitCondition(requested.HitCond)
 	}
 	return err
 }
@@ -380,8 +974,19 @@ func (d *Debugger) ClearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	var clearedBp *api.Breakpoint
-	bp, err := d.target.ClearBreakpoint(requestedBp.Addr)
+	var (
+		clearedBp *api.Breakpoint
+		bp        *proc.Breakpoint
+		err       error
+	)
+	if dbp := d.disabled()[requestedBp.ID]; dbp != nil {
+		delete(d.disabled(), dbp.ID)
+		bp = dbp
+	} else if wp := d.inferior().Breakpoints()[requestedBp.Addr]; wp != nil && wp.WatchType != 0 {
+		bp, err = proc.ClearWatchpoint(d.inferior(), requestedBp.Addr)
+	} else {
+		bp, err = proc.ClearLogicalBreakpoint(d.inferior(), requestedBp.Addr)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Can't clear breakpoint @%x: %s", requestedBp.Addr, err)
 	}
@@ -399,12 +1004,15 @@ func (d *Debugger) Breakpoints() []*api.Breakpoint {
 
 func (d *Debugger) breakpoints() []*api.Breakpoint {
 	bps := []*api.Breakpoint{}
-	for _, bp := range d.target.Breakpoints() {
-		if bp.Internal() {
+	for _, bp := range d.inferior().Breakpoints() {
+		if bp.Internal() || !isFirstAddr(bp) {
 			continue
 		}
 		bps = append(bps, api.ConvertBreakpoint(bp))
 	}
+	for _, bp := range d.disabled() {
+		bps = append(bps, api.ConvertBreakpoint(bp))
+	}
 	return bps
 }
 
@@ -421,12 +1029,68 @@ func (d *Debugger) FindBreakpoint(id int) *api.Breakpoint {
 }
 
 func (d *Debugger) findBreakpoint(id int) *proc.Breakpoint {
-	for _, bp := range d.target.Breakpoints() {
-		if bp.ID == id {
+	for _, bp := range d.inferior().Breakpoints() {
+		if bp.ID == id && isFirstAddr(bp) {
 			return bp
 		}
 	}
-	return nil
+	return d.disabled()[id]
+}
+
+// uniqueBreakpointID renumbers the logical breakpoint bp, just created in
+// the current target, if its ID is already used by another breakpoint of
+// any target. Breakpoint IDs are allocated by each process independently,
+// renumbering them keeps the IDs reported to clients unique across
+// targets. Catchpoints are excluded, their IDs identify their kind.
+func (d *Debugger) uniqueBreakpointID(bp *proc.Breakpoint) {
+	lbps := bp.Logical()
+	max, used := 0, false
+	check := func(other *proc.Breakpoint) {
+		if other.Internal() || other.ID < 0 {
+			return
+		}
+		for _, lbp := range lbps {
+			if other == lbp {
+				return
+			}
+		}
+		if other.ID > max {
+			max = other.ID
+		}
+		if other.ID == bp.ID {
+			used = true
+		}
+	}
+	for _, t := range d.targets {
+		for _, p := range t.target.Inferiors() {
+			for _, other := range p.Breakpoints() {
+				check(other)
+			}
+		}
+		for _, disabled := range t.disabledBreakpoints {
+			for _, other := range disabled {
+				check(other)
+			}
+		}
+	}
+	if used {
+		setLogicalBreakpointID(bp, max+1)
+	}
+}
+
+// setLogicalBreakpointID changes the ID of all the breakpoints that form
+// the logical breakpoint bp.
+func setLogicalBreakpointID(bp *proc.Breakpoint, id int) {
+	for _, lbp := range bp.Logical() {
+		lbp.ID = id
+	}
+}
+
+// isFirstAddr returns true if bp is the breakpoint set on the first
+// address of its logical breakpoint, it is used to report each logical
+// breakpoint only once.
+func isFirstAddr(bp *proc.Breakpoint) bool {
+	return bp.Addrs()[0] == bp.Addr
 }
 
 // FindBreakpointByName returns the breakpoint specified by 'name'
@@ -450,13 +1114,13 @@ func (d *Debugger) Threads() ([]*api.Thread, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	if d.target.Exited() {
+	if d.inferior().Exited() {
 		return nil, proc.ProcessExitedError{Pid: d.ProcessPid()}
 	}
 
 	threads := []*api.Thread{}
-	for _, th := range d.target.ThreadList() {
-		threads = append(threads, api.ConvertThread(th))
+	for _, th := range d.inferior().ThreadList() {
+		threads = append(threads, d.convertThread(th))
 	}
 	return threads, nil
 }
@@ -466,13 +1130,13 @@ func (d *Debugger) FindThread(id int) (*api.Thread, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	if d.target.Exited() {
+	if d.inferior().Exited() {
 		return nil, proc.ProcessExitedError{Pid: d.ProcessPid()}
 	}
 
-	for _, th := range d.target.ThreadList() {
+	for _, th := range d.inferior().ThreadList() {
 		if th.ThreadID() == id {
-			return api.ConvertThread(th), nil
+			return d.convertThread(th), nil
 		}
 	}
 	return nil, nil
@@ -486,7 +1150,7 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 		// RequestManualStop does not invoke any ptrace syscalls, so it's safe to
 		// access the process directly.
 		log.Print("halting")
-		err = d.target.RequestManualStop()
+		err = d.inferior().RequestManualStop()
 	}
 
 	withBreakpointInfo := true
@@ -495,37 +1159,75 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	defer d.processMutex.Unlock()
 
 	switch command.Name {
-	case api.Continue:
-		log.Print("continuing")
-		err = proc.Continue(d.target)
-	case api.Rewind:
-		log.Print("rewinding")
-		if err := d.target.Direction(proc.Backward); err != nil {
+	case api.Rewind, api.ReverseNext, api.ReverseStep, api.ReverseStepOut, api.ReverseStepInstruction:
+		if err := d.inferior().Direction(proc.Backward); err != nil {
 			return nil, err
 		}
 		defer func() {
-			d.target.Direction(proc.Forward)
+			// If a reverse next is interrupted by a breakpoint the direction
+			// can not be changed until it is completed with Rewind.
+			d.inferior().Direction(proc.Forward)
 		}()
-		err = proc.Continue(d.target)
+	case api.Continue, api.Next, api.Step, api.StepInstruction, api.StepOut, api.Call:
+		if err := d.inferior().Direction(proc.Forward); err != nil {
+			return nil, err
+		}
+	}
+
+	switch command.Name {
+	case api.Continue:
+		log.Print("continuing")
+		err = proc.Continue(d.inferior())
+	case api.Rewind:
+		log.Print("rewinding")
+		err = proc.Continue(d.inferior())
+	case api.ReverseNext:
+		log.Print("reverse nexting")
+		err = proc.Next(d.inferior())
+	case api.ReverseStep:
+		log.Print("reverse stepping")
+		err = proc.Step(d.inferior())
+	case api.ReverseStepInstruction:
+		log.Print("reverse single stepping")
+		err = d.inferior().StepInstruction()
+	case api.ReverseStepOut:
+		log.Print("reverse step out")
+		err = proc.StepOut(d.inferior())
 	case api.Next:
 		log.Print("nexting")
-		err = proc.Next(d.target)
+		err = proc.Next(d.inferior())
 	case api.Step:
-		log.Print("stepping")
-		err = proc.Step(d.target)
+		if command.StepTarget != "" {
+			log.Printf("stepping into %s", command.StepTarget)
+			err = proc.StepTo(d.inferior(), command.StepTarget)
+		} else {
+			log.Print("stepping")
+			err = proc.Step(d.inferior())
+		}
 	case api.StepInstruction:
 		log.Print("single stepping")
-		err = d.target.StepInstruction()
+		err = d.inferior().StepInstruction()
 	case api.StepOut:
 		log.Print("step out")
-		err = proc.StepOut(d.target)
+		err = proc.StepOut(d.inferior())
+	case api.Call:
+		log.Printf("function call %s", command.Expr)
+		err = proc.CallFunction(d.inferior(), command.Expr, api.LoadConfigToProc(command.ReturnInfoLoadConfig), !command.UnsafeCall)
 	case api.SwitchThread:
 		log.Printf("switching to thread %d", command.ThreadID)
-		err = d.target.SwitchThread(command.ThreadID)
+		err = d.inferior().SwitchThread(command.ThreadID)
 		withBreakpointInfo = false
 	case api.SwitchGoroutine:
 		log.Printf("switching to goroutine %d", command.GoroutineID)
-		err = d.target.SwitchGoroutine(command.GoroutineID)
+		err = d.inferior().SwitchGoroutine(command.GoroutineID)
+		withBreakpointInfo = false
+	case api.SwitchInferior:
+		log.Printf("switching to inferior %d", command.Pid)
+		err = d.target.SwitchInferior(command.Pid)
+		withBreakpointInfo = false
+	case api.SwitchTarget:
+		log.Printf("switching to target %d", command.TargetID)
+		err = d.switchTarget(command.TargetID)
 		withBreakpointInfo = false
 	case api.Halt:
 		// RequestManualStop already called
@@ -535,6 +1237,8 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	if err != nil {
 		if exitedErr, exited := err.(proc.ProcessExitedError); (command.Name == api.Continue || command.Name == api.Rewind) && exited {
 			state := &api.DebuggerState{}
+			state.TargetID = d.id
+			state.Pid = exitedErr.Pid
 			state.Exited = true
 			state.ExitStatus = exitedErr.Status
 			state.Err = errors.New(exitedErr.Error())
@@ -546,6 +1250,9 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	if stateErr != nil {
 		return state, stateErr
 	}
+	for _, sig := range d.inferior().ReceivedSignals() {
+		state.SignalsReceived = append(state.SignalsReceived, api.ConvertSignal(sig))
+	}
 	if withBreakpointInfo {
 		err = d.collectBreakpointInformation(state)
 	}
@@ -567,15 +1274,15 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 		state.Threads[i].BreakpointInfo = bpi
 
 		if bp.Goroutine {
-			g, err := proc.GetG(d.target.CurrentThread())
+			g, err := proc.GetG(d.inferior().CurrentThread())
 			if err != nil {
 				return err
 			}
-			bpi.Goroutine = api.ConvertGoroutine(g)
+			bpi.Goroutine = d.convertGoroutine(g)
 		}
 
 		if bp.Stacktrace > 0 {
-			rawlocs, err := proc.ThreadStacktrace(d.target.CurrentThread(), bp.Stacktrace)
+			rawlocs, err := proc.ThreadStacktrace(d.inferior().CurrentThread(), bp.Stacktrace)
 			if err != nil {
 				return err
 			}
@@ -585,13 +1292,41 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 			}
 		}
 
-		thread, found := d.target.FindThread(state.Threads[i].ID)
+		thread, found := d.inferior().FindThread(state.Threads[i].ID)
 		if !found {
 			return fmt.Errorf("could not find thread %d", state.Threads[i].ID)
 		}
 
+		if bp.WatchType != 0 {
+			if wp, _, _ := thread.Breakpoint(); wp != nil {
+				oldv, newv, err := wp.WatchValues(thread, proc.LoadConfig{true, 1, 64, 64, -1})
+				if err != nil {
+					return err
+				}
+				bpi.WatchOldValue = d.prettyPrinters.ConvertVar(oldv)
+				bpi.WatchNewValue = d.prettyPrinters.ConvertVar(newv)
+			}
+		}
+
+		if bp.TraceReturn {
+			s, err := proc.GoroutineScope(thread)
+			if err != nil {
+				return err
+			}
+			if vars, err := s.FunctionArguments(proc.LoadConfig{true, 1, 64, 64, -1}); err == nil {
+				for _, v := range vars {
+					if v.Flags&proc.VariableReturnArgument != 0 {
+						state.Threads[i].ReturnValues = append(state.Threads[i].ReturnValues, *d.prettyPrinters.ConvertVar(v))
+					}
+				}
+			}
+		}
+
 		if len(bp.Variables) == 0 && bp.LoadArgs == nil && bp.LoadLocals == nil {
 			// don't try to create goroutine scope if there is nothing to load
+			if bp.LogMessage != "" {
+				bpi.LogMessage = formatLogMessage(bp.LogMessage, nil)
+			}
 			continue
 		}
 
@@ -608,17 +1343,20 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 			if err != nil {
 				bpi.Variables[i] = api.Variable{Name: bp.Variables[i], Unreadable: fmt.Sprintf("eval error: %v", err)}
 			} else {
-				bpi.Variables[i] = *api.ConvertVar(v)
+				bpi.Variables[i] = *d.prettyPrinters.ConvertVar(v)
 			}
 		}
+		if bp.LogMessage != "" {
+			bpi.LogMessage = formatLogMessage(bp.LogMessage, bpi.Variables)
+		}
 		if bp.LoadArgs != nil {
 			if vars, err := s.FunctionArguments(*api.LoadConfigToProc(bp.LoadArgs)); err == nil {
-				bpi.Arguments = convertVars(vars)
+				bpi.Arguments = d.convertVars(vars)
 			}
 		}
 		if bp.LoadLocals != nil {
 			if locals, err := s.LocalVariables(*api.LoadConfigToProc(bp.LoadLocals)); err == nil {
-				bpi.Locals = convertVars(locals)
+				bpi.Locals = d.convertVars(locals)
 			}
 		}
 	}
@@ -626,6 +1364,54 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 	return nil
 }
 
+// formatLogMessage formats the message of a logpoint, values of basic
+// types are passed to fmt.Sprintf as such, everything else is formatted
+// the way the print command would print it.
+func formatLogMessage(format string, vars []api.Variable) string {
+	args := make([]interface{}, len(vars))
+	for i := range vars {
+		args[i] = logpointArg(&vars[i])
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func logpointArg(v *api.Variable) interface{} {
+	if v.Unreadable != "" {
+		return logpointValue{v}
+	}
+	switch v.Kind {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(v.Value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, err := strconv.ParseUint(v.Value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+	case reflect.String:
+		return v.Value
+	}
+	return logpointValue{v}
+}
+
+// logpointValue formats a variable with any verb the way the print command
+// would.
+type logpointValue struct {
+	v *api.Variable
+}
+
+func (lv logpointValue) Format(f fmt.State, verb rune) {
+	f.Write([]byte(lv.v.SinglelineString()))
+}
+
 // Sources returns a list of the source files for target binary.
 func (d *Debugger) Sources(filter string) ([]string, error) {
 	d.processMutex.Lock()
@@ -637,7 +1423,7 @@ func (d *Debugger) Sources(filter string) ([]string, error) {
 	}
 
 	files := []string{}
-	for f := range d.target.BinInfo().Sources() {
+	for f := range d.inferior().BinInfo().Sources() {
 		if regex.Match([]byte(f)) {
 			files = append(files, f)
 		}
@@ -650,7 +1436,49 @@ func (d *Debugger) Functions(filter string) ([]string, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	return regexFilterFuncs(filter, d.target.BinInfo().Funcs())
+	return regexFilterFuncs(filter, d.inferior().BinInfo().Funcs())
+}
+
+// FunctionReturnLocations returns the addresses of all the return
+// instructions of the function called fnName.
+func (d *Debugger) FunctionReturnLocations(fnName string) ([]uint64, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	fn := d.inferior().BinInfo().LookupFunc(fnName)
+	if fn == nil {
+		return nil, fmt.Errorf("unable to find function %s", fnName)
+	}
+
+	insts, err := proc.Disassemble(d.inferior(), nil, fn.Entry, fn.End)
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []uint64
+	for _, inst := range insts {
+		if inst.IsRet() {
+			addrs = append(addrs, inst.Loc.PC)
+		}
+	}
+	return addrs, nil
+}
+
+// StepTargets returns the functions called by the remaining instructions
+// of the current line.
+func (d *Debugger) StepTargets() ([]api.Function, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	fns, err := proc.StepTargets(d.inferior())
+	if err != nil {
+		return nil, err
+	}
+	r := make([]api.Function, len(fns))
+	for i := range fns {
+		r[i] = *api.ConvertFunction(fns[i])
+	}
+	return r, nil
 }
 
 func (d *Debugger) Types(filter string) ([]string, error) {
@@ -662,7 +1490,7 @@ func (d *Debugger) Types(filter string) ([]string, error) {
 		return nil, fmt.Errorf("invalid filter argument: %s", err.Error())
 	}
 
-	types, err := d.target.BinInfo().Types()
+	types, err := d.inferior().BinInfo().Types()
 	if err != nil {
 		return nil, err
 	}
@@ -704,7 +1532,7 @@ func (d *Debugger) PackageVariables(threadID int, filter string, cfg proc.LoadCo
 	}
 
 	vars := []api.Variable{}
-	thread, found := d.target.FindThread(threadID)
+	thread, found := d.inferior().FindThread(threadID)
 	if !found {
 		return nil, fmt.Errorf("couldn't find thread %d", threadID)
 	}
@@ -718,7 +1546,7 @@ func (d *Debugger) PackageVariables(threadID int, filter string, cfg proc.LoadCo
 	}
 	for _, v := range pv {
 		if regex.Match([]byte(v.Name)) {
-			vars = append(vars, *api.ConvertVar(v))
+			vars = append(vars, *d.prettyPrinters.ConvertVar(v))
 		}
 	}
 	return vars, err
@@ -729,7 +1557,7 @@ func (d *Debugger) Registers(threadID int, floatingPoint bool) (api.Registers, e
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	thread, found := d.target.FindThread(threadID)
+	thread, found := d.inferior().FindThread(threadID)
 	if !found {
 		return nil, fmt.Errorf("couldn't find thread %d", threadID)
 	}
@@ -740,10 +1568,10 @@ func (d *Debugger) Registers(threadID int, floatingPoint bool) (api.Registers, e
 	return api.ConvertRegisters(regs.Slice()), err
 }
 
-func convertVars(pv []*proc.Variable) []api.Variable {
+func (d *Debugger) convertVars(pv []*proc.Variable) []api.Variable {
 	vars := make([]api.Variable, 0, len(pv))
 	for _, v := range pv {
-		vars = append(vars, *api.ConvertVar(v))
+		vars = append(vars, *d.prettyPrinters.ConvertVar(v))
 	}
 	return vars
 }
@@ -753,7 +1581,7 @@ func (d *Debugger) LocalVariables(scope api.EvalScope, cfg proc.LoadConfig) ([]a
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, err := proc.ConvertEvalScope(d.inferior(), scope.GoroutineID, scope.Frame)
 	if err != nil {
 		return nil, err
 	}
@@ -761,7 +1589,7 @@ func (d *Debugger) LocalVariables(scope api.EvalScope, cfg proc.LoadConfig) ([]a
 	if err != nil {
 		return nil, err
 	}
-	return convertVars(pv), err
+	return d.convertVars(pv), err
 }
 
 // FunctionArguments returns the arguments to the current function.
@@ -769,7 +1597,7 @@ func (d *Debugger) FunctionArguments(scope api.EvalScope, cfg proc.LoadConfig) (
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, err := proc.ConvertEvalScope(d.inferior(), scope.GoroutineID, scope.Frame)
 	if err != nil {
 		return nil, err
 	}
@@ -777,7 +1605,7 @@ func (d *Debugger) FunctionArguments(scope api.EvalScope, cfg proc.LoadConfig) (
 	if err != nil {
 		return nil, err
 	}
-	return convertVars(pv), nil
+	return d.convertVars(pv), nil
 }
 
 // EvalVariableInScope will attempt to evaluate the variable represented by 'symbol'
@@ -786,42 +1614,130 @@ func (d *Debugger) EvalVariableInScope(scope api.EvalScope, symbol string, cfg p
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	v, err := d.evalVariableInScope(scope, symbol, cfg)
 	if err != nil {
 		return nil, err
 	}
-	v, err := s.EvalVariable(symbol, cfg)
+	return d.prettyPrinters.ConvertVar(v), err
+}
+
+// EvalAndRecordInScope is like EvalVariableInScope but also appends the
+// value to the value history, it returns the index of the value in the
+// history.
+func (d *Debugger) EvalAndRecordInScope(scope api.EvalScope, symbol string, cfg proc.LoadConfig) (*api.Variable, int, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	v, err := d.evalVariableInScope(scope, symbol, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+	return d.prettyPrinters.ConvertVar(v), d.convVars.Record(v), nil
+}
+
+func (d *Debugger) evalVariableInScope(scope api.EvalScope, symbol string, cfg proc.LoadConfig) (*proc.Variable, error) {
+	s, err := proc.ConvertEvalScope(d.inferior(), scope.GoroutineID, scope.Frame)
 	if err != nil {
 		return nil, err
 	}
-	return api.ConvertVar(v), err
+	s.ConvVars = &d.convVars
+	return s.EvalVariable(symbol, cfg)
+}
+
+// maxExamineMemoryLength is the largest number of bytes that
+// ExamineMemory reads at once.
+const maxExamineMemoryLength = 1 << 16
+
+// ExamineMemory evaluates expr in the given scope and reads length bytes
+// of memory starting at the address it represents, which is returned
+// along with the data.
+func (d *Debugger) ExamineMemory(scope api.EvalScope, expr string, length int) (uint64, []byte, error) {
+	if length <= 0 || length > maxExamineMemoryLength {
+		return 0, nil, fmt.Errorf("length must be between 1 and %d", maxExamineMemoryLength)
+	}
+
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	s, err := proc.ConvertEvalScope(d.inferior(), scope.GoroutineID, scope.Frame)
+	if err != nil {
+		return 0, nil, err
+	}
+	s.ConvVars = &d.convVars
+	addr, err := s.EvalAddress(expr)
+	if err != nil {
+		return 0, nil, err
+	}
+	mem := make([]byte, length)
+	if _, err := s.Mem.ReadMemory(mem, addr); err != nil {
+		return uint64(addr), nil, fmt.Errorf("could not read memory at %#x: %v", addr, err)
+	}
+	return uint64(addr), mem, nil
 }
 
 // SetVariableInScope will set the value of the variable represented by
 // 'symbol' to the value given, in the given scope.
+// If symbol is a convenience variable, $name, value is evaluated and saved
+// as $name, if it is a register, e.g. $rax, the register is changed.
 func (d *Debugger) SetVariableInScope(scope api.EvalScope, symbol, value string) error {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, err := proc.ConvertEvalScope(d.inferior(), scope.GoroutineID, scope.Frame)
 	if err != nil {
 		return err
 	}
+	s.ConvVars = &d.convVars
+	if name := strings.TrimSpace(symbol); strings.HasPrefix(name, "$") {
+		if proc.IsRegister(name[1:]) {
+			if err := s.SetRegister(name[1:], value); err != nil {
+				return err
+			}
+			// the cached goroutines have the old registers
+			if c, ok := d.inferior().(proc.AllGCache); ok {
+				*c.AllGCache() = nil
+			}
+			return nil
+		}
+		v, err := s.EvalVariable(value, proc.LoadConfig{true, 1, 64, 64, -1})
+		if err != nil {
+			return err
+		}
+		return d.convVars.Set(name[1:], v)
+	}
 	return s.SetVariable(symbol, value)
 }
 
+// ConvenienceVariables returns the value history followed by the
+// convenience variables.
+func (d *Debugger) ConvenienceVariables() []api.Variable {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	return d.convertVars(d.convVars.List())
+}
+
+// ClearConvenienceVariables removes the convenience variable $name or, if
+// name is empty, all convenience variables and the value history.
+func (d *Debugger) ClearConvenienceVariables(name string) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	return d.convVars.Clear(strings.TrimPrefix(name, "$"))
+}
+
 // Goroutines will return a list of goroutines in the target process.
 func (d *Debugger) Goroutines() ([]*api.Goroutine, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
 	goroutines := []*api.Goroutine{}
-	gs, err := proc.GoroutinesInfo(d.target)
+	gs, err := proc.GoroutinesInfo(d.inferior())
 	if err != nil {
 		return nil, err
 	}
 	for _, g := range gs {
-		goroutines = append(goroutines, api.ConvertGoroutine(g))
+		goroutines = append(goroutines, d.convertGoroutine(g))
 	}
 	return goroutines, err
 }
@@ -833,19 +1749,19 @@ func (d *Debugger) Stacktrace(goroutineID, depth int, cfg *proc.LoadConfig) ([]a
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	if d.target.Exited() {
+	if d.inferior().Exited() {
 		return nil, proc.ProcessExitedError{Pid: d.ProcessPid()}
 	}
 
 	var rawlocs []proc.Stackframe
 
-	g, err := proc.FindGoroutine(d.target, goroutineID)
+	g, err := proc.FindGoroutine(d.inferior(), goroutineID)
 	if err != nil {
 		return nil, err
 	}
 
 	if g == nil {
-		rawlocs, err = proc.ThreadStacktrace(d.target.CurrentThread(), depth)
+		rawlocs, err = proc.ThreadStacktrace(d.inferior().CurrentThread(), depth)
 	} else {
 		rawlocs, err = g.Stacktrace(depth)
 	}
@@ -868,7 +1784,7 @@ func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadCo
 		}
 		if cfg != nil && rawlocs[i].Current.Fn != nil {
 			var err error
-			scope := proc.FrameToScope(d.target, rawlocs[i])
+			scope := proc.FrameToScope(d.inferior(), rawlocs[i])
 			locals, err := scope.LocalVariables(*cfg)
 			if err != nil {
 				return nil, err
@@ -878,8 +1794,8 @@ func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadCo
 				return nil, err
 			}
 
-			frame.Locals = convertVars(locals)
-			frame.Arguments = convertVars(arguments)
+			frame.Locals = d.convertVars(locals)
+			frame.Arguments = d.convertVars(arguments)
 		}
 		locations = append(locations, frame)
 	}
@@ -892,8 +1808,8 @@ func (d *Debugger) FindLocation(scope api.EvalScope, locStr string) ([]api.Locat
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	if d.target.Exited() {
-		return nil, &proc.ProcessExitedError{Pid: d.target.Pid()}
+	if d.inferior().Exited() {
+		return nil, &proc.ProcessExitedError{Pid: d.inferior().Pid()}
 	}
 
 	loc, err := parseLocationSpec(locStr)
@@ -901,11 +1817,11 @@ func (d *Debugger) FindLocation(scope api.EvalScope, locStr string) ([]api.Locat
 		return nil, err
 	}
 
-	s, _ := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, _ := proc.ConvertEvalScope(d.inferior(), scope.GoroutineID, scope.Frame)
 
 	locs, err := loc.Find(d, s, locStr)
 	for i := range locs {
-		file, line, fn := d.target.BinInfo().PCToLine(locs[i].PC)
+		file, line, fn := d.inferior().BinInfo().PCToLine(locs[i].PC)
 		locs[i].File = file
 		locs[i].Line = line
 		locs[i].Function = api.ConvertFunction(fn)
@@ -913,18 +1829,46 @@ func (d *Debugger) FindLocation(scope api.EvalScope, locStr string) ([]api.Locat
 	return locs, err
 }
 
+// Jump changes the next statement executed by the selected goroutine to the
+// location specified by locStr, which must be in the current function.
+func (d *Debugger) Jump(locStr string) (*api.DebuggerState, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if d.inferior().Exited() {
+		return nil, &proc.ProcessExitedError{Pid: d.inferior().Pid()}
+	}
+
+	loc, err := parseLocationSpec(locStr)
+	if err != nil {
+		return nil, err
+	}
+	s, _ := proc.ConvertEvalScope(d.inferior(), -1, 0)
+	locs, err := loc.Find(d, s, locStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(locs) != 1 {
+		return nil, fmt.Errorf("location %q is ambiguous", locStr)
+	}
+	if err := proc.Jump(d.inferior(), locs[0].PC); err != nil {
+		return nil, err
+	}
+	return d.state()
+}
+
 // Disassemble code between startPC and endPC
 // if endPC == 0 it will find the function containing startPC and disassemble the whole function
 func (d *Debugger) Disassemble(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	if d.target.Exited() {
-		return nil, &proc.ProcessExitedError{Pid: d.target.Pid()}
+	if d.inferior().Exited() {
+		return nil, &proc.ProcessExitedError{Pid: d.inferior().Pid()}
 	}
 
 	if endPC == 0 {
-		_, _, fn := d.target.BinInfo().PCToLine(startPC)
+		_, _, fn := d.inferior().BinInfo().PCToLine(startPC)
 		if fn == nil {
 			return nil, fmt.Errorf("Address 0x%x does not belong to any function", startPC)
 		}
@@ -932,12 +1876,12 @@ func (d *Debugger) Disassemble(scope api.EvalScope, startPC, endPC uint64, flavo
 		endPC = fn.End
 	}
 
-	g, err := proc.FindGoroutine(d.target, scope.GoroutineID)
+	g, err := proc.FindGoroutine(d.inferior(), scope.GoroutineID)
 	if err != nil {
 		return nil, err
 	}
 
-	insts, err := proc.Disassemble(d.target, g, startPC, endPC)
+	insts, err := proc.Disassemble(d.inferior(), g, startPC, endPC)
 	if err != nil {
 		return nil, err
 	}
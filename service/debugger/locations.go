@@ -91,6 +91,22 @@ func parseLocationSpec(locStr string) (LocationSpec, error) {
 	}
 }
 
+// IsAbsoluteLocationSpec returns true if locStr is a valid location spec
+// that depends neither on the position where the program is stopped nor on
+// the addresses of the current executable, so that it can be resolved
+// again in a different process.
+func IsAbsoluteLocationSpec(locStr string) bool {
+	loc, err := parseLocationSpec(locStr)
+	if err != nil {
+		return false
+	}
+	switch loc.(type) {
+	case *OffsetLocationSpec, *LineLocationSpec, *AddrLocationSpec:
+		return false
+	}
+	return true
+}
+
 func parseLocationSpecDefault(locStr, rest string) (LocationSpec, error) {
 	malformed := func(reason string) error {
 		return fmt.Errorf("Malformed breakpoint location \"%s\" at %d: %s", locStr, len(locStr)-len(rest), reason)
@@ -243,14 +259,14 @@ func (spec *FuncLocationSpec) Match(sym *gosym.Sym) bool {
 }
 
 func (loc *RegexLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr string) ([]api.Location, error) {
-	funcs := d.target.BinInfo().Funcs()
+	funcs := d.inferior().BinInfo().Funcs()
 	matches, err := regexFilterFuncs(loc.FuncRegex, funcs)
 	if err != nil {
 		return nil, err
 	}
 	r := make([]api.Location, 0, len(matches))
 	for i := range matches {
-		addr, err := proc.FindFunctionLocation(d.target, matches[i], true, 0)
+		addr, err := proc.FindFunctionLocation(d.inferior(), matches[i], true, 0)
 		if err == nil {
 			r = append(r, api.Location{PC: addr})
 		}
@@ -278,8 +294,8 @@ func (loc *AddrLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr str
 			addr, _ := constant.Uint64Val(v.Value)
 			return []api.Location{{PC: addr}}, nil
 		case reflect.Func:
-			_, _, fn := d.target.BinInfo().PCToLine(uint64(v.Base))
-			pc, err := proc.FirstPCAfterPrologue(d.target, fn, false)
+			_, _, fn := d.inferior().BinInfo().PCToLine(uint64(v.Base))
+			pc, err := proc.FirstPCAfterPrologue(d.inferior(), fn, false)
 			if err != nil {
 				return nil, err
 			}
@@ -329,7 +345,7 @@ func (ale AmbiguousLocationError) Error() string {
 func (loc *NormalLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr string) ([]api.Location, error) {
 	limit := maxFindLocationCandidates
 	var candidateFiles []string
-	for file := range d.target.BinInfo().Sources() {
+	for file := range d.inferior().BinInfo().Sources() {
 		if loc.FileMatch(file) {
 			candidateFiles = append(candidateFiles, file)
 			if len(candidateFiles) >= limit {
@@ -342,7 +358,7 @@ func (loc *NormalLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr s
 
 	var candidateFuncs []string
 	if loc.FuncBase != nil {
-		for _, f := range d.target.BinInfo().Funcs() {
+		for _, f := range d.inferior().BinInfo().Funcs() {
 			if f.Sym == nil {
 				continue
 			}
@@ -371,52 +387,106 @@ func (loc *NormalLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr s
 			return nil, fmt.Errorf("Location \"%s\" not found", locStr)
 		}
 		return locs, nil
-	} else if matching > 1 {
+	} else if matching >= maxFindLocationCandidates {
 		return nil, AmbiguousLocationError{Location: locStr, CandidatesString: append(candidateFiles, candidateFuncs...)}
 	}
 
-	// len(candidateFiles) + len(candidateFuncs) == 1
-	var addr uint64
-	var err error
-	if len(candidateFiles) == 1 {
+	// Every candidate is returned, breakpoints are set on all of them. A
+	// candidate that can not be resolved is only an error if it is the
+	// only one.
+	var r []api.Location
+	var firstErr error
+	for _, file := range candidateFiles {
+		var locs []api.Location
+		var err error
 		if loc.LineOffset < 0 {
-			return nil, fmt.Errorf("Malformed breakpoint location, no line offset specified")
+			err = fmt.Errorf("Malformed breakpoint location, no line offset specified")
+		} else {
+			locs, err = fileLineLocation(d, file, loc.LineOffset)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
 		}
-		addr, err = proc.FindFileLocation(d.target, candidateFiles[0], loc.LineOffset)
-	} else { // len(candidateFUncs) == 1
+		r = append(r, locs...)
+	}
+	for _, fn := range candidateFuncs {
+		var addr uint64
+		var err error
 		if loc.LineOffset < 0 {
-			addr, err = proc.FindFunctionLocation(d.target, candidateFuncs[0], true, 0)
+			addr, err = proc.FindFunctionLocation(d.inferior(), fn, true, 0)
 		} else {
-			addr, err = proc.FindFunctionLocation(d.target, candidateFuncs[0], false, loc.LineOffset)
+			addr, err = proc.FindFunctionLocation(d.inferior(), fn, false, loc.LineOffset)
 		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		r = append(r, api.Location{PC: addr})
+	}
+	if len(r) == 0 {
+		return nil, firstErr
 	}
+	return r, nil
+}
 
-	if err != nil {
-		return nil, err
+// LocationAddrs returns the addresses of locs, without duplicates, a
+// breakpoint on a location spec is set on all of them.
+func LocationAddrs(locs []api.Location) []uint64 {
+	var addrs []uint64
+	seen := make(map[uint64]bool)
+	for _, loc := range locs {
+		pcs := loc.PCs
+		if len(pcs) == 0 {
+			pcs = []uint64{loc.PC}
+		}
+		for _, pc := range pcs {
+			if !seen[pc] {
+				seen[pc] = true
+				addrs = append(addrs, pc)
+			}
+		}
 	}
-	return []api.Location{{PC: addr}}, nil
+	return addrs
 }
 
 func (loc *OffsetLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr string) ([]api.Location, error) {
 	if scope == nil {
 		return nil, fmt.Errorf("could not determine current location (scope is nil)")
 	}
-	file, line, fn := d.target.BinInfo().PCToLine(scope.PC)
+	file, line, fn := d.inferior().BinInfo().PCToLine(scope.PC)
 	if fn == nil {
 		return nil, fmt.Errorf("could not determine current location")
 	}
-	addr, err := proc.FindFileLocation(d.target, file, line+loc.Offset)
-	return []api.Location{{PC: addr}}, err
+	return fileLineLocation(d, file, line+loc.Offset)
 }
 
 func (loc *LineLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr string) ([]api.Location, error) {
 	if scope == nil {
 		return nil, fmt.Errorf("could not determine current location (scope is nil)")
 	}
-	file, _, fn := d.target.BinInfo().PCToLine(scope.PC)
+	file, _, fn := d.inferior().BinInfo().PCToLine(scope.PC)
 	if fn == nil {
 		return nil, fmt.Errorf("could not determine current location")
 	}
-	addr, err := proc.FindFileLocation(d.target, file, loc.Line)
-	return []api.Location{{PC: addr}}, err
+	return fileLineLocation(d, file, loc.Line)
+}
+
+// fileLineLocation returns the location for file:line, if the line was
+// compiled into more than one range of instructions all the addresses
+// are returned in the PCs field.
+func fileLineLocation(d *Debugger, file string, line int) ([]api.Location, error) {
+	addrs, err := proc.FindFileLocations(d.inferior(), file, line)
+	if err != nil {
+		return nil, err
+	}
+	loc := api.Location{PC: addrs[0]}
+	if len(addrs) > 1 {
+		loc.PCs = addrs
+	}
+	return []api.Location{loc}, nil
 }
@@ -66,3 +66,22 @@ func TestFunctionLocationParsing(t *testing.T) {
 	assertNormalLocationSpec(t, "github.com/derekparker/delve/pkg/proc.Process.Continue:10", NormalLocationSpec{"github.com/derekparker/delve/pkg/proc.Process.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/pkg/proc", ReceiverName: "Process", BaseName: "Continue"}, 10})
 	assertNormalLocationSpec(t, "github.com/derekparker/delve/pkg/proc.Continue:10", NormalLocationSpec{"github.com/derekparker/delve/pkg/proc.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/pkg/proc", BaseName: "Continue"}, 10})
 }
+
+func TestIsAbsoluteLocationSpec(t *testing.T) {
+	for _, tc := range []struct {
+		locstr string
+		tgt    bool
+	}{
+		{"main.main", true},
+		{"main.go:10", true},
+		{"/^main\\.f/", true},
+		{"+2", false},
+		{"10", false},
+		{"*0x4010a0", false},
+		{"", false},
+	} {
+		if r := IsAbsoluteLocationSpec(tc.locstr); r != tc.tgt {
+			t.Errorf("IsAbsoluteLocationSpec(%q) = %v, expected %v", tc.locstr, r, tc.tgt)
+		}
+	}
+}
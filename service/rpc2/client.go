@@ -51,13 +51,19 @@ func (c *RPCClient) Detach(kill bool) error {
 
 func (c *RPCClient) Restart() ([]api.DiscardedBreakpoint, error) {
 	out := new(RestartOut)
-	err := c.call("Restart", RestartIn{""}, out)
+	err := c.call("Restart", RestartIn{"", false}, out)
 	return out.DiscardedBreakpoints, err
 }
 
 func (c *RPCClient) RestartFrom(pos string) ([]api.DiscardedBreakpoint, error) {
 	out := new(RestartOut)
-	err := c.call("Restart", RestartIn{pos}, out)
+	err := c.call("Restart", RestartIn{pos, false}, out)
+	return out.DiscardedBreakpoints, err
+}
+
+func (c *RPCClient) RestartRebuild() ([]api.DiscardedBreakpoint, error) {
+	out := new(RestartOut)
+	err := c.call("Restart", RestartIn{"", true}, out)
 	return out.DiscardedBreakpoints, err
 }
 
@@ -125,12 +131,60 @@ func (c *RPCClient) Step() (*api.DebuggerState, error) {
 	return &out.State, err
 }
 
+func (c *RPCClient) StepTo(target string) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.Step, StepTarget: target}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) Jump(loc string) (*api.DebuggerState, error) {
+	var out JumpOut
+	err := c.call("Jump", JumpIn{loc}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) StepTargets() ([]api.Function, error) {
+	var out StepTargetsOut
+	err := c.call("StepTargets", StepTargetsIn{}, &out)
+	return out.Targets, err
+}
+
+func (c *RPCClient) ReverseNext() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseNext}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseStep() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStep}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseStepOut() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStepOut}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseStepInstruction() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStepInstruction}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) StepOut() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", &api.DebuggerCommand{Name: api.StepOut}, &out)
 	return &out.State, err
 }
 
+func (c *RPCClient) Call(expr string, unsafe bool) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.Call, Expr: expr, UnsafeCall: unsafe}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) StepInstruction() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.StepInstruction}, &out)
@@ -157,6 +211,26 @@ func (c *RPCClient) SwitchGoroutine(goroutineID int) (*api.DebuggerState, error)
 	return &out.State, err
 }
 
+func (c *RPCClient) SwitchInferior(pid int) (*api.DebuggerState, error) {
+	var out CommandOut
+	cmd := api.DebuggerCommand{
+		Name: api.SwitchInferior,
+		Pid:  pid,
+	}
+	err := c.call("Command", cmd, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) SwitchTarget(id int) (*api.DebuggerState, error) {
+	var out CommandOut
+	cmd := api.DebuggerCommand{
+		Name:     api.SwitchTarget,
+		TargetID: id,
+	}
+	err := c.call("Command", cmd, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) Halt() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.Halt}, &out)
@@ -181,6 +255,67 @@ func (c *RPCClient) CreateBreakpoint(breakPoint *api.Breakpoint) (*api.Breakpoin
 	return &out.Breakpoint, err
 }
 
+func (c *RPCClient) CreateWatchpoint(scope api.EvalScope, expr string, wtype api.WatchType) (*api.Breakpoint, error) {
+	var out CreateWatchpointOut
+	err := c.call("CreateWatchpoint", CreateWatchpointIn{scope, expr, wtype}, &out)
+	return &out.Breakpoint, err
+}
+
+func (c *RPCClient) CreateCatchpoint(kind string) (*api.Breakpoint, error) {
+	var out CreateCatchpointOut
+	err := c.call("CreateCatchpoint", CreateCatchpointIn{kind}, &out)
+	return &out.Breakpoint, err
+}
+
+func (c *RPCClient) CatchSyscalls(syscalls []string, enabled bool) error {
+	var out CatchSyscallsOut
+	return c.call("CatchSyscalls", CatchSyscallsIn{enabled, syscalls}, &out)
+}
+
+func (c *RPCClient) SetSignalHandling(sig string, stop, print, pass bool) error {
+	var out SetSignalHandlingOut
+	return c.call("SetSignalHandling", SetSignalHandlingIn{sig, stop, print, pass}, &out)
+}
+
+func (c *RPCClient) SetStepFilter(packages, files []string) error {
+	var out SetStepFilterOut
+	return c.call("SetStepFilter", SetStepFilterIn{packages, files}, &out)
+}
+
+func (c *RPCClient) AllowFunctionCalls(allow bool) error {
+	var out AllowFunctionCallsOut
+	return c.call("AllowFunctionCalls", AllowFunctionCallsIn{allow}, &out)
+}
+
+func (c *RPCClient) SetFollowForkMode(mode string) error {
+	var out SetFollowForkModeOut
+	return c.call("SetFollowForkMode", SetFollowForkModeIn{mode}, &out)
+}
+
+func (c *RPCClient) ListInferiors() ([]api.Inferior, string, error) {
+	var out ListInferiorsOut
+	err := c.call("ListInferiors", ListInferiorsIn{}, &out)
+	return out.Inferiors, out.FollowForkMode, err
+}
+
+func (c *RPCClient) ListTargets() ([]api.Target, error) {
+	var out ListTargetsOut
+	err := c.call("ListTargets", ListTargetsIn{}, &out)
+	return out.Targets, err
+}
+
+func (c *RPCClient) AttachTarget(pid int, path string) (api.Target, error) {
+	var out AttachTargetOut
+	err := c.call("AttachTarget", AttachTargetIn{pid, path}, &out)
+	return out.Target, err
+}
+
+func (c *RPCClient) LaunchTarget(processArgs []string, wd string) (api.Target, error) {
+	var out LaunchTargetOut
+	err := c.call("LaunchTarget", LaunchTargetIn{processArgs, wd}, &out)
+	return out.Target, err
+}
+
 func (c *RPCClient) ListBreakpoints() ([]*api.Breakpoint, error) {
 	var out ListBreakpointsOut
 	err := c.call("ListBreakpoints", ListBreakpointsIn{}, &out)
@@ -224,10 +359,33 @@ func (c *RPCClient) GetThread(id int) (*api.Thread, error) {
 
 func (c *RPCClient) EvalVariable(scope api.EvalScope, expr string, cfg api.LoadConfig) (*api.Variable, error) {
 	var out EvalOut
-	err := c.call("Eval", EvalIn{scope, expr, &cfg}, &out)
+	err := c.call("Eval", EvalIn{scope, expr, &cfg, false}, &out)
 	return out.Variable, err
 }
 
+func (c *RPCClient) EvalAndRecord(scope api.EvalScope, expr string, cfg api.LoadConfig) (*api.Variable, int, error) {
+	var out EvalOut
+	err := c.call("Eval", EvalIn{scope, expr, &cfg, true}, &out)
+	return out.Variable, out.HistoryIndex, err
+}
+
+func (c *RPCClient) ListConvenienceVariables() ([]api.Variable, error) {
+	var out ListConvenienceVariablesOut
+	err := c.call("ListConvenienceVariables", ListConvenienceVariablesIn{}, &out)
+	return out.Variables, err
+}
+
+func (c *RPCClient) ClearConvenienceVariables(name string) error {
+	var out ClearConvenienceVariablesOut
+	return c.call("ClearConvenienceVariables", ClearConvenienceVariablesIn{name}, &out)
+}
+
+func (c *RPCClient) ExamineMemory(scope api.EvalScope, expr string, length int) (uint64, []byte, error) {
+	var out ExamineMemoryOut
+	err := c.call("ExamineMemory", ExamineMemoryIn{scope, expr, length}, &out)
+	return out.Address, out.Mem, err
+}
+
 func (c *RPCClient) SetVariable(scope api.EvalScope, symbol, value string) error {
 	out := new(SetOut)
 	return c.call("Set", SetIn{scope, symbol, value}, out)
@@ -245,6 +403,12 @@ func (c *RPCClient) ListFunctions(filter string) ([]string, error) {
 	return funcs.Funcs, err
 }
 
+func (c *RPCClient) FunctionReturnLocations(fnName string) ([]uint64, error) {
+	var out FunctionReturnLocationsOut
+	err := c.call("FunctionReturnLocations", FunctionReturnLocationsIn{fnName}, &out)
+	return out.Addrs, err
+}
+
 func (c *RPCClient) ListTypes(filter string) ([]string, error) {
 	types := new(ListTypesOut)
 	err := c.call("ListTypes", ListTypesIn{filter}, types)
@@ -1,7 +1,6 @@
 package rpc2
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
@@ -62,6 +61,9 @@ type RestartIn struct {
 	// Position to restart from, if it starts with 'c' it's a checkpoint ID,
 	// otherwise it's an event number. Only valid for recorded targets.
 	Position string
+	// Rebuild builds the executable again before restarting it. Only
+	// valid for programs started with 'dlv debug' or 'dlv test'.
+	Rebuild bool
 }
 
 type RestartOut struct {
@@ -70,11 +72,8 @@ type RestartOut struct {
 
 // Restart restarts program.
 func (s *RPCServer) Restart(arg RestartIn, out *RestartOut) error {
-	if s.config.AttachPid != 0 {
-		return errors.New("cannot restart process Delve did not create")
-	}
 	var err error
-	out.DiscardedBreakpoints, err = s.debugger.Restart(arg.Position)
+	out.DiscardedBreakpoints, err = s.debugger.Restart(arg.Position, arg.Rebuild)
 	return err
 }
 
@@ -173,7 +172,7 @@ type ListBreakpointsOut struct {
 	Breakpoints []*api.Breakpoint
 }
 
-// ListBreakpoints gets all breakpoints.
+// ListBreakpoints gets all breakpoints of the current target.
 func (s *RPCServer) ListBreakpoints(arg ListBreakpointsIn, out *ListBreakpointsOut) error {
 	out.Breakpoints = s.debugger.Breakpoints()
 	return nil
@@ -187,7 +186,7 @@ type CreateBreakpointOut struct {
 	Breakpoint api.Breakpoint
 }
 
-// CreateBreakpoint creates a new breakpoint.
+// CreateBreakpoint creates a new breakpoint in the current target.
 //
 // - If arg.Breakpoint.File is not an empty string the breakpoint
 // will be created on the specified file:line location
@@ -208,6 +207,228 @@ func (s *RPCServer) CreateBreakpoint(arg CreateBreakpointIn, out *CreateBreakpoi
 	return nil
 }
 
+type CreateWatchpointIn struct {
+	Scope api.EvalScope
+	Expr  string
+	Type  api.WatchType
+}
+
+type CreateWatchpointOut struct {
+	Breakpoint api.Breakpoint
+}
+
+// CreateWatchpoint creates a watchpoint on the memory occupied by the
+// result of evaluating arg.Expr in the specified scope.
+//
+// Execution will stop when the memory is accessed in the way specified
+// by arg.Type. If arg.Expr is a stack variable the watchpoint will be
+// cleared automatically when its frame returns, see
+// DebuggerState.WatchOutOfScope.
+func (s *RPCServer) CreateWatchpoint(arg CreateWatchpointIn, out *CreateWatchpointOut) error {
+	createdbp, err := s.debugger.CreateWatchpoint(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Expr, arg.Type)
+	if err != nil {
+		return err
+	}
+	out.Breakpoint = *createdbp
+	return nil
+}
+
+type CreateCatchpointIn struct {
+	// Kind is one of "panic", "throw" or "exit".
+	Kind string
+}
+
+type CreateCatchpointOut struct {
+	Breakpoint api.Breakpoint
+}
+
+// CreateCatchpoint creates a catchpoint, a breakpoint that stops when a
+// panic starts (arg.Kind == "panic"), when the runtime raises a fatal
+// error (arg.Kind == "throw") or before the program calls os.Exit
+// (arg.Kind == "exit").
+//
+// Catchpoints have negative IDs, when they are hit the panic value, the
+// error message or the exit code is the only variable in
+// BreakpointInfo.Variables.
+// Use ClearBreakpoint to remove a catchpoint.
+func (s *RPCServer) CreateCatchpoint(arg CreateCatchpointIn, out *CreateCatchpointOut) error {
+	createdbp, err := s.debugger.CreateCatchpoint(arg.Kind)
+	if err != nil {
+		return err
+	}
+	out.Breakpoint = *createdbp
+	return nil
+}
+
+type CatchSyscallsIn struct {
+	Enabled bool
+	// Syscalls is the list of system calls to catch, if empty all system
+	// calls are caught.
+	Syscalls []string
+}
+
+type CatchSyscallsOut struct {
+}
+
+// CatchSyscalls enables (arg.Enabled == true) or disables syscall
+// catchpoints: the target will stop every time a thread enters or exits one
+// of the system calls listed in arg.Syscalls, or any system call if the
+// list is empty. Calling it again replaces the previous list.
+//
+// When the target is stopped by a syscall catchpoint the system call is
+// described by the Syscall field of DebuggerState.
+// Only supported by the native backend on Linux.
+func (s *RPCServer) CatchSyscalls(arg CatchSyscallsIn, out *CatchSyscallsOut) error {
+	return s.debugger.CatchSyscalls(arg.Syscalls, arg.Enabled)
+}
+
+type SetSignalHandlingIn struct {
+	// Signal is a signal name, like "SIGUSR1" or "USR1", or a signal number.
+	Signal string
+	Stop   bool
+	Print  bool
+	Pass   bool
+}
+
+type SetSignalHandlingOut struct {
+}
+
+// SetSignalHandling changes what happens when the target receives
+// arg.Signal: if arg.Stop is set the target is stopped and the signal is
+// returned in the Signal field of DebuggerState, otherwise if arg.Print is
+// set the signal is listed in the SignalsReceived field of the
+// DebuggerState returned by the next command. If arg.Pass is set the
+// signal is delivered to the target when it is resumed.
+// By default signals are passed to the target without stopping it.
+// Only supported by the native backend on Linux.
+func (s *RPCServer) SetSignalHandling(arg SetSignalHandlingIn, out *SetSignalHandlingOut) error {
+	return s.debugger.SetSignalHandling(arg.Signal, arg.Stop, arg.Print, arg.Pass)
+}
+
+type SetFollowForkModeIn struct {
+	// Mode is "parent", "child" or "both".
+	Mode string
+}
+
+type SetFollowForkModeOut struct {
+}
+
+// SetFollowForkMode changes which processes are debugged after the target
+// forks: with "parent", the default, children run untraced, with "child"
+// the debugger detaches from the parent and follows the child, with
+// "both" parent and child are debugged together and listed by
+// ListInferiors.
+// When a followed process calls exec the program it executes is loaded,
+// its breakpoints are cleared, and the target stops at the exit of the
+// execve system call.
+// Only supported by the native backend on Linux.
+func (s *RPCServer) SetFollowForkMode(arg SetFollowForkModeIn, out *SetFollowForkModeOut) error {
+	return s.debugger.SetFollowForkMode(arg.Mode)
+}
+
+type ListInferiorsIn struct {
+}
+
+type ListInferiorsOut struct {
+	Inferiors      []api.Inferior
+	FollowForkMode string
+}
+
+// ListInferiors lists the processes being debugged, the current
+// inferior is the one all other requests refer to, it can be changed with
+// the SwitchInferior command.
+func (s *RPCServer) ListInferiors(arg ListInferiorsIn, out *ListInferiorsOut) error {
+	out.Inferiors, out.FollowForkMode = s.debugger.Inferiors()
+	return nil
+}
+
+type ListTargetsIn struct {
+}
+
+type ListTargetsOut struct {
+	Targets []api.Target
+}
+
+// ListTargets lists the programs being debugged. The current target is the
+// one all other requests refer to, it can be changed with the SwitchTarget
+// command. Only the current target is resumed by Command, the others stay
+// stopped.
+func (s *RPCServer) ListTargets(arg ListTargetsIn, out *ListTargetsOut) error {
+	out.Targets = s.debugger.Targets()
+	return nil
+}
+
+type AttachTargetIn struct {
+	Pid int
+	// Path is the executable of the process, only needed by the lldb
+	// backend on macOS.
+	Path string
+}
+
+type AttachTargetOut struct {
+	Target api.Target
+}
+
+// AttachTarget attaches to an existing process and adds it to the programs
+// being debugged, as the current target.
+func (s *RPCServer) AttachTarget(arg AttachTargetIn, out *AttachTargetOut) error {
+	var err error
+	out.Target, err = s.debugger.AttachTarget(arg.Pid, arg.Path)
+	return err
+}
+
+type LaunchTargetIn struct {
+	// ProcessArgs are the executable and the arguments of the new process.
+	ProcessArgs []string
+	WorkingDir  string
+}
+
+type LaunchTargetOut struct {
+	Target api.Target
+}
+
+// LaunchTarget starts a new process and adds it to the programs being
+// debugged, as the current target.
+func (s *RPCServer) LaunchTarget(arg LaunchTargetIn, out *LaunchTargetOut) error {
+	var err error
+	out.Target, err = s.debugger.LaunchTarget(arg.ProcessArgs, arg.WorkingDir)
+	return err
+}
+
+type SetStepFilterIn struct {
+	// Packages is a list of package path patterns, "..." matches any string.
+	Packages []string
+	// Files is a list of file globs.
+	Files []string
+}
+
+type SetStepFilterOut struct {
+}
+
+// SetStepFilter replaces the list of packages and files that step and
+// stepout treat as opaque: calls to functions in them are stepped over
+// and, when returning from a function into one of them, execution
+// continues until a frame that isn't filtered is reached.
+// Globs in arg.Files that do not contain a '/' are matched against the
+// base name of the file.
+func (s *RPCServer) SetStepFilter(arg SetStepFilterIn, out *SetStepFilterOut) error {
+	return s.debugger.SetStepFilter(arg.Packages, arg.Files)
+}
+
+type AllowFunctionCallsIn struct {
+	Allow bool
+}
+
+type AllowFunctionCallsOut struct {
+}
+
+// AllowFunctionCalls changes whether the expressions evaluated by
+// EvalVariable on the selected goroutine, and breakpoint conditions, can
+// call functions.
+func (s *RPCServer) AllowFunctionCalls(arg AllowFunctionCallsIn, out *AllowFunctionCallsOut) error {
+	return s.debugger.AllowFunctionCalls(arg.Allow)
+}
+
 type ClearBreakpointIn struct {
 	Id   int
 	Name string
@@ -401,10 +622,16 @@ type EvalIn struct {
 	Scope api.EvalScope
 	Expr  string
 	Cfg   *api.LoadConfig
+	// Record appends the value to the value history.
+	Record bool
 }
 
 type EvalOut struct {
 	Variable *api.Variable
+	// HistoryIndex is the index of the value in the value history, it can
+	// be referred to as $HistoryIndex in later expressions. Only set if
+	// Record was.
+	HistoryIndex int
 }
 
 // EvalVariable returns a variable in the specified context.
@@ -416,7 +643,15 @@ func (s *RPCServer) Eval(arg EvalIn, out *EvalOut) error {
 	if cfg == nil {
 		cfg = &api.LoadConfig{true, 1, 64, 64, -1}
 	}
-	v, err := s.debugger.EvalVariableInScope(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg))
+	var (
+		v   *api.Variable
+		err error
+	)
+	if arg.Record {
+		v, out.HistoryIndex, err = s.debugger.EvalAndRecordInScope(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg))
+	} else {
+		v, err = s.debugger.EvalVariableInScope(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg))
+	}
 	if err != nil {
 		return err
 	}
@@ -435,10 +670,67 @@ type SetOut struct {
 
 // Set sets the value of a variable. Only numerical types and
 // pointers are currently supported.
+// If arg.Symbol is a convenience variable, $name, arg.Value is evaluated
+// and saved as $name, it can be of any type.
+// If arg.Symbol is a register, e.g. $rax, the register of the topmost frame
+// of arg.Scope is changed.
 func (s *RPCServer) Set(arg SetIn, out *SetOut) error {
 	return s.debugger.SetVariableInScope(arg.Scope, arg.Symbol, arg.Value)
 }
 
+type ListConvenienceVariablesIn struct {
+}
+
+type ListConvenienceVariablesOut struct {
+	Variables []api.Variable
+}
+
+// ListConvenienceVariables lists the value history, $1, $2..., followed
+// by the convenience variables set with Set.
+func (s *RPCServer) ListConvenienceVariables(arg ListConvenienceVariablesIn, out *ListConvenienceVariablesOut) error {
+	out.Variables = s.debugger.ConvenienceVariables()
+	return nil
+}
+
+type ClearConvenienceVariablesIn struct {
+	// Name is the convenience variable to remove, if empty all
+	// convenience variables and the value history are removed.
+	Name string
+}
+
+type ClearConvenienceVariablesOut struct {
+}
+
+// ClearConvenienceVariables removes convenience variables.
+func (s *RPCServer) ClearConvenienceVariables(arg ClearConvenienceVariablesIn, out *ClearConvenienceVariablesOut) error {
+	return s.debugger.ClearConvenienceVariables(arg.Name)
+}
+
+type ExamineMemoryIn struct {
+	Scope api.EvalScope
+	// Expr is an expression evaluating to the address of the memory to
+	// read: an integer or a pointer.
+	Expr   string
+	Length int
+}
+
+type ExamineMemoryOut struct {
+	Address uint64
+	Mem     []byte
+}
+
+// ExamineMemory reads arg.Length bytes of memory starting at the address
+// arg.Expr evaluates to in arg.Scope.
+func (s *RPCServer) ExamineMemory(arg ExamineMemoryIn, out *ExamineMemoryOut) error {
+	addr, mem, err := s.debugger.ExamineMemory(arg.Scope, arg.Expr, arg.Length)
+	if err != nil {
+		return err
+	}
+	out.Address = addr
+	out.Mem = mem
+	return nil
+}
+
 type ListSourcesIn struct {
 	Filter string
 }
@@ -475,6 +767,70 @@ func (s *RPCServer) ListFunctions(arg ListFunctionsIn, out *ListFunctionsOut) er
 	return nil
 }
 
+type FunctionReturnLocationsIn struct {
+	// FnName is the name of the function for which all
+	// return locations should be given.
+	FnName string
+}
+
+type FunctionReturnLocationsOut struct {
+	// Addrs is the list of all locations where the given function returns.
+	Addrs []uint64
+}
+
+// FunctionReturnLocations returns the addresses of all the return
+// instructions of the function called FnName.
+func (s *RPCServer) FunctionReturnLocations(in FunctionReturnLocationsIn, out *FunctionReturnLocationsOut) error {
+	addrs, err := s.debugger.FunctionReturnLocations(in.FnName)
+	if err != nil {
+		return err
+	}
+	out.Addrs = addrs
+	return nil
+}
+
+type JumpIn struct {
+	// Loc is a location specification, see Documentation/cli/locspec.md.
+	Loc string
+}
+
+type JumpOut struct {
+	State api.DebuggerState
+}
+
+// Jump changes the PC of the selected goroutine to the location specified
+// by arg.Loc, without executing the code in between, and returns the new
+// state. The location must be a line of the current function after its
+// prologue. Not supported on recordings and core files.
+func (s *RPCServer) Jump(arg JumpIn, out *JumpOut) error {
+	st, err := s.debugger.Jump(arg.Loc)
+	if err != nil {
+		return err
+	}
+	out.State = *st
+	return nil
+}
+
+type StepTargetsIn struct {
+}
+
+type StepTargetsOut struct {
+	// Targets is the list of functions called by the current line, in order.
+	Targets []api.Function
+}
+
+// StepTargets returns the functions called by the instructions of the
+// current line that have not been executed yet. Each of them can be passed,
+// by name or by index, as the StepTarget of a Step command.
+func (s *RPCServer) StepTargets(arg StepTargetsIn, out *StepTargetsOut) error {
+	targets, err := s.debugger.StepTargets()
+	if err != nil {
+		return err
+	}
+	out.Targets = targets
+	return nil
+}
+
 type ListTypesIn struct {
 	Filter string
 }
@@ -519,9 +875,7 @@ type AttachedToExistingProcessOut struct {
 
 // AttachedToExistingProcess returns whether we attached to a running process or not
 func (c *RPCServer) AttachedToExistingProcess(arg AttachedToExistingProcessIn, out *AttachedToExistingProcessOut) error {
-	if c.config.AttachPid != 0 {
-		out.Answer = true
-	}
+	out.Answer = c.debugger.AttachedToExistingProcess()
 	return nil
 }
 
@@ -116,6 +116,11 @@ func (s *ServerImpl) Run() error {
 		WorkingDir:  s.config.WorkingDir,
 		CoreFile:    s.config.CoreFile,
 		Backend:     s.config.Backend,
+		ExecuteKind: s.config.ExecuteKind,
+		Package:     s.config.Package,
+		BuildFlags:  s.config.BuildFlags,
+
+		PrettyPrinters: s.config.PrettyPrinters,
 	}); err != nil {
 		return err
 	}
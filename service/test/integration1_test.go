@@ -572,7 +572,9 @@ func Test1ClientServer_FindLocations(t *testing.T) {
 		findLocationHelper(t, c, "sprog.go:26", true, 0, 0)
 
 		findLocationHelper(t, c, "String", true, 0, 0)
-		findLocationHelper(t, c, "main.String", true, 0, 0)
+		if addrs := findLocationHelper(t, c, "main.String", false, -1, 0); len(addrs) < 2 {
+			t.Fatalf("wrong locations for main.String: %#x", addrs)
+		}
 
 		someTypeStringFuncAddr := findLocationHelper(t, c, "locationsprog.go:14", false, 1, 0)[0]
 		otherTypeStringFuncAddr := findLocationHelper(t, c, "locationsprog.go:18", false, 1, 0)[0]
@@ -1,8 +1,10 @@
 package service_test
 
 import (
+	"encoding/binary"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"os"
@@ -15,9 +17,11 @@ import (
 
 	protest "github.com/derekparker/delve/pkg/proc/test"
 
+	"github.com/derekparker/delve/pkg/gobuild"
 	"github.com/derekparker/delve/pkg/goversion"
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
+	"github.com/derekparker/delve/service/debugger"
 	"github.com/derekparker/delve/service/rpc2"
 	"github.com/derekparker/delve/service/rpccommon"
 )
@@ -624,7 +628,10 @@ func TestClientServer_FindLocations(t *testing.T) {
 		findLocationHelper(t, c, "sprog.go:26", true, 0, 0)
 
 		findLocationHelper(t, c, "String", true, 0, 0)
-		findLocationHelper(t, c, "main.String", true, 0, 0)
+		// every match of an ambiguous location is returned
+		if addrs := findLocationHelper(t, c, "main.String", false, -1, 0); len(addrs) < 2 {
+			t.Fatalf("wrong locations for main.String: %#x", addrs)
+		}
 
 		someTypeStringFuncAddr := findLocationHelper(t, c, "locationsprog.go:14", false, 1, 0)[0]
 		otherTypeStringFuncAddr := findLocationHelper(t, c, "locationsprog.go:18", false, 1, 0)[0]
@@ -779,6 +786,48 @@ func TestClientServer_SetVariable(t *testing.T) {
 	})
 }
 
+func TestClientServer_ConvenienceVariables(t *testing.T) {
+	withTestClient2("testvariables", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		assertValue := func(expr, value string) {
+			v, err := c.EvalVariable(api.EvalScope{-1, 0}, expr, normalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%q)", expr))
+			if v.Value != value {
+				t.Fatalf("wrong value for %s: %q, expected %q", expr, v.Value, value)
+			}
+		}
+
+		_, n, err := c.EvalAndRecord(api.EvalScope{-1, 0}, "a2", normalLoadConfig)
+		assertNoError(err, t, "EvalAndRecord()")
+		if n != 1 {
+			t.Fatalf("wrong history index %d", n)
+		}
+		assertNoError(c.SetVariable(api.EvalScope{-1, 0}, "$old", "a2 * 2"), t, "SetVariable($old)")
+		assertNoError(c.SetVariable(api.EvalScope{-1, 0}, "a2", "8"), t, "SetVariable(a2)")
+
+		// values are not changed by the program
+		assertValue("$1", "6")
+		assertValue("a2 - $1", "2")
+		assertValue("$old", "12")
+
+		if err := c.SetVariable(api.EvalScope{-1, 0}, "$1", "2"); err == nil {
+			t.Fatal("could assign to the value history")
+		}
+
+		vars, err := c.ListConvenienceVariables()
+		assertNoError(err, t, "ListConvenienceVariables()")
+		if len(vars) != 2 || vars[0].Name != "$1" || vars[1].Name != "$old" {
+			t.Fatalf("wrong convenience variables %v", vars)
+		}
+		assertNoError(c.ClearConvenienceVariables(""), t, "ClearConvenienceVariables()")
+		if _, err := c.EvalVariable(api.EvalScope{-1, 0}, "$old", normalLoadConfig); err == nil {
+			t.Fatal("convenience variables not cleared")
+		}
+	})
+}
+
 func TestClientServer_FullStacktrace(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestClient2("goroutinestackprog", t, func(c service.Client) {
@@ -1065,6 +1114,141 @@ func TestClientServer_CondBreakpoint(t *testing.T) {
 	})
 }
 
+func TestClientServer_HitCondBreakpoint(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("bpcountstest", t, func(c service.Client) {
+		fp := testProgPath(t, "bpcountstest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 12})
+		assertNoError(err, t, "CreateBreakpoint()")
+		bp.HitCond = ">=   5"
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		if bp.HitCond != ">= 5" {
+			t.Fatalf("Wrong hit condition on breakpoint %#v", bp)
+		}
+
+		for i := 5; i < 8; i++ {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue()")
+			bp, err = c.GetBreakpoint(bp.ID)
+			assertNoError(err, t, "GetBreakpoint()")
+			if bp.TotalHitCount != uint64(i) {
+				t.Fatalf("Wrong TotalHitCount for the breakpoint %d (expected %d)", bp.TotalHitCount, i)
+			}
+		}
+
+		bp.HitCond = "hello"
+		if err := c.AmendBreakpoint(bp); err == nil {
+			t.Fatalf("AmendBreakpoint() with an invalid hit condition did not return an error")
+		}
+	})
+}
+
+func TestClientServer_MultiAddrBreakpoint(t *testing.T) {
+	// the header of a for loop is compiled into more than one range of
+	// instructions, all of them should belong to the same breakpoint.
+	protest.AllowRecording(t)
+	withTestClient2("bpcountstest", t, func(c service.Client) {
+		fp := testProgPath(t, "bpcountstest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 11})
+		assertNoError(err, t, "CreateBreakpoint()")
+		if len(bp.Addrs) < 2 || bp.Addrs[0] != bp.Addr {
+			t.Fatalf("Wrong addresses for breakpoint %#v", bp)
+		}
+
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		n := 0
+		for _, bp2 := range bps {
+			if bp2.ID == bp.ID {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Fatalf("Breakpoint %d listed %d times", bp.ID, n)
+		}
+
+		bp.Cond = "id == 1"
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("Not stopped at breakpoint %d: %#v", bp.ID, state.CurrentThread)
+		}
+		if state.CurrentThread.Breakpoint.Cond != "id == 1" {
+			t.Fatalf("Condition not set on all addresses: %#v", state.CurrentThread.Breakpoint)
+		}
+
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		if bp.TotalHitCount != 1 {
+			t.Fatalf("Wrong TotalHitCount %d", bp.TotalHitCount)
+		}
+
+		_, err = c.ClearBreakpoint(bp.ID)
+		assertNoError(err, t, "ClearBreakpoint()")
+		bps, err = c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, bp2 := range bps {
+			if bp2.ID == bp.ID {
+				t.Fatalf("Breakpoint %d not cleared", bp.ID)
+			}
+		}
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("Process did not exit after clearing the breakpoint: %#v", state)
+		}
+	})
+}
+
+func TestClientServer_DisableBreakpoint(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("bpcountstest", t, func(c service.Client) {
+		fp := testProgPath(t, "bpcountstest")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 12, Name: "loop"})
+		assertNoError(err, t, "CreateBreakpoint()")
+		bp2, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 17})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("Not stopped at breakpoint %d: %#v", bp.ID, state.CurrentThread)
+		}
+
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		bp.Cond = "id == 1"
+		bp.Disabled = true
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp2.ID {
+			t.Fatalf("Not stopped at breakpoint %d: %#v", bp2.ID, state.CurrentThread)
+		}
+
+		bp, err = c.GetBreakpointByName("loop")
+		assertNoError(err, t, "GetBreakpointByName()")
+		if !bp.Disabled || bp.Cond != "id == 1" || bp.TotalHitCount != 1 {
+			t.Fatalf("Wrong disabled breakpoint %#v", bp)
+		}
+
+		bp.Disabled = false
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		if bp.Disabled || bp.Name != "loop" || bp.Cond != "id == 1" || bp.TotalHitCount != 1 {
+			t.Fatalf("Wrong enabled breakpoint %#v", bp)
+		}
+
+		_, err = c.ClearBreakpoint(bp.ID)
+		assertNoError(err, t, "ClearBreakpoint()")
+	})
+}
+
 func TestSkipPrologue(t *testing.T) {
 	withTestClient2("locationsprog2", t, func(c service.Client) {
 		<-c.Continue()
@@ -1341,6 +1525,43 @@ func TestClientServer_ReverseContinue(t *testing.T) {
 	})
 }
 
+func TestClientServer_ReverseStepping(t *testing.T) {
+	protest.AllowRecording(t)
+	if testBackend != "rr" {
+		t.Skip("backend is not rr")
+	}
+	withTestClient2("steptarget", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{Addr: findLocationHelper(t, c, "steptarget.go:23", false, 1, 0)[0]})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		assertLocation := func(state *api.DebuggerState, err error, op, fn string, line int) {
+			assertNoError(err, t, op)
+			th := state.CurrentThread
+			if th.Function == nil || th.Function.Name != fn || (line > 0 && th.Line != line) {
+				t.Fatalf("%s: wrong location %s:%d, expected %s:%d", op, th.File, th.Line, fn, line)
+			}
+		}
+
+		state, err = c.ReverseNext()
+		assertLocation(state, err, "ReverseNext()", "main.main", 22)
+		state, err = c.ReverseStep()
+		assertLocation(state, err, "ReverseStep()", "main.handle", 0)
+		state, err = c.ReverseStepOut()
+		assertLocation(state, err, "ReverseStepOut()", "main.main", 22)
+		pc := state.CurrentThread.PC
+		state, err = c.ReverseStepInstruction()
+		assertLocation(state, err, "ReverseStepInstruction()", "main.main", 22)
+		if state.CurrentThread.PC >= pc {
+			t.Fatalf("ReverseStepInstruction() did not go backwards %#x %#x", pc, state.CurrentThread.PC)
+		}
+
+		state, err = c.Next()
+		assertLocation(state, err, "Next()", "main.main", 23)
+	})
+}
+
 func TestClientServer_collectBreakpointInfoOnNext(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestClient2("testnextprog", t, func(c service.Client) {
@@ -1387,3 +1608,193 @@ func TestClientServer_collectBreakpointInfoError(t *testing.T) {
 		assertNoError(state.Err, t, "Continue()")
 	})
 }
+
+func TestClientServer_Logpoint(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("bpcountstest", t, func(c service.Client) {
+		fp := testProgPath(t, "bpcountstest")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 14, Tracepoint: true, LogMessage: "demo %d slept %d ms (%v)", Variables: []string{"id", "sleep", "wait"}})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		th := state.CurrentThread
+		if th.BreakpointInfo == nil {
+			t.Fatalf("no breakpoint info")
+		}
+		var id, sleep int
+		var rest string
+		if _, err := fmt.Sscanf(th.BreakpointInfo.LogMessage, "demo %d slept %d ms %s", &id, &sleep, &rest); err != nil {
+			t.Fatalf("wrong log message %q: %v", th.BreakpointInfo.LogMessage, err)
+		}
+		if (id != 1 && id != 2) || sleep < 1 || sleep > 10 {
+			t.Fatalf("wrong log message %q", th.BreakpointInfo.LogMessage)
+		}
+	})
+}
+
+func TestClientServer_RestartRebuild(t *testing.T) {
+	if testBackend == "rr" {
+		t.Skip("can not rebuild a recording")
+	}
+	const src = `package main
+
+import "fmt"
+
+func f() int {
+	return 1
+}
+
+func main() {
+	fmt.Println(f())
+}
+`
+	dir, err := ioutil.TempDir("", "rebuild")
+	assertNoError(err, t, "TempDir()")
+	defer os.RemoveAll(dir)
+	srcfile := filepath.Join(dir, "main.go")
+	exefile := filepath.Join(dir, "debug")
+	assertNoError(ioutil.WriteFile(srcfile, []byte(src), 0666), t, "WriteFile()")
+	assertNoError(gobuild.GoBuild(exefile, srcfile, ""), t, "GoBuild()")
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	assertNoError(err, t, "Listen()")
+	defer listener.Close()
+	server := rpccommon.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: []string{exefile},
+		Backend:     testBackend,
+		ExecuteKind: debugger.ExecutingGeneratedFile,
+		Package:     srcfile,
+	}, false)
+	assertNoError(server.Run(), t, "server.Run()")
+	c := rpc2.NewClient(listener.Addr().String())
+	defer c.Detach(true)
+
+	fbp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.f", Line: -1, LocationSpec: "main.f"})
+	assertNoError(err, t, "CreateBreakpoint(main.f)")
+	if fbp.Line != 6 {
+		t.Fatalf("breakpoint on main.f set at line %d, expected 6", fbp.Line)
+	}
+	lbp, err := c.CreateBreakpoint(&api.Breakpoint{File: srcfile, Line: 10, LocationSpec: srcfile + ":10"})
+	assertNoError(err, t, "CreateBreakpoint(main.go:10)")
+
+	// Move everything down by two lines, line 10 becomes empty.
+	newsrc := strings.Replace(src, "import \"fmt\"\n", "import \"fmt\"\n\nvar x = 0\n", 1)
+	assertNoError(ioutil.WriteFile(srcfile, []byte(newsrc), 0666), t, "WriteFile()")
+
+	discarded, err := c.RestartRebuild()
+	assertNoError(err, t, "RestartRebuild()")
+	if len(discarded) != 1 || discarded[0].Breakpoint.ID != lbp.ID {
+		t.Fatalf("wrong discarded breakpoints %v", discarded)
+	}
+
+	state := <-c.Continue()
+	assertNoError(state.Err, t, "Continue()")
+	if state.CurrentThread.Line != 8 {
+		t.Fatalf("stopped at line %d, expected 8", state.CurrentThread.Line)
+	}
+}
+
+func TestClientServer_RestartRebuildExistingFile(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("continuetestprog", t, func(c service.Client) {
+		if _, err := c.RestartRebuild(); err == nil {
+			t.Fatal("expected error rebuilding an executable not built by delve")
+		}
+	})
+}
+
+func TestClientServer_Targets(t *testing.T) {
+	if testBackend == "rr" {
+		t.Skip("can not launch new targets while replaying a recording")
+	}
+	withTestClient2("continuetestprog", t, func(c service.Client) {
+		fixture := protest.BuildFixture("testnextprog", 0)
+		tgt, err := c.LaunchTarget([]string{fixture.Path}, "")
+		assertNoError(err, t, "LaunchTarget()")
+		if tgt.ID != 2 || !tgt.Current {
+			t.Fatalf("wrong target %#v", tgt)
+		}
+
+		targets, err := c.ListTargets()
+		assertNoError(err, t, "ListTargets()")
+		if len(targets) != 2 || targets[0].Current || !targets[1].Current {
+			t.Fatalf("wrong targets %#v", targets)
+		}
+
+		// breakpoints are created in the current target
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.TargetID != 2 || state.CurrentThread.TargetID != 2 || state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("not stopped at breakpoint in target 2: %#v", state)
+		}
+		if state.SelectedGoroutine == nil || state.SelectedGoroutine.TargetID != 2 {
+			t.Fatalf("wrong selected goroutine: %#v", state.SelectedGoroutine)
+		}
+
+		state, err = c.SwitchTarget(1)
+		assertNoError(err, t, "SwitchTarget()")
+		if state.TargetID != 1 {
+			t.Fatalf("wrong target after switch %d", state.TargetID)
+		}
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, bp := range bps {
+			if bp.FunctionName == "main.helloworld" {
+				t.Fatalf("breakpoint of target 2 listed in target 1: %#v", bp)
+			}
+		}
+
+		// breakpoint IDs are unique across targets
+		bp1, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		if bp1.ID == bp.ID {
+			t.Fatalf("breakpoints of different targets have the same ID %d", bp.ID)
+		}
+		_, err = c.ClearBreakpoint(bp1.ID)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		// only the current target is resumed
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("target 1 did not exit: %#v", state)
+		}
+		state, err = c.SwitchTarget(2)
+		assertNoError(err, t, "SwitchTarget()")
+		if state.CurrentThread == nil || state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("target 2 was resumed: %#v", state)
+		}
+	})
+}
+
+func TestClientServer_ExamineMemory(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		i1, err := c.EvalVariable(api.EvalScope{-1, 0}, "&i1", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable()")
+
+		addr, mem, err := c.ExamineMemory(api.EvalScope{-1, 0}, "&i1", 8)
+		assertNoError(err, t, "ExamineMemory()")
+		if addr != uint64(i1.Children[0].Addr) {
+			t.Fatalf("wrong address %#x, expected %#x", addr, i1.Children[0].Addr)
+		}
+		if n := binary.LittleEndian.Uint64(mem); n != 1 {
+			t.Fatalf("wrong memory contents %v", mem)
+		}
+
+		addr2, _, err := c.ExamineMemory(api.EvalScope{-1, 0}, fmt.Sprintf("%#x", addr), 8)
+		assertNoError(err, t, "ExamineMemory(integer)")
+		if addr2 != addr {
+			t.Fatalf("wrong address %#x, expected %#x", addr2, addr)
+		}
+
+		if _, _, err := c.ExamineMemory(api.EvalScope{-1, 0}, "str1", 8); err == nil {
+			t.Fatalf("ExamineMemory of a string did not fail")
+		}
+	})
+}
@@ -2,11 +2,13 @@ package service_test
 
 import (
 	"fmt"
+	"go/parser"
 	"runtime"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/derekparker/delve/pkg/config"
 	"github.com/derekparker/delve/pkg/goversion"
 	"github.com/derekparker/delve/pkg/proc"
 	"github.com/derekparker/delve/pkg/proc/gdbserial"
@@ -825,6 +827,134 @@ func TestUnsafePointer(t *testing.T) {
 	})
 }
 
+func TestPrettyPrinters(t *testing.T) {
+	_, err := api.NewPrettyPrinters([]config.PrettyPrinter{{Type: "main.astruct", Display: "{$v.A"}})
+	if err == nil {
+		t.Fatalf("unbalanced braces accepted")
+	}
+
+	pps, err := api.NewPrettyPrinters([]config.PrettyPrinter{
+		{Type: "main.astruct", Display: "<{$v.A}, {$v.B}>"},
+		{Type: "main.bstruct", Children: []config.PrettyPrinterChild{{Name: "sum", Expr: "$v.a.A + $v.a.B"}}},
+	})
+	assertNoError(err, t, "NewPrettyPrinters()")
+
+	protest.AllowRecording(t)
+	withTestProcess("testvariables2", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(proc.Continue(p), t, "Continue() returned an error")
+		for _, tc := range []struct {
+			expr, tgt string
+		}{
+			{"as1", "<1, 1>"},
+			{"*c1.pb", "main.bstruct {sum: 3}"},
+			{"s2[:2]", "[]main.astruct len: 2, cap: 8, [<1, 2>,<3, 4>]"},
+		} {
+			v, err := evalVariable(p, tc.expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if out := pps.ConvertVar(v).SinglelineString(); out != tc.tgt {
+				t.Errorf("%s: expected %q got %q", tc.expr, tc.tgt, out)
+			}
+		}
+	})
+}
+
+func TestStdlibDisplay(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("stdlibvars", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(proc.Continue(p), t, "Continue() returned an error")
+		for _, tc := range []struct {
+			expr, tgt string
+		}{
+			{"t1", "2017-10-16T12:00:00Z"},
+			{"t2", "2017-10-16T12:00:00.0000005+02:00"},
+			{"d1", "1.5s"},
+			{"b1", "*-123456789012345678901234567890"},
+			{"ip1", "192.168.0.1"},
+			{"ipnet1", "*10.0.0.0/8"},
+			{"mu1", "locked"},
+			{"mu2", "unlocked"},
+			{"buf1", `"ello world"`},
+		} {
+			v, err := evalVariable(p, tc.expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			av := api.ConvertVar(v)
+			if out := av.SinglelineString(); out != tc.tgt {
+				t.Errorf("%s: expected %q got %q", tc.expr, tc.tgt, out)
+			}
+			av.ClearDisplay()
+			if out := av.SinglelineString(); out == tc.tgt {
+				t.Errorf("%s: raw value printed as %q", tc.expr, out)
+			}
+		}
+
+		// the load configuration limits what is read and displayed
+		cfg := proc.LoadConfig{true, 1, 10, 2, -1}
+		v, err := evalVariable(p, "b1", cfg)
+		assertNoError(err, t, "EvalVariable(b1)")
+		if out := api.ConvertVar(v).SinglelineString(); out != "*-123456789...+21 more" {
+			t.Errorf("b1: expected truncated value got %q", out)
+		}
+		v, err = evalVariable(p, "ip1", cfg)
+		assertNoError(err, t, "EvalVariable(ip1)")
+		if v.Display != "" {
+			t.Errorf("ip1: displayed %q with MaxArrayValues %d", v.Display, cfg.MaxArrayValues)
+		}
+	})
+}
+
+func TestChanContents(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("chanstate", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(proc.Continue(p), t, "Continue() returned an error")
+
+		field := func(v *api.Variable, name string) *api.Variable {
+			for i := range v.Children {
+				if v.Children[i].Name == name {
+					return &v.Children[i]
+				}
+			}
+			t.Fatalf("no field %s in %s", name, v.MultilineString(""))
+			return nil
+		}
+
+		v, err := evalVariable(p, "ch1", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(ch1)")
+		buf := field(api.ConvertVar(v), "buf")
+		if out := buf.SinglelineString(); out != "[3]int [2,3,4]" {
+			t.Errorf("wrong buffer of ch1 %q", out)
+		}
+
+		v, err = evalVariable(p, "ch2", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(ch2)")
+		if len(v.RecvWaiting) != 2 || len(v.SendWaiting) != 0 {
+			t.Fatalf("wrong waiting goroutines of ch2 %v %v", v.RecvWaiting, v.SendWaiting)
+		}
+		for _, id := range v.RecvWaiting {
+			g, err := proc.FindGoroutine(p, id)
+			assertNoError(err, t, fmt.Sprintf("FindGoroutine(%d)", id))
+			if !g.ChanRecvBlocked() {
+				t.Errorf("goroutine %d is not blocked receiving", id)
+			}
+		}
+
+		v, err = evalVariable(p, "ch3", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(ch3)")
+		if len(v.RecvWaiting) != 0 || len(v.SendWaiting) != 1 {
+			t.Fatalf("wrong waiting goroutines of ch3 %v %v", v.RecvWaiting, v.SendWaiting)
+		}
+
+		v, err = evalVariable(p, "ch4", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(ch4)")
+		av := api.ConvertVar(v)
+		if closed := field(av, "closed").Value; closed != "1" {
+			t.Errorf("ch4 not closed: %q", closed)
+		}
+		if buf := field(av, "buf"); buf.Len != 0 || len(buf.Children) != 0 {
+			t.Errorf("wrong buffer of ch4 %s", buf.SinglelineString())
+		}
+	})
+}
+
 type issue426TestCase struct {
 	name string
 	typ  string
@@ -928,3 +1058,140 @@ func TestPackageRenames(t *testing.T) {
 		}
 	})
 }
+
+func TestCallFunction(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("function calls are only supported by the native backend on linux")
+	}
+
+	var testcases = []struct {
+		expr string   // call expression to evaluate
+		outs []string // list of return parameters in this format: <param name>:<param type>:<param value>
+		err  string   // if not empty the expected error
+	}{
+		{"sum(one, two)", []string{":int:3"}, ""},
+		{"sum(1, 2)", []string{":int:3"}, ""},
+		{"a.add(4)", []string{":int:7"}, ""},
+		{`repeat("x", 3)`, []string{`:string:"xxx"`, ":int:3"}, ""},
+		{"callpanic()", []string{`~panic:interface {}:interface {}(string) "callpanic panicked"`}, ""},
+		{"sum(1)", nil, "not enough arguments"},
+		{"sum(1, 2, 3)", nil, "too many arguments"},
+		{"nonexistent()", nil, "could not find symbol value for nonexistent"},
+	}
+
+	withTestProcess("fncall", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := proc.FindFunctionLocation(p, "runtime.debugCallV1", true, 0)
+		if err != nil {
+			t.Skip("function calls not supported by this version of Go")
+		}
+		assertNoError(proc.Continue(p), t, "Continue()")
+		for _, tc := range testcases {
+			err := proc.CallFunction(p, tc.expr, &pnormalLoadConfig, true)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("call %q: expected error %q, got %v", tc.expr, tc.err, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("call %q: %v", tc.expr, err)
+			}
+
+			retvals := p.CurrentThread().Common().ReturnValues(pnormalLoadConfig)
+			if len(retvals) != len(tc.outs) {
+				t.Fatalf("call %q: wrong number of return values, expected %d got %d", tc.expr, len(tc.outs), len(retvals))
+			}
+			for i := range retvals {
+				outfields := strings.SplitN(tc.outs[i], ":", 3)
+				tgtName, tgtType, tgtValue := outfields[0], outfields[1], outfields[2]
+				if tgtName != "" && tgtName != retvals[i].Name {
+					t.Fatalf("call %q output parameter %d: expected name %q, got %q", tc.expr, i, tgtName, retvals[i].Name)
+				}
+				if retvals[i].TypeString() != tgtType {
+					t.Fatalf("call %q output parameter %d: expected type %q, got %q", tc.expr, i, tgtType, retvals[i].TypeString())
+				}
+				if cvs := api.ConvertVar(retvals[i]).SinglelineString(); cvs != tgtValue {
+					t.Fatalf("call %q output parameter %d: expected value %q, got %q", tc.expr, i, tgtValue, cvs)
+				}
+			}
+		}
+	})
+}
+
+func TestCallFunctionAllowed(t *testing.T) {
+	// function calls in expressions other than the argument of 'call'
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("function calls are only supported by the native backend on linux")
+	}
+	withTestProcess("fncall", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := proc.FindFunctionLocation(p, "runtime.debugCallV1", true, 0)
+		if err != nil {
+			t.Skip("function calls not supported by this version of Go")
+		}
+		assertNoError(proc.Continue(p), t, "Continue()")
+		scope, err := proc.ConvertEvalScope(p, -1, 0)
+		assertNoError(err, t, "ConvertEvalScope()")
+		if _, err := scope.EvalVariable("sum(one, two)", pnormalLoadConfig); err == nil {
+			t.Fatalf("function call allowed by default")
+		}
+
+		proc.AllowFunctionCalls(p, true)
+		for _, tc := range []struct{ expr, value string }{
+			{"sum(one, two) + 1", "4"},
+			{"a.add(sum(one, two))", "6"},
+		} {
+			scope, err := proc.ConvertEvalScope(p, -1, 0)
+			assertNoError(err, t, "ConvertEvalScope()")
+			v, err := scope.EvalVariable(tc.expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if cvs := api.ConvertVar(v).SinglelineString(); cvs != tc.value {
+				t.Fatalf("%s: expected %s got %s", tc.expr, tc.value, cvs)
+			}
+		}
+	})
+}
+
+func TestCallFunctionCondition(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("function calls are only supported by the native backend on linux")
+	}
+	for _, tc := range []struct {
+		cond string
+		hit  bool
+	}{
+		{"sum(n, 1) == 3", true},
+		{"sum(n, 1) == 4", false},
+	} {
+		withTestProcess("fncall", t, func(p proc.Process, fixture protest.Fixture) {
+			_, err := proc.FindFunctionLocation(p, "runtime.debugCallV1", true, 0)
+			if err != nil {
+				t.Skip("function calls not supported by this version of Go")
+			}
+			proc.AllowFunctionCalls(p, true)
+			addr, err := proc.FindFunctionLocation(p, "main.repeat", true, 0)
+			assertNoError(err, t, "FindFunctionLocation()")
+			bp, err := p.SetBreakpoint(addr, proc.UserBreakpoint, nil)
+			assertNoError(err, t, "SetBreakpoint()")
+			bp.Cond, err = parser.ParseExpr(tc.cond)
+			assertNoError(err, t, "ParseExpr()")
+
+			assertNoError(proc.Continue(p), t, "Continue()") // runtime.Breakpoint
+			err = proc.Continue(p)
+			if !tc.hit {
+				if _, exited := err.(proc.ProcessExitedError); !exited {
+					t.Fatalf("%s: program did not exit: %v", tc.cond, err)
+				}
+				return
+			}
+			assertNoError(err, t, "Continue()")
+			curbp, active, err := p.CurrentThread().Breakpoint()
+			assertNoError(err, t, "condition")
+			if curbp != bp || !active {
+				t.Fatalf("%s: not stopped at the breakpoint on main.repeat: %v", tc.cond, curbp)
+			}
+			if bp.TotalHitCount != 1 {
+				t.Fatalf("%s: wrong hit count %d", tc.cond, bp.TotalHitCount)
+			}
+		})
+	}
+}